@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"invoice/internal/config"
+	"invoice/internal/services/currency"
+	"invoice/internal/services/pdf"
+
+	"github.com/spf13/cobra"
+)
+
+// batchResult is the outcome of rendering a single config file
+type batchResult struct {
+	configFile string
+	outputFile string
+	invoice    *Invoice
+	err        error
+}
+
+// Batch command - renders every invoice config in a directory
+var batchCmd = &cobra.Command{
+	Use:   "batch <dir>",
+	Short: "Generate invoices for every config file in a directory",
+	Long:  `Generate one invoice per .json/.yaml config file in a directory. Failures are reported at the end without stopping the other invoices.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputDir := cmd.Flag("output-dir").Value.String()
+		concurrency, err := cmd.Flags().GetInt("concurrency")
+		if err != nil {
+			return err
+		}
+		strict, err := cmd.Flags().GetBool("strict")
+		if err != nil {
+			return err
+		}
+		namePattern := cmd.Flag("filename").Value.String()
+		if err := config.CheckFilenamePattern(namePattern); err != nil {
+			return err
+		}
+
+		results, err := generateBatch(args[0], outputDir, concurrency, strict, namePattern)
+		if err != nil {
+			return err
+		}
+
+		failed := 0
+		for _, result := range results {
+			if result.err != nil {
+				failed++
+				fmt.Fprintf(os.Stderr, "Failed %s: %v\n", result.configFile, result.err)
+			} else {
+				recordInvoice(cmd.Flag("ledger").Value.String(), result.invoice, result.outputFile)
+				fmt.Printf("Generated %s\n", result.outputFile)
+			}
+		}
+
+		fmt.Printf("%d succeeded, %d failed\n", len(results)-failed, failed)
+		if failed > 0 {
+			return fmt.Errorf("%d of %d invoices failed", failed, len(results))
+		}
+		return nil
+	},
+}
+
+func init() {
+	batchCmd.Flags().String("output-dir", ".", "Directory to write the generated PDFs to")
+	batchCmd.Flags().Int("concurrency", 1, "Number of invoices to render in parallel")
+	batchCmd.Flags().Bool("strict", false, "Reject config files with unknown keys or wrongly typed values")
+	batchCmd.Flags().String("filename", "", "Output filename pattern, e.g. {from}-{id}-{date}.pdf (defaults to <id>.pdf)")
+	batchCmd.Flags().String("ledger", "", "Record the generated invoices in this ledger file (defaults to $"+ledgerEnv+", off if neither is set)")
+}
+
+// generateBatch renders every invoice config in dir to <id>.pdf in outputDir,
+// or to the filename given by namePattern if it is set.
+// Config files with a list of invoices produce one PDF per invoice.
+// Results are returned in config file order. In strict mode config files with
+// unknown keys or wrongly typed values fail instead of being rendered.
+func generateBatch(dir, outputDir string, concurrency int, strict bool, namePattern string) ([]batchResult, error) {
+	files, err := config.FindConfigFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list config files: %v", err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .json or .yaml config files found in %s", dir)
+	}
+
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("unable to create output directory: %v", err)
+	}
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	loader := config.NewConfigLoader()
+	if strict {
+		loader = config.NewStrictConfigLoader()
+	}
+	renderer := pdf.NewPDFRenderer(currency.NewCurrencyService())
+	renderer.SetFontData(interRegularTTF, interBoldTTF)
+
+	fileResults := make([][]batchResult, len(files))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fileResults[i] = renderBatchFile(loader, renderer, files[i], outputDir, namePattern)
+			}
+		}()
+	}
+
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	var results []batchResult
+	for _, r := range fileResults {
+		results = append(results, r...)
+	}
+	return results, nil
+}
+
+// renderBatchFile loads and renders the invoices of a single config file
+func renderBatchFile(loader config.ConfigLoader, renderer *pdf.PDFRenderer, configFile, outputDir, namePattern string) []batchResult {
+	invoices, err := loader.LoadInvoices(configFile)
+	if err != nil {
+		return []batchResult{{configFile: configFile, err: err}}
+	}
+
+	var results []batchResult
+	for _, invoice := range invoices {
+		result := batchResult{configFile: configFile, invoice: invoice}
+		name, err := config.FormatFilename(namePattern, invoice)
+		if err != nil {
+			result.err = err
+		} else {
+			result.outputFile = filepath.Join(outputDir, name)
+			printWarnings(invoice)
+			result.err = renderer.RenderToFile(invoice, result.outputFile)
+		}
+		results = append(results, result)
+	}
+	return results
+}
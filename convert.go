@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"invoice/internal/config"
+	"invoice/internal/models"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Convert command - turns an accepted quote into an invoice config
+var convertCmd = &cobra.Command{
+	Use:   "convert <quote>",
+	Short: "Turn a quote config into an invoice config",
+	Long:  `Write a copy of a quote config (.json/.yaml) as another document type, usually an invoice once the customer accepted the quote. The copy gets a new number and today's date, every other field such as the items and the footer is kept.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		documentType := cmd.Flag("to").Value.String()
+		id := cmd.Flag("id").Value.String()
+		output := cmd.Flag("output").Value.String()
+
+		written, err := convertDocument(args[0], documentType, id, output)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Converted %s to %s\n", args[0], written)
+		return nil
+	},
+}
+
+func init() {
+	convertCmd.Flags().String("to", models.DocumentInvoice, "Document type to convert to: invoice, credit-note, quote or reminder")
+	convertCmd.Flags().String("id", time.Now().Format("20060102"), "Number of the new document")
+	convertCmd.Flags().StringP("output", "o", "", "Config file to write (defaults to <id> next to the quote, with its extension)")
+}
+
+// convertDocument writes a copy of the config at path with the given document
+// type, id and today's date, and returns the path it was written to. Existing
+// files are never overwritten.
+func convertDocument(path, documentType, id, output string) (string, error) {
+	switch documentType {
+	case models.DocumentInvoice, models.DocumentCreditNote, models.DocumentQuote, models.DocumentReminder:
+	default:
+		return "", fmt.Errorf("unknown document type %q (supported: %s, %s, %s, %s)", documentType,
+			models.DocumentInvoice, models.DocumentCreditNote, models.DocumentQuote, models.DocumentReminder)
+	}
+
+	// The id names the new config file, so it takes the same allowlist as web requests
+	if id == "" {
+		return "", fmt.Errorf("the new document needs an --id")
+	}
+	request := models.InvoiceRequest{Id: id}
+	if err := request.ValidateIds(); err != nil {
+		return "", err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file: %v", err)
+	}
+
+	var fileType string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		fileType = "json"
+	case ".yaml", ".yml":
+		fileType = "yaml"
+	default:
+		return "", fmt.Errorf("unsupported file type: only .json, .yaml, or .yml are supported")
+	}
+
+	// JSON is valid YAML, so this reads the type of either format
+	var source struct {
+		DocumentType string `yaml:"documentType"`
+	}
+	if err := yaml.Unmarshal(data, &source); err != nil {
+		return "", fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	if source.DocumentType != models.DocumentQuote {
+		fmt.Fprintf(os.Stderr, "Warning: %s is not a quote (documentType %q)\n", path, source.DocumentType)
+	}
+
+	converted, err := config.SetFields(data, fileType, map[string]string{
+		"documentType": documentType,
+		"id":           id,
+		"date":         time.Now().Format(models.DefaultDateFormat),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to convert %s: %v", path, err)
+	}
+
+	if output == "" {
+		output = filepath.Join(filepath.Dir(path), id+filepath.Ext(path))
+	}
+	out, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return "", fmt.Errorf("unable to create %s: %v", output, err)
+	}
+	defer out.Close()
+
+	if _, err := out.Write(converted); err != nil {
+		return "", fmt.Errorf("unable to write %s: %v", output, err)
+	}
+	return output, out.Close()
+}
@@ -37,12 +37,16 @@ var defaultCurrencySymbols = map[string]string{
 
 // Custom currency configuration that can be loaded from a file
 type CurrencyConfig struct {
-	Symbols map[string]string `json:"symbols"`
+	Symbols  map[string]string `json:"symbols"`
+	Decimals map[string]int    `json:"decimals,omitempty"`
 }
 
 // Global variable to store the merged currency symbols (default + custom)
 var currencySymbols = make(map[string]string)
 
+// Decimal places of currencies that don't use two, merged like the symbols
+var currencyDecimals = map[string]int{"JPY": 0, "KRW": 0}
+
 // Initialize the currency symbols map with default values
 func init() {
 	// Start with default symbols
@@ -82,8 +86,12 @@ func loadCurrencyConfig(configPath string) bool {
 	for code, symbol := range config.Symbols {
 		currencySymbols[strings.ToUpper(code)] = symbol
 	}
+	for code, decimals := range config.Decimals {
+		currencyDecimals[strings.ToUpper(code)] = decimals
+	}
 
-	fmt.Printf("Loaded custom currency symbols from %s\n", configPath)
+	// Stderr keeps stdout clean for PDFs written with --output -
+	fmt.Fprintf(os.Stderr, "Loaded custom currency symbols from %s\n", configPath)
 	return true
 }
 
@@ -107,7 +115,8 @@ func getCurrencySymbol(currency string) string {
 // Export the currency configuration to a JSON file
 func exportCurrencyConfig(configPath string) error {
 	config := CurrencyConfig{
-		Symbols: currencySymbols,
+		Symbols:  currencySymbols,
+		Decimals: currencyDecimals,
 	}
 	
 	data, err := json.MarshalIndent(config, "", "  ")
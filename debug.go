@@ -0,0 +1,20 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+)
+
+// debugLog receives diagnostic output. It stays silent unless --verbose is
+// given, so normal runs only print their results.
+var debugLog = log.New(io.Discard, "DEBUG: ", 0)
+
+// setVerbose routes the debug output to stderr, or discards it again
+func setVerbose(verbose bool) {
+	if verbose {
+		debugLog.SetOutput(os.Stderr)
+	} else {
+		debugLog.SetOutput(io.Discard)
+	}
+}
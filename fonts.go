@@ -0,0 +1,11 @@
+//go:build !embedfonts
+
+package main
+
+// Without the embedfonts tag no fonts are bundled, so a checkout without the
+// Inter files still builds. The renderer then loads the configured font paths
+// (--font, fontRegularPath) or the Inter files from disk.
+var (
+	interRegularTTF []byte
+	interBoldTTF    []byte
+)
@@ -0,0 +1,18 @@
+//go:build embedfonts
+
+package main
+
+import (
+	_ "embed"
+)
+
+// Inter fonts bundled into the binary so invoices can be rendered from any
+// working directory without the font files next to the executable. Build with
+// -tags embedfonts once the font files are in place, see fonts.go otherwise.
+var (
+	//go:embed "Inter/Inter Variable/Inter.ttf"
+	interRegularTTF []byte
+
+	//go:embed "Inter/Inter Hinted for Windows/Desktop/Inter-Bold.ttf"
+	interBoldTTF []byte
+)
@@ -3,34 +3,161 @@ package main
 import (
         "encoding/json"
         "fmt"
+        "io"
         "os"
         "path/filepath"
+        "strconv"
         "strings"
+        "time"
+
+        "invoice/internal/config"
 
         "github.com/spf13/pflag"
         "gopkg.in/yaml.v3"
 )
 
-func importData(path string, structure *Invoice, flags *pflag.FlagSet) error {
+// stdinPath is the import path that reads the config from stdin
+const stdinPath = "-"
+
+// stdinConfig holds the config read from stdin, as stdin can only be read
+// once but the imported files are read again for every invoice of a list
+var stdinConfig []byte
+
+// importData imports a single invoice from one or more files, merged in
+// order, with the flags set on the command line overriding the imported values
+func importData(paths []string, structure *Invoice, flags *pflag.FlagSet) error {
+        invoices, err := importInvoices(paths, flags)
+        if err != nil {
+                return err
+        }
+        if len(invoices) != 1 {
+                return fmt.Errorf("%s contains %d invoices, expected one", paths[len(paths)-1], len(invoices))
+        }
+
+        *structure = invoices[0]
+        return nil
+}
+
+// importInvoices imports all invoices from the last of paths, a file holding
+// either a single invoice or a list of them (a JSON array or YAML sequence).
+// Any files before it hold a single invoice each and are merged in order
+// beneath every invoice of the last file, e.g. the branding and footer in one
+// file and the items in another. As with "extends", each file wins for every
+// key it sets. The flags set on the command line override the imported values
+// of every invoice.
+func importInvoices(paths []string, flags *pflag.FlagSet) ([]Invoice, error) {
+        path := resolveImportPath(paths[len(paths)-1])
+        fileText, fileType, err := readImportFile(path)
+        if err != nil {
+                return nil, err
+        }
+
+        documents, err := config.SplitInvoices(fileText, fileType)
+        if err != nil {
+                return nil, err
+        }
+
+        strict, _ := flags.GetBool("strict")
+
+        var invoices []Invoice
+        for i, document := range documents {
+                // Errors in a list name the invoice they belong to
+                location := path
+                if len(documents) > 1 {
+                        location = fmt.Sprintf("%s (invoice %d)", path, i+1)
+                }
+
+                // The earlier files are decoded afresh for every invoice, as
+                // decoding on top of a shared base would let the invoices share
+                // its lists and maps
+                var base *Invoice
+                if len(paths) > 1 {
+                        merged, err := mergeImports(paths[:len(paths)-1], strict)
+                        if err != nil {
+                                return nil, err
+                        }
+                        base = &merged
+                }
+
+                invoice, err := decodeInvoice(document, fileType, path, location, base, flags)
+                if err != nil {
+                        return nil, err
+                }
+                invoices = append(invoices, invoice)
+        }
+
+        // Later PDFs overwrite earlier ones with the same number
+        loaded := make([]*Invoice, len(invoices))
+        for i := range invoices {
+                loaded[i] = &invoices[i]
+        }
+        config.WarnDuplicateIds(loaded, path)
+
+        return invoices, nil
+}
+
+// mergeImports decodes the files of a multi-file import in order, each on
+// top of the ones before it. The first file starts from the defaults or the
+// config it extends.
+func mergeImports(paths []string, strict bool) (Invoice, error) {
+        var merged *Invoice
+        for _, path := range paths {
+                path = resolveImportPath(path)
+                fileText, fileType, err := readImportFile(path)
+                if err != nil {
+                        return Invoice{}, err
+                }
+
+                documents, err := config.SplitInvoices(fileText, fileType)
+                if err != nil {
+                        return Invoice{}, err
+                }
+                if len(documents) != 1 {
+                        return Invoice{}, fmt.Errorf("%s contains %d invoices, only the last imported file may hold a list", path, len(documents))
+                }
+
+                layer, err := decodeLayer(documents[0], fileType, path, path, merged, strict)
+                if err != nil {
+                        return Invoice{}, err
+                }
+                merged = &layer
+        }
+        return *merged, nil
+}
+
+// resolveImportPath looks for a bare file name in the config directory
+func resolveImportPath(path string) string {
+        if path == stdinPath {
+                return path
+        }
+
         // Check if path doesn't have a directory prefix, assume it's in config dir
         if filepath.Dir(path) == "." {
-                path = filepath.Join("config", path)
+                return filepath.Join("config", path)
+        }
+        return path
+}
+
+// readImportFile reads a config file and returns its content, without a
+// UTF-8 BOM, and its format, "json" or "yaml". The path "-" reads the config
+// from stdin, its format is told by its content as there is no extension.
+func readImportFile(path string) ([]byte, string, error) {
+        if path == stdinPath {
+                return readStdinConfig()
         }
-        
+
         // Read the file
         fileText, err := os.ReadFile(path)
         if err != nil {
-                return fmt.Errorf("unable to read file: %v", err)
+                return nil, "", fmt.Errorf("unable to read file: %v", err)
         }
+        debugLog.Printf("importing %s (%d bytes)", path, len(fileText))
 
         // Remove UTF-8 BOM if present
         if len(fileText) >= 3 && fileText[0] == 0xEF && fileText[1] == 0xBB && fileText[2] == 0xBF {
                 fileText = fileText[3:]
         }
 
-        // Create temporary structure to ensure footer gets populated
-        tempStructure := DefaultInvoice()
-
         // Check file type first
         var fileType string
         if strings.HasSuffix(path, ".json") {
@@ -38,58 +165,248 @@ func importData(path string, structure *Invoice, flags *pflag.FlagSet) error {
         } else if strings.HasSuffix(path, ".yaml") || strings.HasSuffix(path, ".yml") {
                 fileType = "yaml"
         } else {
-                return fmt.Errorf("unsupported file type: only .json, .yaml, or .yml are supported")
+                return nil, "", fmt.Errorf("unsupported file type: only .json, .yaml, or .yml are supported")
         }
+        return fileText, fileType, nil
+}
 
-        // Now copy the structure after checking file type
-        *structure = tempStructure
+// readStdinConfig reads the config piped to stdin, e.g. generated by a CI job
+func readStdinConfig() ([]byte, string, error) {
+        if stdinConfig == nil {
+                fileText, err := io.ReadAll(os.Stdin)
+                if err != nil {
+                        return nil, "", fmt.Errorf("unable to read stdin: %v", err)
+                }
+                debugLog.Printf("importing stdin (%d bytes)", len(fileText))
+
+                // Remove UTF-8 BOM if present
+                if len(fileText) >= 3 && fileText[0] == 0xEF && fileText[1] == 0xBB && fileText[2] == 0xBF {
+                        fileText = fileText[3:]
+                }
+                if len(strings.TrimSpace(string(fileText))) == 0 {
+                        return nil, "", fmt.Errorf("no config on stdin")
+                }
+                stdinConfig = fileText
+        }
+        return stdinConfig, config.DetectFormat(stdinConfig), nil
+}
+
+// decodeInvoice decodes a single invoice imported from path on top of base,
+// or if base is nil the defaults or the base config it extends, and applies
+// the command line flags
+func decodeInvoice(fileText []byte, fileType, path, location string, base *Invoice, flags *pflag.FlagSet) (Invoice, error) {
+        strict, _ := flags.GetBool("strict")
+
+        structure, err := decodeLayer(fileText, fileType, path, location, base, strict)
+        if err != nil {
+                return structure, err
+        }
+
+        // Environment variables such as INVOICE_CURRENCY override the file,
+        // command line flags override both
+        if err := config.NewConfigLoader().ApplyEnvironmentVariables(&structure); err != nil {
+                return structure, err
+        }
+        applyFlagOverrides(&structure, flags)
+
+        // A --from or --to given on the command line replaces a structured
+        // sender or recipient
+        if flags.Changed("from") {
+                structure.Sender = nil
+        }
+        if flags.Changed("to") {
+                structure.Recipient = nil
+        }
+        structure.ApplySenderDefaults()
+
+        // Fill in recurring-invoice placeholders such as {{month}}
+        if err := config.SubstitutePlaceholders(&structure, time.Now()); err != nil {
+                return structure, err
+        }
+
+        if err := structure.NormalizeDates(); err != nil {
+                return structure, fmt.Errorf("%s: %v", location, err)
+        }
+
+        return structure, nil
+}
+
+// decodeLayer decodes a config on top of base. Without a base it starts from
+// the defaults, or the config it extends. Only the first of several imported
+// files may extend another config, as a base further down would undo the
+// files before it.
+func decodeLayer(fileText []byte, fileType, path, location string, base *Invoice, strict bool) (Invoice, error) {
+        var structure Invoice
+        if base == nil {
+                // Start from the defaults to ensure the footer gets populated
+                extended, err := config.ExtendedBase(fileText, fileType, path, strict)
+                if err != nil {
+                        return extended, fmt.Errorf("%s: %v", location, err)
+                }
+                structure = extended
+        } else {
+                if config.HasExtends(fileText, fileType) {
+                        return *base, fmt.Errorf("%s: only the first imported file may use extends", location)
+                }
+                structure = *base
+        }
+        config.ResetItems(fileText, fileType, &structure)
 
-        // Import based on file extension
-        if fileType == "json" {
+        // In strict mode typos and wrong types are errors instead of being ignored
+        if strict {
+                if err := config.DecodeStrict(fileText, fileType, &structure); err != nil {
+                        return structure, fmt.Errorf("%s: %v", location, err)
+                }
+        } else if fileType == "json" {
                 // First parse JSON into a map to validate it
                 var jsonMap map[string]interface{}
                 err := json.Unmarshal(fileText, &jsonMap)
                 if err != nil {
-                        return fmt.Errorf("invalid JSON: %v", err)
+                        return structure, fmt.Errorf("invalid JSON: %v", err)
                 }
 
                 // Now parse into our structure
-                err = json.Unmarshal(fileText, structure)
+                err = json.Unmarshal(fileText, &structure)
                 if err != nil {
-                        return fmt.Errorf("JSON structure mapping error: %v", err)
+                        return structure, fmt.Errorf("JSON structure mapping error: %v", err)
                 }
         } else if fileType == "yaml" {
-                err = yaml.Unmarshal(fileText, structure)
+                err := yaml.Unmarshal(fileText, &structure)
                 if err != nil {
-                        return fmt.Errorf("YAML parsing error: %v", err)
+                        return structure, fmt.Errorf("YAML parsing error: %v", err)
                 }
         }
 
-        // Process command line flags (these override file values)
-        var byteBuffer [][]byte
+        return structure, nil
+}
+
+// applyFlagOverrides applies the flags set on the command line on top of the
+// imported values. Each flag sets its field with its typed value, so a list
+// such as --rate replaces the imported list as a whole. Flags that don't set
+// an invoice field, like --output, are skipped. The footer flags such as
+// --bank-iban replace a single footer field and keep the rest as imported.
+func applyFlagOverrides(structure *Invoice, flags *pflag.FlagSet) {
+        stringFields := map[string]*string{
+                "id":                  &structure.Id,
+                "id-suffix":           &structure.IdSuffix,
+                "title":               &structure.Title,
+                "type":                &structure.DocumentType,
+                "language":            &structure.Language,
+                "logo":                &structure.Logo,
+                "background":          &structure.BackgroundImage,
+                "from":                &structure.From,
+                "to":                  &structure.To,
+                "ship-to":             &structure.ShipTo,
+                "customer-number":     &structure.CustomerNumber,
+                "date":                &structure.Date,
+                "due":                 &structure.Due,
+                "service-from":        &structure.ServiceDateFrom,
+                "service-to":          &structure.ServiceDateTo,
+                "paid-date":           &structure.PaidDate,
+                "discount-type":       &structure.DiscountType,
+                "currency":            &structure.Currency,
+                "rounding-mode":       &structure.RoundingMode,
+                "decimal-sep":         &structure.DecimalSeparator,
+                "thousands-sep":       &structure.ThousandsSeparator,
+                "tax-exempt-note":     &structure.TaxExemptNote,
+                "note":                &structure.Note,
+                "note-position":       &structure.NotePosition,
+                "density":             &structure.Density,
+                "table-style":         &structure.TableStyle,
+                "font":                &structure.FontRegularPath,
+                "font-bold":           &structure.FontBoldPath,
+                "footer-company":      &structure.Footer.CompanyName,
+                "footer-vat-id":       &structure.Footer.VatId,
+                "bank-name":           &structure.Footer.BankName,
+                "bank-iban":           &structure.Footer.BankIban,
+                "bank-bic":            &structure.Footer.BankBic,
+                "settlement-currency": &structure.SettlementCurrency,
+        }
+        floatFields := map[string]*float64{
+                "tax":            &structure.Tax,
+                "discount":       &structure.Discount,
+                "exchange-rate":  &structure.ExchangeRate,
+                "skonto-percent": &structure.SkontoPercent,
+        }
+        boolFields := map[string]*bool{
+                "tax-exempt":          &structure.TaxExempt,
+                "discount-before-tax": &structure.DiscountBeforeTax,
+                "prices-include-tax":  &structure.PricesIncludeTax,
+                "allow-credits":       &structure.AllowCredits,
+                "sender-rule":         &structure.ShowSenderRule,
+        }
+
         flags.Visit(func(f *pflag.Flag) {
-                var b []byte
-                if f.Value.Type() != "string" {
-                        b = []byte(fmt.Sprintf(`{"%s":%s}`, f.Name, f.Value))
+                var err error
+                if field, ok := stringFields[f.Name]; ok {
+                        *field, err = flags.GetString(f.Name)
+                } else if field, ok := floatFields[f.Name]; ok {
+                        *field, err = flags.GetFloat64(f.Name)
+                } else if field, ok := boolFields[f.Name]; ok {
+                        *field, err = flags.GetBool(f.Name)
                 } else {
-                        b = []byte(fmt.Sprintf(`{"%s":"%s"}`, f.Name, f.Value))
+                        switch f.Name {
+                        case "item":
+                                structure.Items, err = flags.GetStringSlice(f.Name)
+                        case "rate":
+                                structure.Rates, err = flags.GetFloat64Slice(f.Name)
+                        case "quantity":
+                                structure.Quantities, err = flags.GetIntSlice(f.Name)
+                        case "skonto-days":
+                                structure.SkontoDays, err = flags.GetInt(f.Name)
+                        case "append-pdf":
+                                structure.AppendPDFs, err = flags.GetStringArray(f.Name)
+                        case "line":
+                                var lines []string
+                                if lines, err = flags.GetStringArray(f.Name); err == nil {
+                                        structure.Items, structure.Quantities, structure.Rates, err = parseLines(lines)
+                                }
+                        default:
+                                return
+                        }
+                }
+
+                debugLog.Printf("applying flag override --%s", f.Name)
+                if err != nil {
+                        fmt.Fprintf(os.Stderr, "Warning: Error applying flag override --%s: %v\n", f.Name, err)
                 }
-                byteBuffer = append(byteBuffer, b)
         })
+}
+
+// parseLines splits --line values of the form "description;quantity;rate"
+// into aligned items, quantities and rates. The description may contain
+// semicolons itself, the quantity and rate are taken from the end.
+func parseLines(lines []string) ([]string, []int, []float64, error) {
+        var items []string
+        var quantities []int
+        var rates []float64
+        for i, line := range lines {
+                separator := strings.LastIndex(line, ";")
+                rest := ""
+                if separator >= 0 {
+                        rest = line[:separator]
+                }
+                quantitySeparator := strings.LastIndex(rest, ";")
+                if separator < 0 || quantitySeparator < 0 {
+                        return nil, nil, nil, fmt.Errorf("--line %d (%q): expected \"description;quantity;rate\"", i+1, line)
+                }
 
-        // Apply flag overrides without touching the footer
-        footerBackup := structure.Footer
-        for _, bytes := range byteBuffer {
-                err = json.Unmarshal(bytes, structure)
+                item := strings.TrimSpace(rest[:quantitySeparator])
+                if item == "" {
+                        return nil, nil, nil, fmt.Errorf("--line %d (%q): the description is empty", i+1, line)
+                }
+                quantity, err := strconv.Atoi(strings.TrimSpace(rest[quantitySeparator+1:]))
                 if err != nil {
-                        fmt.Fprintf(os.Stderr, "Warning: Error applying flag override: %v\n", err)
+                        return nil, nil, nil, fmt.Errorf("--line %d (%q): the quantity must be a whole number", i+1, line)
+                }
+                rate, err := strconv.ParseFloat(strings.TrimSpace(line[separator+1:]), 64)
+                if err != nil {
+                        return nil, nil, nil, fmt.Errorf("--line %d (%q): the rate must be a number", i+1, line)
                 }
-        }
 
-        // Restore footer if it was overwritten by flags
-        if structure.Footer.CompanyName == "" && footerBackup.CompanyName != "" {
-                structure.Footer = footerBackup
+                items = append(items, item)
+                quantities = append(quantities, quantity)
+                rates = append(rates, rate)
         }
-
-        return nil
-}
\ No newline at end of file
+        return items, quantities, rates, nil
+}
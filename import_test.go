@@ -0,0 +1,367 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/pflag"
+)
+
+// writeImport writes a config file into dir and returns its path
+func writeImport(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// importFlags returns the flags of generate that set invoice fields, parsed
+// from args
+func importFlags(t *testing.T, args ...string) *pflag.FlagSet {
+	t.Helper()
+	flags := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	flags.Bool("strict", false, "")
+	for _, name := range []string{"id", "from", "to", "currency", "note", "footer-company", "footer-vat-id", "bank-name", "bank-iban", "bank-bic"} {
+		flags.String(name, "", "")
+	}
+	flags.Float64("tax", 0, "")
+	flags.Float64("discount", 0, "")
+	flags.Bool("tax-exempt", false, "")
+	flags.Int("skonto-days", 0, "")
+	flags.StringSlice("item", nil, "")
+	flags.Float64Slice("rate", nil, "")
+	flags.IntSlice("quantity", nil, "")
+	flags.StringArray("line", nil, "")
+	if err := flags.Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	return flags
+}
+
+func TestImportFooterOverrides(t *testing.T) {
+	dir := t.TempDir()
+	path := writeImport(t, dir, "footer.yaml", "footer:\n  companyName: ''\n  bankName: Bank A\n  bankIban: DE00 1111\n  vatId: DE111\n")
+
+	tests := []struct {
+		name   string
+		args   []string
+		footer Footer
+	}{
+		{
+			name:   "no flags keep the imported footer, a blank company included",
+			footer: Footer{CompanyName: "", BankName: "Bank A", BankIban: "DE00 1111", VatId: "DE111"},
+		},
+		{
+			name:   "unrelated flags leave the footer alone",
+			args:   []string{"--from", "Other GmbH", "--tax", "0.07"},
+			footer: Footer{CompanyName: "", BankName: "Bank A", BankIban: "DE00 1111", VatId: "DE111"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invoice Invoice
+			if err := importData([]string{path}, &invoice, importFlags(t, tt.args...)); err != nil {
+				t.Fatalf("importData: %v", err)
+			}
+			got := invoice.Footer
+			if got.CompanyName != tt.footer.CompanyName || got.BankName != tt.footer.BankName || got.BankIban != tt.footer.BankIban || got.VatId != tt.footer.VatId {
+				t.Errorf("footer company, bank, IBAN, VAT id = %q, %q, %q, %q, want %q, %q, %q, %q",
+					got.CompanyName, got.BankName, got.BankIban, got.VatId,
+					tt.footer.CompanyName, tt.footer.BankName, tt.footer.BankIban, tt.footer.VatId)
+			}
+		})
+	}
+}
+
+func TestImportItemsWithoutQuantities(t *testing.T) {
+	dir := t.TempDir()
+	branding := writeImport(t, dir, "branding.yaml", "from: Brand GmbH\nitems: [Base]\nquantities: [5]\nrates: [50]\n")
+	items := writeImport(t, dir, "items.yaml", "items: [A, B, C]\nrates: [10, 20, 30]\n")
+
+	tests := []struct {
+		name  string
+		paths []string
+	}{
+		{"single file", []string{items}},
+		{"merged over a file with quantities", []string{branding, items}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invoice Invoice
+			if err := importData(tt.paths, &invoice, pflag.NewFlagSet("test", pflag.ContinueOnError)); err != nil {
+				t.Fatalf("importData: %v", err)
+			}
+			if invoice.Quantities != nil {
+				t.Errorf("quantities = %v, want none", invoice.Quantities)
+			}
+			if want := []float64{10, 20, 30}; !reflect.DeepEqual(invoice.Rates, want) {
+				t.Errorf("rates = %v, want %v", invoice.Rates, want)
+			}
+			if err := invoice.Validate(); err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+		})
+	}
+}
+
+func TestImportFlagPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := writeImport(t, dir, "invoice.yaml", "id: R-1\ncurrency: EUR\ntax: 0.19\ndiscount: 0.1\ntaxExempt: false\nskontoDays: 14\nitems: [A, B]\nquantities: [1, 2]\nrates: [10, 20]\n")
+
+	tests := []struct {
+		name  string
+		args  []string
+		check func(t *testing.T, invoice Invoice)
+	}{
+		{
+			name: "unset flags keep the imported values",
+			check: func(t *testing.T, invoice Invoice) {
+				if invoice.Id != "R-1" || invoice.Tax != 0.19 || invoice.Discount != 0.1 || invoice.SkontoDays != 14 {
+					t.Errorf("id, tax, discount, skonto days = %q, %v, %v, %d", invoice.Id, invoice.Tax, invoice.Discount, invoice.SkontoDays)
+				}
+			},
+		},
+		{
+			name: "numbers and booleans",
+			args: []string{"--tax", "0.07", "--discount", "0", "--tax-exempt", "--skonto-days", "10"},
+			check: func(t *testing.T, invoice Invoice) {
+				if invoice.Tax != 0.07 || invoice.Discount != 0 || !invoice.TaxExempt || invoice.SkontoDays != 10 {
+					t.Errorf("tax, discount, exempt, skonto days = %v, %v, %v, %d", invoice.Tax, invoice.Discount, invoice.TaxExempt, invoice.SkontoDays)
+				}
+			},
+		},
+		{
+			name: "lists replace the imported lists as a whole",
+			args: []string{"--item", "X", "--rate", "5", "--quantity", "3"},
+			check: func(t *testing.T, invoice Invoice) {
+				if !reflect.DeepEqual(invoice.Items, []string{"X"}) || !reflect.DeepEqual(invoice.Rates, []float64{5}) || !reflect.DeepEqual(invoice.Quantities, []int{3}) {
+					t.Errorf("items, rates, quantities = %q, %v, %v", invoice.Items, invoice.Rates, invoice.Quantities)
+				}
+			},
+		},
+		{
+			name: "lines with commas and semicolons",
+			args: []string{"--line", "Consulting, March;10;95", "--line", "Travel; Berlin;1;120.5"},
+			check: func(t *testing.T, invoice Invoice) {
+				if want := []string{"Consulting, March", "Travel; Berlin"}; !reflect.DeepEqual(invoice.Items, want) {
+					t.Errorf("items = %q, want %q", invoice.Items, want)
+				}
+				if !reflect.DeepEqual(invoice.Quantities, []int{10, 1}) || !reflect.DeepEqual(invoice.Rates, []float64{95, 120.5}) {
+					t.Errorf("quantities, rates = %v, %v", invoice.Quantities, invoice.Rates)
+				}
+			},
+		},
+		{
+			name: "strings",
+			args: []string{"--id", "R-2", "--currency", "CHF"},
+			check: func(t *testing.T, invoice Invoice) {
+				if invoice.Id != "R-2" || invoice.Currency != "CHF" {
+					t.Errorf("id, currency = %q, %q", invoice.Id, invoice.Currency)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var invoice Invoice
+			if err := importData([]string{path}, &invoice, importFlags(t, tt.args...)); err != nil {
+				t.Fatalf("importData: %v", err)
+			}
+			tt.check(t, invoice)
+		})
+	}
+}
+
+func TestParseLines(t *testing.T) {
+	tests := []struct {
+		line    string
+		wantErr string
+	}{
+		{"Beratung;2;100", ""},
+		{"Beratung;2", `--line 1 ("Beratung;2"): expected "description;quantity;rate"`},
+		{";2;100", `--line 1 (";2;100"): the description is empty`},
+		{"Beratung;zwei;100", `--line 1 ("Beratung;zwei;100"): the quantity must be a whole number`},
+		{"Beratung;2;hundert", `--line 1 ("Beratung;2;hundert"): the rate must be a number`},
+	}
+	for _, tt := range tests {
+		_, _, _, err := parseLines([]string{tt.line})
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("parseLines(%q) error = %q, want %q", tt.line, got, tt.wantErr)
+		}
+	}
+}
+
+func TestImportMergesFilesInOrder(t *testing.T) {
+	dir := t.TempDir()
+	branding := writeImport(t, dir, "branding.yaml", "from: Brand GmbH\ncurrency: CHF\nfooter:\n  companyName: Brand GmbH\n  bankName: Bank A\n")
+	client := writeImport(t, dir, "client.json", `{"to": "Kunde AG", "currency": "EUR", "footer": {"bankName": "Bank B"}}`)
+	items := writeImport(t, dir, "items.yaml", "id: R-1\nitems: [A]\nrates: [10]\n")
+
+	var invoice Invoice
+	if err := importData([]string{branding, client, items}, &invoice, importFlags(t, "--note", "Danke")); err != nil {
+		t.Fatalf("importData: %v", err)
+	}
+
+	tests := []struct {
+		field string
+		got   string
+		want  string
+	}{
+		{"from, set only by the first file", invoice.From, "Brand GmbH"},
+		{"to, set by the second file", invoice.To, "Kunde AG"},
+		{"currency, overridden by the second file", invoice.Currency, "EUR"},
+		{"footer company, kept from the first file", invoice.Footer.CompanyName, "Brand GmbH"},
+		{"footer bank, overridden key by key", invoice.Footer.BankName, "Bank B"},
+		{"id, from the last file", invoice.Id, "R-1"},
+		{"note, from the flags", invoice.Note, "Danke"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestImportListBelowMergedFiles(t *testing.T) {
+	dir := t.TempDir()
+	branding := writeImport(t, dir, "branding.yaml", "from: Brand GmbH\n")
+	list := writeImport(t, dir, "list.yaml", "- id: R-1\n  items: [A]\n  rates: [10]\n- id: R-2\n  from: Other GmbH\n  items: [B]\n  rates: [20]\n")
+
+	invoices, err := importInvoices([]string{branding, list}, importFlags(t))
+	if err != nil {
+		t.Fatalf("importInvoices: %v", err)
+	}
+	if len(invoices) != 2 {
+		t.Fatalf("got %d invoices, want 2", len(invoices))
+	}
+	if invoices[0].From != "Brand GmbH" || invoices[1].From != "Other GmbH" {
+		t.Errorf("from = %q, %q, want Brand GmbH, Other GmbH", invoices[0].From, invoices[1].From)
+	}
+
+	// Only the last file may hold a list
+	if _, err := importInvoices([]string{list, branding}, importFlags(t)); err == nil {
+		t.Error("importInvoices accepted a list before the last file")
+	}
+}
+
+// pipeStdin feeds content to readStdinConfig through a temp file standing in
+// for stdin and clears the config cached by an earlier read
+func pipeStdin(t *testing.T, content string) {
+	t.Helper()
+	file, err := os.Open(writeImport(t, t.TempDir(), "stdin", content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin := os.Stdin
+	os.Stdin = file
+	stdinConfig = nil
+	t.Cleanup(func() {
+		os.Stdin = stdin
+		stdinConfig = nil
+		file.Close()
+	})
+}
+
+func TestImportFromStdin(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"json", `{"id": "R-1", "to": "Kunde AG", "items": ["A"], "rates": [10]}`},
+		{"yaml", "id: R-1\nto: Kunde AG\nitems: [A]\nrates: [10]\n"},
+		{"yaml with BOM", "\xEF\xBB\xBFid: R-1\nto: Kunde AG\nitems: [A]\nrates: [10]\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipeStdin(t, tt.input)
+
+			var invoice Invoice
+			if err := importData([]string{stdinPath}, &invoice, importFlags(t)); err != nil {
+				t.Fatalf("importData: %v", err)
+			}
+			if invoice.Id != "R-1" || invoice.To != "Kunde AG" || len(invoice.Items) != 1 {
+				t.Errorf("got id %q, to %q, %d items", invoice.Id, invoice.To, len(invoice.Items))
+			}
+		})
+	}
+}
+
+func TestImportFromStdinMergesWithFiles(t *testing.T) {
+	branding := writeImport(t, t.TempDir(), "branding.yaml", "from: Brand GmbH\nto: Default AG\n")
+	pipeStdin(t, `{"to": "Kunde AG", "items": ["A"], "rates": [10]}`)
+
+	var invoice Invoice
+	if err := importData([]string{branding, stdinPath}, &invoice, importFlags(t)); err != nil {
+		t.Fatalf("importData: %v", err)
+	}
+	if invoice.From != "Brand GmbH" || invoice.To != "Kunde AG" {
+		t.Errorf("from %q, to %q, want Brand GmbH, Kunde AG", invoice.From, invoice.To)
+	}
+
+	// Stdin can only be read once, a second import reuses what was read
+	if _, _, err := readStdinConfig(); err != nil {
+		t.Errorf("second read of stdin: %v", err)
+	}
+}
+
+func TestImportFromEmptyStdin(t *testing.T) {
+	for _, input := range []string{"", "  \n\t\n"} {
+		pipeStdin(t, input)
+
+		var invoice Invoice
+		if err := importData([]string{stdinPath}, &invoice, importFlags(t)); err == nil {
+			t.Errorf("importData accepted stdin %q", input)
+		}
+	}
+}
+
+func TestImportBankAndFooterFlags(t *testing.T) {
+	path := writeImport(t, t.TempDir(), "footer.yaml", "footer:\n  companyName: Brand GmbH\n  vatId: DE111\n  showVatId: true\n  address: Hauptstr. 1\n  city: Berlin\n  bankName: Bank A\n  bankIban: DE00 1111\n  bankBic: AAAADEFF\n")
+	var imported Invoice
+	if err := importData([]string{path}, &imported, importFlags(t)); err != nil {
+		t.Fatalf("importData: %v", err)
+	}
+
+	tests := []struct {
+		args []string
+		edit func(*Footer)
+	}{
+		{[]string{"--footer-company", "Other GmbH"}, func(f *Footer) { f.CompanyName = "Other GmbH" }},
+		{[]string{"--footer-vat-id", "DE222"}, func(f *Footer) { f.VatId = "DE222" }},
+		{[]string{"--bank-name", "Bank B"}, func(f *Footer) { f.BankName = "Bank B" }},
+		{[]string{"--bank-iban", "DE00 2222"}, func(f *Footer) { f.BankIban = "DE00 2222" }},
+		{[]string{"--bank-bic", "BBBBDEFF"}, func(f *Footer) { f.BankBic = "BBBBDEFF" }},
+		{[]string{"--bank-bic", ""}, func(f *Footer) { f.BankBic = "" }},
+		{[]string{"--footer-vat-id", ""}, func(f *Footer) { f.VatId = "" }},
+		{
+			[]string{"--bank-name", "Bank B", "--bank-iban", "DE00 2222", "--bank-bic", "BBBBDEFF"},
+			func(f *Footer) { f.BankName, f.BankIban, f.BankBic = "Bank B", "DE00 2222", "BBBBDEFF" },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			var invoice Invoice
+			if err := importData([]string{path}, &invoice, importFlags(t, tt.args...)); err != nil {
+				t.Fatalf("importData: %v", err)
+			}
+			want := imported.Footer
+			tt.edit(&want)
+			if !reflect.DeepEqual(invoice.Footer, want) {
+				t.Errorf("footer = %+v, want %+v", invoice.Footer, want)
+			}
+		})
+	}
+}
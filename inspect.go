@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"invoice/internal/config"
+
+	"github.com/spf13/cobra"
+)
+
+// maxInspectWidth is the width values are shortened to in the inspect table
+const maxInspectWidth = 60
+
+// Inspect command - lists the fields a config resolves to
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <config>",
+	Short: "List every field of a config with its value and source",
+	Long:  `Load an invoice config (.json/.yaml) the way generate does and print every invoice field with its resolved value and whether it came from the file, an environment variable, the base config it extends or the defaults. Long values are shortened in the table, --json prints them in full.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var invoice Invoice
+		if err := importData(args, &invoice, cmd.Flags()); err != nil {
+			return err
+		}
+
+		path := resolveImportPath(args[0])
+		data, format, err := readImportFile(path)
+		if err != nil {
+			return err
+		}
+		fields, err := config.Inspect(&invoice, data, format, path)
+		if err != nil {
+			return err
+		}
+
+		if asJSON, _ := cmd.Flags().GetBool("json"); asJSON {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(fields)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "FIELD\tVALUE\tSOURCE")
+		for _, field := range fields {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", field.Key, inspectValue(field.Value), field.Source)
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	inspectCmd.Flags().Bool("json", false, "Print the fields as JSON")
+	inspectCmd.Flags().Bool("strict", false, "Reject unknown keys and wrongly typed values in the config")
+}
+
+// inspectValue formats a field value for the inspect table on a single line:
+// strings quoted, so empty ones show, and lists and objects as JSON
+func inspectValue(value interface{}) string {
+	var text string
+	if s, ok := value.(string); ok {
+		text = strconv.Quote(s)
+	} else if encoded, err := json.Marshal(value); err == nil {
+		text = string(encoded)
+	} else {
+		text = fmt.Sprint(value)
+	}
+
+	if utf8.RuneCountInString(text) > maxInspectWidth {
+		text = string([]rune(text)[:maxInspectWidth-3]) + "..."
+	}
+	return text
+}
@@ -0,0 +1,137 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	
+	"invoice/internal/models"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// maxExtendsDepth limits how many base configs can be chained
+const maxExtendsDepth = 10
+
+// ExtendedBase returns the invoice a config is decoded on top of: the
+// defaults, or the config named by its "extends" key with that config's own
+// base resolved first. A relative "extends" path is resolved against the
+// directory of path, the file the config was read from. Decoding the child
+// on top of the base lets the child win for every key it sets; lists such
+// as items replace the base's wholesale, while objects such as the footer
+// are merged key by key. Circular chains are an error.
+func ExtendedBase(data []byte, format, path string, strict bool) (models.Invoice, error) {
+	return extendedBase(data, format, path, strict, []string{absPath(path)})
+}
+
+// extendedBase resolves the base of a config, with chain holding the files
+// already visited to detect circular extends
+func extendedBase(data []byte, format, path string, strict bool, chain []string) (models.Invoice, error) {
+	extends, err := extendsOf(data, format)
+	if err != nil || extends == "" {
+		// Decoding errors are reported when the config itself is decoded
+		return models.DefaultInvoice(), nil
+	}
+	
+	if !filepath.IsAbs(extends) {
+		extends = filepath.Join(filepath.Dir(path), extends)
+	}
+	parentPath := absPath(extends)
+	for _, visited := range chain {
+		if visited == parentPath {
+			return models.DefaultInvoice(), fmt.Errorf("circular extends: %s", strings.Join(append(chain, parentPath), " -> "))
+		}
+	}
+	if len(chain) > maxExtendsDepth {
+		return models.DefaultInvoice(), fmt.Errorf("more than %d nested extends in %s", maxExtendsDepth, chain[0])
+	}
+	
+	parentData, err := os.ReadFile(extends)
+	if err != nil {
+		return models.DefaultInvoice(), fmt.Errorf("unable to read base config: %v", err)
+	}
+	
+	var parentFormat string
+	switch strings.ToLower(filepath.Ext(extends)) {
+	case ".json":
+		parentFormat = "json"
+	case ".yaml", ".yml":
+		parentFormat = "yaml"
+	default:
+		return models.DefaultInvoice(), fmt.Errorf("unsupported base config file type: %s", extends)
+	}
+	
+	base, err := extendedBase(parentData, parentFormat, extends, strict, append(chain, parentPath))
+	if err != nil {
+		return base, err
+	}
+	ResetItems(parentData, parentFormat, &base)
+	
+	if strict {
+		err = DecodeStrict(parentData, parentFormat, &base)
+	} else if parentFormat == "json" {
+		err = json.Unmarshal(parentData, &base)
+	} else {
+		err = yaml.Unmarshal(parentData, &base)
+	}
+	if err != nil {
+		return base, fmt.Errorf("error parsing base config %s: %v", extends, err)
+	}
+	return base, nil
+}
+
+// HasExtends reports whether a config names a base config to extend
+func HasExtends(data []byte, format string) bool {
+	extends, err := extendsOf(data, format)
+	return err == nil && extends != ""
+}
+
+// ResetItems clears the per-item lists of invoice, the defaults or base
+// config a config is about to be decoded onto, when that config sets its own
+// items. Its quantities, rates and item dates, sections, units and tax rates
+// then come from the config alone, so a config without quantities counts
+// every item once instead of pairing its items with the default quantity of 2.
+func ResetItems(data []byte, format string, invoice *models.Invoice) {
+	var header struct {
+		Items *[]interface{} `json:"items" yaml:"items"`
+	}
+	
+	var err error
+	if format == "json" {
+		err = json.Unmarshal(data, &header)
+	} else {
+		err = yaml.Unmarshal(data, &header)
+	}
+	if err != nil || header.Items == nil {
+		return
+	}
+	
+	invoice.Quantities, invoice.Rates = nil, nil
+	invoice.ItemDates, invoice.ItemSections, invoice.ItemTaxRates, invoice.ItemUnits = nil, nil, nil, nil
+}
+
+// extendsOf returns the "extends" key of a config, if any
+func extendsOf(data []byte, format string) (string, error) {
+	var header struct {
+		Extends string `json:"extends" yaml:"extends"`
+	}
+	
+	var err error
+	if format == "json" {
+		err = json.Unmarshal(data, &header)
+	} else {
+		err = yaml.Unmarshal(data, &header)
+	}
+	return strings.TrimSpace(header.Extends), err
+}
+
+// absPath returns the absolute form of a path for comparison, or the path
+// itself if it can't be resolved
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
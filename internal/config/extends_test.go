@@ -0,0 +1,95 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExtendsMergesOverBase(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.yaml", "from: Base GmbH\ncurrency: CHF\nfooter:\n  companyName: Base AG\n  bankName: Base Bank\nitems: [Base]\nquantities: [5]\nrates: [50]\n")
+	
+	tests := []struct {
+		name       string
+		content    string
+		items      []string
+		quantities []int
+		rates      []float64
+	}{
+		{
+			name:       "items inherited",
+			content:    "extends: base.yaml\nto: Kunde\n",
+			items:      []string{"Base"},
+			quantities: []int{5},
+			rates:      []float64{50},
+		},
+		{
+			name:       "own items drop the base quantities",
+			content:    "extends: base.yaml\nitems: [A, B]\nrates: [10, 20]\n",
+			items:      []string{"A", "B"},
+			quantities: nil,
+			rates:      []float64{10, 20},
+		},
+		{
+			name:       "own items and quantities",
+			content:    "extends: base.yaml\nitems: [A]\nquantities: [3]\nrates: [10]\n",
+			items:      []string{"A"},
+			quantities: []int{3},
+			rates:      []float64{10},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, dir, "child.yaml", tt.content)
+			invoice, err := NewConfigLoader().LoadInvoice(path)
+			if err != nil {
+				t.Fatalf("LoadInvoice: %v", err)
+			}
+			if invoice.From != "Base GmbH" || invoice.Currency != "CHF" {
+				t.Errorf("from, currency = %q, %q, want the base's", invoice.From, invoice.Currency)
+			}
+			if invoice.Footer.CompanyName != "Base AG" || invoice.Footer.BankName != "Base Bank" {
+				t.Errorf("footer = %+v, want the base's", invoice.Footer)
+			}
+			if !reflect.DeepEqual(invoice.Items, tt.items) {
+				t.Errorf("items = %v, want %v", invoice.Items, tt.items)
+			}
+			if !reflect.DeepEqual(invoice.Quantities, tt.quantities) {
+				t.Errorf("quantities = %v, want %v", invoice.Quantities, tt.quantities)
+			}
+			if !reflect.DeepEqual(invoice.Rates, tt.rates) {
+				t.Errorf("rates = %v, want %v", invoice.Rates, tt.rates)
+			}
+		})
+	}
+}
+
+func TestExtendsMergesFooterKeyByKey(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "base.json", `{"footer": {"companyName": "Base AG", "bankName": "Base Bank"}}`)
+	path := writeConfig(t, dir, "child.json", `{"extends": "base.json", "footer": {"bankName": "Child Bank"}}`)
+	
+	invoice, err := NewConfigLoader().LoadInvoice(path)
+	if err != nil {
+		t.Fatalf("LoadInvoice: %v", err)
+	}
+	if invoice.Footer.CompanyName != "Base AG" {
+		t.Errorf("companyName = %q, want Base AG", invoice.Footer.CompanyName)
+	}
+	if invoice.Footer.BankName != "Child Bank" {
+		t.Errorf("bankName = %q, want Child Bank", invoice.Footer.BankName)
+	}
+}
+
+func TestExtendsRejectsCircularChains(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "a.yaml", "extends: b.yaml\n")
+	path := writeConfig(t, dir, "b.yaml", "extends: a.yaml\n")
+	
+	_, err := NewConfigLoader().LoadInvoice(path)
+	if err == nil || !strings.Contains(err.Error(), "circular extends") {
+		t.Fatalf("err = %v, want a circular extends error", err)
+	}
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// SetFields sets top-level string keys of a single invoice config and keeps
+// every other key as it is. YAML configs also keep their key order and
+// comments, JSON configs are written with sorted keys. Keys the config lacks
+// are added. The format is "json" or "yaml".
+func SetFields(data []byte, format string, fields map[string]string) ([]byte, error) {
+	switch format {
+	case "json":
+		var document map[string]json.RawMessage
+		if err := json.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %v", err)
+		}
+		
+		for key, value := range fields {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			document[key] = encoded
+		}
+		
+		out, err := json.MarshalIndent(document, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	case "yaml":
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("expected a single invoice, not a list")
+		}
+		
+		mapping := root.Content[0]
+		for key, value := range fields {
+			setMappingValue(mapping, key, value)
+		}
+		
+		var out bytes.Buffer
+		encoder := yaml.NewEncoder(&out)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&root); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// setMappingValue replaces the value of key in a YAML mapping, or appends
+// the key if the mapping lacks it
+func setMappingValue(mapping *yaml.Node, key, value string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1].SetString(value)
+			return
+		}
+	}
+	
+	keyNode := &yaml.Node{}
+	keyNode.SetString(key)
+	valueNode := &yaml.Node{}
+	valueNode.SetString(value)
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// filenamePlaceholder matches the placeholders of a filename pattern such as {id}
+var filenamePlaceholder = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// filenameUnsafe replaces characters that must not end up in a filename part
+var filenameUnsafe = strings.NewReplacer("/", "", `\`, "", " ", "_", "\t", "_")
+
+// filenamePlaceholders are the placeholders supported in filename patterns
+var filenamePlaceholders = []string{"from", "company", "id", "suffix", "date", "customer"}
+
+// CheckFilenamePattern reports unknown placeholders in a filename pattern, so
+// a typo fails before any invoice is rendered
+func CheckFilenamePattern(pattern string) error {
+	var unknown []string
+	for _, match := range filenamePlaceholder.FindAllStringSubmatch(pattern, -1) {
+		if !isFilenamePlaceholder(strings.TrimSpace(match[1])) {
+			unknown = append(unknown, match[0])
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown placeholder %s in filename pattern (supported: {%s})",
+			strings.Join(unknown, ", "), strings.Join(filenamePlaceholders, "}, {"))
+	}
+	return nil
+}
+
+// isFilenamePlaceholder reports whether name is a supported filename placeholder
+func isFilenamePlaceholder(name string) bool {
+	for _, placeholder := range filenamePlaceholders {
+		if name == placeholder {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatFilename builds the PDF filename for an invoice from a pattern such as
+// "{from}-{id}-{date}.pdf". The placeholders {from} (or {company}), {id},
+// {suffix}, {date} and {customer} are filled in from the invoice with slashes
+// removed and spaces replaced by underscores. Unknown placeholders are an
+// error. An empty pattern gives the default <id><suffix>.pdf.
+func FormatFilename(pattern string, invoice *models.Invoice) (string, error) {
+	if pattern == "" {
+		return invoice.Id + invoice.IdSuffix + ".pdf", nil
+	}
+	if err := CheckFilenamePattern(pattern); err != nil {
+		return "", err
+	}
+	
+	customer := invoice.RecipientName()
+	values := map[string]string{
+		"from":     invoice.SenderName(),
+		"company":  invoice.SenderName(),
+		"id":       invoice.Id,
+		"suffix":   invoice.IdSuffix,
+		"date":     invoice.Date,
+		"customer": customer,
+	}
+	
+	name := filenamePlaceholder.ReplaceAllStringFunc(pattern, func(match string) string {
+		value := values[strings.TrimSpace(match[1:len(match)-1])]
+		return filenameUnsafe.Replace(strings.TrimSpace(value))
+	})
+	
+	// The extension is optional in the pattern
+	if strings.EqualFold(filepath.Ext(name), ".pdf") {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	if name == "" {
+		return "", fmt.Errorf("filename pattern %q gives an empty filename for invoice %s", pattern, invoice.Id)
+	}
+	return name + ".pdf", nil
+}
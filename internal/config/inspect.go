@@ -0,0 +1,121 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	
+	"invoice/internal/models"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// Sources of the fields listed by Inspect
+const (
+	// SourceEnv is a field set by an environment variable such as INVOICE_CURRENCY
+	SourceEnv = "env"
+	
+	// SourceFile is a field set by the config itself
+	SourceFile = "file"
+	
+	// SourceDerived is a field filled in from other fields, e.g. from with
+	// the structured sender
+	SourceDerived = "derived"
+	
+	// SourceExtends is a field set by the base config the config extends
+	SourceExtends = "extends"
+	
+	// SourceDefault is a field left at its default
+	SourceDefault = "default"
+)
+
+// Field is a field of a resolved invoice config with where its value came
+// from. The key is the config key, nested objects are joined with a dot,
+// e.g. "footer.bankIban".
+type Field struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+}
+
+// Inspect lists every field of invoice, the invoice resolved from the config
+// data read from path, in the order of models.Invoice. Each field names its
+// source: an environment variable, the config itself, the base config it
+// extends or the defaults. The format is "json" or "yaml".
+func Inspect(invoice *models.Invoice, data []byte, format, path string) ([]Field, error) {
+	var document map[string]interface{}
+	var err error
+	if format == "json" {
+		err = json.Unmarshal(data, &document)
+	} else {
+		err = yaml.Unmarshal(data, &document)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse %s: %v", path, err)
+	}
+	
+	base, err := ExtendedBase(data, format, path, false)
+	if err != nil {
+		return nil, err
+	}
+	defaults := models.DefaultInvoice()
+	
+	var fields []Field
+	inspectStruct(reflect.ValueOf(invoice).Elem(), reflect.ValueOf(base), reflect.ValueOf(defaults), document, "", &fields)
+	return fields, nil
+}
+
+// inspectStruct appends the fields of value to fields, recursing into nested
+// objects such as the footer. base and defaults hold the same struct before
+// the config was applied, document the keys the config sets.
+func inspectStruct(value, base, defaults reflect.Value, document map[string]interface{}, prefix string, fields *[]Field) {
+	typ := value.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		fieldType := typ.Field(i)
+		key := strings.Split(fieldType.Tag.Get("json"), ",")[0]
+		if fieldType.PkgPath != "" || key == "" || key == "-" {
+			continue
+		}
+		
+		field := indirect(value.Field(i))
+		if field.Kind() == reflect.Struct {
+			nested, _ := document[key].(map[string]interface{})
+			inspectStruct(field, indirect(base.Field(i)), indirect(defaults.Field(i)), nested, prefix+key+".", fields)
+			continue
+		}
+		
+		_, inFile := document[key]
+		source := SourceDefault
+		switch {
+		case envSet(fieldType.Tag.Get("env")):
+			source = SourceEnv
+		case inFile:
+			source = SourceFile
+		case !reflect.DeepEqual(field.Interface(), base.Field(i).Interface()):
+			source = SourceDerived
+		case !reflect.DeepEqual(base.Field(i).Interface(), defaults.Field(i).Interface()):
+			source = SourceExtends
+		}
+		*fields = append(*fields, Field{Key: prefix + key, Value: field.Interface(), Source: source})
+	}
+}
+
+// indirect returns the struct a pointer points to, or its zero value for a
+// nil pointer such as an unset sender
+func indirect(value reflect.Value) reflect.Value {
+	if value.Kind() != reflect.Ptr {
+		return value
+	}
+	if value.IsNil() {
+		return reflect.Zero(value.Type().Elem())
+	}
+	return value.Elem()
+}
+
+// envSet reports whether the environment variable of an env tag is set, as
+// read by ApplyEnvironmentVariables
+func envSet(tag string) bool {
+	return tag != "" && (os.Getenv(tag) != "" || os.Getenv("INVOICE_"+tag) != "")
+}
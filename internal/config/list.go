@@ -0,0 +1,103 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	
+	"invoice/internal/models"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// SplitInvoices splits a config file holding a list of invoices, a JSON array
+// or YAML sequence, into one document per invoice. A file with a single
+// invoice is returned as is, so its line numbers stay intact.
+// The format is "json" or "yaml".
+func SplitInvoices(data []byte, format string) ([][]byte, error) {
+	switch format {
+	case "json":
+		if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '[' {
+			return [][]byte{data}, nil
+		}
+		
+		var elements []json.RawMessage
+		if err := json.Unmarshal(data, &elements); err != nil {
+			return nil, fmt.Errorf("invalid invoice list: %v", err)
+		}
+		
+		documents := make([][]byte, len(elements))
+		for i, element := range elements {
+			documents[i] = element
+		}
+		return documents, nil
+	case "yaml":
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
+		if len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+			return [][]byte{data}, nil
+		}
+		
+		var documents [][]byte
+		for i, element := range root.Content[0].Content {
+			document, err := yaml.Marshal(element)
+			if err != nil {
+				return nil, fmt.Errorf("invoice %d: %v", i+1, err)
+			}
+			documents = append(documents, document)
+		}
+		return documents, nil
+	default:
+		return nil, fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// WarnDuplicateIds prints a warning for every invoice number used more than
+// once, as the later PDF would overwrite the earlier one
+func WarnDuplicateIds(invoices []*models.Invoice, source string) {
+	seen := make(map[string]bool)
+	for _, invoice := range invoices {
+		id := invoice.Id + invoice.IdSuffix
+		if seen[id] {
+			fmt.Fprintf(os.Stderr, "Warning: Invoice number %s is used more than once in %s, only the last one is kept as %s.pdf\n", id, source, id)
+		}
+		seen[id] = true
+	}
+}
+
+// DetectFormat tells a config's format from its content, for configs without
+// a file extension such as one read from stdin. A config starting with "{" or
+// "[" is JSON, anything else YAML.
+func DetectFormat(data []byte) string {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		return "json"
+	}
+	return "yaml"
+}
+
+// FindConfigFiles returns the JSON and YAML invoice configs in dir, skipping
+// the currency and web server settings kept next to them
+func FindConfigFiles(dir string) ([]string, error) {
+	var files []string
+	for _, pattern := range []string{"*.json", "*.yml", "*.yaml"} {
+		matches, err := filepath.Glob(filepath.Join(dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+	
+	var configFiles []string
+	for _, file := range files {
+		basename := filepath.Base(file)
+		if basename == "currency.json" || basename == "web_config.json" {
+			continue
+		}
+		configFiles = append(configFiles, file)
+	}
+	return configFiles, nil
+}
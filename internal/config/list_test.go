@@ -0,0 +1,22 @@
+package config
+
+import "testing"
+
+func TestDetectFormat(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`{"id": "R-1"}`, "json"},
+		{"\n  [{\"id\": \"R-1\"}]", "json"},
+		{"id: R-1\n", "yaml"},
+		{"- id: R-1\n", "yaml"},
+		{"# comment\n{}", "yaml"},
+		{"", "yaml"},
+	}
+	for _, tt := range tests {
+		if got := DetectFormat([]byte(tt.input)); got != tt.want {
+			t.Errorf("DetectFormat(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 	
 	"invoice/internal/models"
 	
@@ -19,17 +20,27 @@ type ConfigLoader interface {
 	Load(path string) (*models.AppConfig, error)
 	LoadWeb(path string) (models.WebConfig, error)
 	LoadInvoice(path string) (*models.Invoice, error)
+	LoadInvoices(path string) ([]*models.Invoice, error)
 	ApplyEnvironmentVariables(config interface{}) error
 }
 
 // FileConfigLoader implements the ConfigLoader interface
-type FileConfigLoader struct{}
+type FileConfigLoader struct {
+	// Reject unknown keys and report type mismatches in invoice files
+	strict bool
+}
 
 // NewConfigLoader creates a new FileConfigLoader
 func NewConfigLoader() ConfigLoader {
 	return &FileConfigLoader{}
 }
 
+// NewStrictConfigLoader creates a FileConfigLoader that rejects unknown or
+// misspelled keys in invoice files, see DecodeStrict
+func NewStrictConfigLoader() ConfigLoader {
+	return &FileConfigLoader{strict: true}
+}
+
 // Load loads the application configuration from a file
 func (l *FileConfigLoader) Load(path string) (*models.AppConfig, error) {
 	config := models.DefaultAppConfig()
@@ -127,9 +138,20 @@ func (l *FileConfigLoader) LoadWeb(path string) (models.WebConfig, error) {
 
 // LoadInvoice loads an invoice template from a file
 func (l *FileConfigLoader) LoadInvoice(path string) (*models.Invoice, error) {
-	// Create a default invoice
-	invoice := models.DefaultInvoice()
+	invoices, err := l.LoadInvoices(path)
+	if err != nil {
+		return nil, err
+	}
 	
+	if len(invoices) != 1 {
+		return nil, fmt.Errorf("%s contains %d invoices, expected one", path, len(invoices))
+	}
+	return invoices[0], nil
+}
+
+// LoadInvoices loads all invoices from a file. The file holds either a single
+// invoice or a list of them (a JSON array or YAML sequence).
+func (l *FileConfigLoader) LoadInvoices(path string) ([]*models.Invoice, error) {
 	// Check if path doesn't have a directory prefix, assume it's in config dir
 	if filepath.Dir(path) == "." {
 		path = filepath.Join("config", path)
@@ -138,7 +160,7 @@ func (l *FileConfigLoader) LoadInvoice(path string) (*models.Invoice, error) {
 	// Read the file
 	data, err := os.ReadFile(path)
 	if err != nil {
-		return &invoice, fmt.Errorf("unable to read file: %v", err)
+		return nil, fmt.Errorf("unable to read file: %v", err)
 	}
 	
 	// Remove UTF-8 BOM if present
@@ -146,19 +168,80 @@ func (l *FileConfigLoader) LoadInvoice(path string) (*models.Invoice, error) {
 		data = data[3:]
 	}
 	
-	// Check file type and parse accordingly
-	ext := strings.ToLower(filepath.Ext(path))
-	switch ext {
+	// Check file type
+	var format string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
 	case ".json":
-		err = json.Unmarshal(data, &invoice)
+		format = "json"
 	case ".yaml", ".yml":
-		err = yaml.Unmarshal(data, &invoice)
+		format = "yaml"
 	default:
-		return &invoice, fmt.Errorf("unsupported file type: %s", ext)
+		return nil, fmt.Errorf("unsupported file type: %s", ext)
 	}
 	
+	documents, err := SplitInvoices(data, format)
 	if err != nil {
-		return &invoice, fmt.Errorf("error parsing invoice file: %v", err)
+		return nil, fmt.Errorf("error parsing invoice file: %v", err)
+	}
+	
+	var invoices []*models.Invoice
+	for i, document := range documents {
+		// Errors in a list name the invoice they belong to
+		location := path
+		if len(documents) > 1 {
+			location = fmt.Sprintf("%s (invoice %d)", path, i+1)
+		}
+		
+		invoice, err := l.parseInvoice(document, format, path, location)
+		invoices = append(invoices, invoice)
+		if err != nil {
+			return invoices, err
+		}
+	}
+	
+	WarnDuplicateIds(invoices, path)
+	return invoices, nil
+}
+
+// parseInvoice decodes a single invoice read from path on top of the
+// defaults, or the base config it extends
+func (l *FileConfigLoader) parseInvoice(data []byte, format, path, location string) (*models.Invoice, error) {
+	invoice, err := ExtendedBase(data, format, path, l.strict)
+	if err != nil {
+		return &invoice, fmt.Errorf("invalid invoice file %s: %v", location, err)
+	}
+	ResetItems(data, format, &invoice)
+	
+	switch {
+	case l.strict:
+		err = DecodeStrict(data, format, &invoice)
+	case format == "json":
+		err = json.Unmarshal(data, &invoice)
+	default:
+		err = yaml.Unmarshal(data, &invoice)
+	}
+	if err != nil {
+		return &invoice, fmt.Errorf("error parsing invoice file %s: %v", location, err)
+	}
+	
+	// Environment variables such as INVOICE_CURRENCY override the file
+	if err := l.ApplyEnvironmentVariables(&invoice); err != nil {
+		return &invoice, err
+	}
+	
+	invoice.ApplySenderDefaults()
+	
+	// Fill in recurring-invoice placeholders such as {{month}}
+	if err := SubstitutePlaceholders(&invoice, time.Now()); err != nil {
+		return &invoice, fmt.Errorf("invalid invoice file %s: %v", location, err)
+	}
+	
+	if err := invoice.NormalizeDates(); err != nil {
+		return &invoice, fmt.Errorf("invalid invoice file %s: %v", location, err)
+	}
+	
+	if err := invoice.Validate(); err != nil {
+		return &invoice, fmt.Errorf("invalid invoice file %s: %v", location, err)
 	}
 	
 	return &invoice, nil
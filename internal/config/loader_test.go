@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	
+	"invoice/internal/models"
+)
+
+// writeConfig writes a config file into dir and returns its path
+func writeConfig(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadInvoiceItemsWithoutQuantities(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    string
+		quantities []int
+		rates      []float64
+	}{
+		{
+			name:       "yaml without quantities",
+			file:       "three.yaml",
+			content:    "items: [A, B, C]\nrates: [10, 20, 30]\n",
+			quantities: nil,
+			rates:      []float64{10, 20, 30},
+		},
+		{
+			name:       "json with a single item",
+			file:       "one.json",
+			content:    `{"items": ["Beratung"], "rates": [100]}`,
+			quantities: nil,
+			rates:      []float64{100},
+		},
+		{
+			name:       "quantities given",
+			file:       "given.yaml",
+			content:    "items: [A, B]\nquantities: [3, 4]\nrates: [1, 2]\n",
+			quantities: []int{3, 4},
+			rates:      []float64{1, 2},
+		},
+		{
+			name:       "no items keeps the defaults",
+			file:       "defaults.yaml",
+			content:    "to: Kunde\n",
+			quantities: []int{2},
+			rates:      []float64{25},
+		},
+	}
+	
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, t.TempDir(), tt.file, tt.content)
+			invoice, err := NewConfigLoader().LoadInvoice(path)
+			if err != nil {
+				t.Fatalf("LoadInvoice: %v", err)
+			}
+			if !reflect.DeepEqual(invoice.Quantities, tt.quantities) {
+				t.Errorf("quantities = %v, want %v", invoice.Quantities, tt.quantities)
+			}
+			if !reflect.DeepEqual(invoice.Rates, tt.rates) {
+				t.Errorf("rates = %v, want %v", invoice.Rates, tt.rates)
+			}
+		})
+	}
+}
+
+func TestLoadInvoiceSingleItemCountsOnce(t *testing.T) {
+	path := writeConfig(t, t.TempDir(), "one.yaml", "items: [Beratung]\nrates: [100]\ntax: 0\n")
+	invoice, err := NewConfigLoader().LoadInvoice(path)
+	if err != nil {
+		t.Fatalf("LoadInvoice: %v", err)
+	}
+	if total := models.ComputeInvoice(invoice).Total; total != 100 {
+		t.Errorf("total = %.2f, want 100.00", total)
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	
+	"invoice/internal/models"
+)
+
+// placeholderPattern matches template placeholders such as {{month}}
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([^{}]*?)\s*\}\}`)
+
+// placeholderValues returns the supported placeholders for the given time,
+// with the month name in the invoice's language and the date in its
+// DateFormat, as the renderer prints the other dates
+func placeholderValues(now time.Time, invoice *models.Invoice) map[string]string {
+	format := invoice.DateFormat
+	if format == "" {
+		format = models.DefaultDateFormat
+	}
+	
+	return map[string]string{
+		"month":     now.Format("01"),
+		"monthName": models.MonthName(now.Month(), invoice.Language),
+		"year":      now.Format("2006"),
+		"date":      models.FormatDate(now, format, invoice.Language),
+	}
+}
+
+// SubstitutePlaceholders replaces {{month}}, {{monthName}}, {{year}} and
+// {{date}} in the invoice's Id, Items and Note so one config can be reused
+// every month. Unknown placeholders are an error so typos don't end up on an
+// invoice.
+func SubstitutePlaceholders(invoice *models.Invoice, now time.Time) error {
+	values := placeholderValues(now, invoice)
+	
+	var err error
+	if invoice.Id, err = substitute("id", invoice.Id, values); err != nil {
+		return err
+	}
+	for i := range invoice.Items {
+		if invoice.Items[i], err = substitute(fmt.Sprintf("item %d", i+1), invoice.Items[i], values); err != nil {
+			return err
+		}
+	}
+	if invoice.Note, err = substitute("note", invoice.Note, values); err != nil {
+		return err
+	}
+	
+	return nil
+}
+
+// substitute replaces the placeholders in a single field
+func substitute(field, text string, values map[string]string) (string, error) {
+	var unknown []string
+	result := placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholderPattern.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			unknown = append(unknown, match)
+			return match
+		}
+		return value
+	})
+	
+	if len(unknown) > 0 {
+		return text, fmt.Errorf("unknown placeholder %s in %s (supported: {{month}}, {{monthName}}, {{year}}, {{date}})", strings.Join(unknown, ", "), field)
+	}
+	return result, nil
+}
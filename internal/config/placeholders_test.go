@@ -0,0 +1,46 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+	
+	"invoice/internal/models"
+)
+
+func TestSubstitutePlaceholders(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	
+	tests := []struct {
+		name       string
+		dateFormat string
+		language   string
+		note       string
+		want       string
+	}{
+		{"default format", "", "", "erstellt am {{date}}", "erstellt am 01.03.2024"},
+		{"invoice date format", "2006-01-02", "en", "created {{ date }}", "created 2024-03-01"},
+		{"month names in the invoice language", "2. January 2006", "de", "{{date}}", "1. März 2024"},
+		{"english month names", "January 2, 2006", "en", "{{date}}", "March 1, 2024"},
+		{"month and year", "", "en", "{{monthName}} {{year}} ({{month}})", "March 2024 (03)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := models.Invoice{DateFormat: tt.dateFormat, Language: tt.language, Note: tt.note}
+			if err := SubstitutePlaceholders(&invoice, now); err != nil {
+				t.Fatal(err)
+			}
+			if invoice.Note != tt.want {
+				t.Errorf("note = %q, want %q", invoice.Note, tt.want)
+			}
+		})
+	}
+}
+
+func TestSubstitutePlaceholdersRejectsUnknown(t *testing.T) {
+	invoice := models.Invoice{Id: "R-{{yaer}}"}
+	err := SubstitutePlaceholders(&invoice, time.Now())
+	if err == nil || !strings.Contains(err.Error(), "{{yaer}}") {
+		t.Errorf("error = %v, want the unknown placeholder", err)
+	}
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	
+	"gopkg.in/yaml.v3"
+)
+
+// yamlUnknownField matches the unknown field errors reported by yaml.v3
+var yamlUnknownField = regexp.MustCompile(`^line (\d+): field (\S+) not found in type \S+$`)
+
+// DecodeStrict decodes a JSON or YAML config into v, rejecting keys that do
+// not exist in v and reporting type mismatches with the key and line, e.g.
+// a misspelled "quantites" that would otherwise be ignored silently.
+// The format is "json" or "yaml".
+func DecodeStrict(data []byte, format string, v interface{}) error {
+	switch format {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(v); err != nil {
+			return describeJSONError(data, v, err)
+		}
+		return nil
+	case "yaml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(v); err != nil {
+			return describeYAMLError(v, err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported format: %s", format)
+	}
+}
+
+// describeJSONError rewrites the errors of encoding/json into messages that
+// name the offending key and its line
+func describeJSONError(data []byte, v interface{}, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	
+	switch {
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("line %d: %q must be %s, got %s", lineAt(data, typeErr.Offset),
+			typeErr.Field, describeKind(typeErr.Type), typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("line %d: %v", lineAt(data, syntaxErr.Offset), syntaxErr)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		key := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		line := lineAt(data, int64(bytes.Index(data, []byte(`"`+key+`"`))))
+		return unknownKeyError(line, key, knownKeys(reflect.TypeOf(v), "json"))
+	default:
+		return err
+	}
+}
+
+// describeYAMLError rewrites the unknown field errors of yaml.v3, which name
+// the Go type instead of the key, and keeps its other messages
+func describeYAMLError(v interface{}, err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		return err
+	}
+	
+	var problems []string
+	for _, problem := range typeErr.Errors {
+		if m := yamlUnknownField.FindStringSubmatch(problem); m != nil {
+			line, _ := strconv.Atoi(m[1])
+			problem = unknownKeyError(line, m[2], knownKeys(reflect.TypeOf(v), "yaml")).Error()
+		}
+		problems = append(problems, problem)
+	}
+	return fmt.Errorf("%s", strings.Join(problems, "; "))
+}
+
+// unknownKeyError reports an unknown key, suggesting the closest known key
+func unknownKeyError(line int, key string, known []string) error {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if d := editDistance(strings.ToLower(key), strings.ToLower(candidate)); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	
+	if best != "" {
+		return fmt.Errorf("line %d: unknown key %q (did you mean %q?)", line, key, best)
+	}
+	return fmt.Errorf("line %d: unknown key %q", line, key)
+}
+
+// knownKeys lists the keys of a struct type and its nested structs for the
+// given tag, "json" or "yaml"
+func knownKeys(t reflect.Type, tag string) []string {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		name := strings.Split(t.Field(i).Tag.Get(tag), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		keys = append(keys, name)
+		keys = append(keys, knownKeys(t.Field(i).Type, tag)...)
+	}
+	return keys
+}
+
+// describeKind names the JSON type expected for a Go type
+func describeKind(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "true or false"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "a whole number"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "a list"
+	default:
+		return "an object"
+	}
+}
+
+// lineAt returns the 1-based line of a byte offset
+func lineAt(data []byte, offset int64) int {
+	if offset < 0 {
+		return 1
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
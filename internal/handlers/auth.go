@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	
+	"invoice/internal/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// healthPath is left open so load balancers can check the server without credentials
+const healthPath = "/healthz"
+
+// RequireAuth returns a middleware that only lets requests through with the
+// basic auth credentials or bearer token configured in webConfig. When neither
+// is configured every request is let through.
+func RequireAuth(webConfig models.WebConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !webConfig.AuthEnabled() || c.Request.URL.Path == healthPath {
+			c.Next()
+			return
+		}
+		
+		if authorized(c.Request, webConfig) {
+			c.Next()
+			return
+		}
+		
+		if webConfig.AuthUsername != "" {
+			c.Header("WWW-Authenticate", `Basic realm="invoice", charset="UTF-8"`)
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Authentication required"})
+	}
+}
+
+// authorized reports whether a request carries valid credentials
+func authorized(request *http.Request, webConfig models.WebConfig) bool {
+	if webConfig.AuthToken != "" {
+		header := request.Header.Get("Authorization")
+		if token, ok := strings.CutPrefix(header, "Bearer "); ok && secureEqual(token, webConfig.AuthToken) {
+			return true
+		}
+	}
+	
+	if webConfig.AuthUsername != "" {
+		username, password, ok := request.BasicAuth()
+		// Both are compared to not reveal which one was wrong through the timing
+		usernameOK := secureEqual(username, webConfig.AuthUsername)
+		passwordOK := secureEqual(password, webConfig.AuthPassword)
+		if ok && usernameOK && passwordOK {
+			return true
+		}
+	}
+	
+	return false
+}
+
+// secureEqual compares a secret in constant time
+func secureEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	
+	"invoice/internal/models"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// newAuthRouter returns a router with the auth middleware in front of a
+// protected route and the health check
+func newAuthRouter(webConfig models.WebConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequireAuth(webConfig))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET(healthPath, ok)
+	router.GET("/api/config-files", ok)
+	return router
+}
+
+func TestRequireAuth(t *testing.T) {
+	basic := models.WebConfig{AuthUsername: "admin", AuthPassword: "secret"}
+	token := models.WebConfig{AuthToken: "s3cr3t"}
+	both := models.WebConfig{AuthUsername: "admin", AuthPassword: "secret", AuthToken: "s3cr3t"}
+	
+	tests := []struct {
+		name      string
+		config    models.WebConfig
+		path      string
+		setup     func(*http.Request)
+		want      int
+		challenge bool
+	}{
+		{"no auth configured", models.WebConfig{}, "/api/config-files", nil, http.StatusOK, false},
+		{"health check is open", both, healthPath, nil, http.StatusOK, false},
+		{"basic auth missing", basic, "/api/config-files", nil, http.StatusUnauthorized, true},
+		{"basic auth valid", basic, "/api/config-files", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusOK, false},
+		{"basic auth wrong password", basic, "/api/config-files", func(r *http.Request) { r.SetBasicAuth("admin", "wrong") }, http.StatusUnauthorized, true},
+		{"basic auth wrong user", basic, "/api/config-files", func(r *http.Request) { r.SetBasicAuth("root", "secret") }, http.StatusUnauthorized, true},
+		{"token valid", token, "/api/config-files", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t") }, http.StatusOK, false},
+		{"token wrong", token, "/api/config-files", func(r *http.Request) { r.Header.Set("Authorization", "Bearer nope") }, http.StatusUnauthorized, false},
+		{"token without bearer prefix", token, "/api/config-files", func(r *http.Request) { r.Header.Set("Authorization", "s3cr3t") }, http.StatusUnauthorized, false},
+		{"basic auth when both are configured", both, "/api/config-files", func(r *http.Request) { r.SetBasicAuth("admin", "secret") }, http.StatusOK, false},
+		{"token when both are configured", both, "/api/config-files", func(r *http.Request) { r.Header.Set("Authorization", "Bearer s3cr3t") }, http.StatusOK, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.setup != nil {
+				tt.setup(request)
+			}
+			recorder := httptest.NewRecorder()
+			newAuthRouter(tt.config).ServeHTTP(recorder, request)
+			
+			if recorder.Code != tt.want {
+				t.Errorf("status = %d, want %d", recorder.Code, tt.want)
+			}
+			if challenge := recorder.Header().Get("WWW-Authenticate") != ""; challenge != tt.challenge {
+				t.Errorf("WWW-Authenticate set = %v, want %v", challenge, tt.challenge)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"syscall"
+	
+	"invoice/internal/services/invoice"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// ServiceError responds with the HTTP status of an invoice service error and
+// its machine-readable code, so API clients can tell bad input from a
+// server problem, e.g. {"success": false, "code": "validation", "message": ...}.
+// The message is prefixed to the error's own.
+func ServiceError(c *gin.Context, message string, err error) {
+	c.JSON(serviceStatus(err), gin.H{
+		"success": false,
+		"code":    invoice.Code(err),
+		"message": message + err.Error(),
+	})
+}
+
+// serviceStatus returns the HTTP status for an invoice service error: bad
+// input is the client's fault, missing fonts make the server unavailable
+// until fixed, and a full disk is reported as such
+func serviceStatus(err error) int {
+	switch {
+	case errors.Is(err, invoice.ErrValidation), errors.Is(err, invoice.ErrConfig):
+		return http.StatusBadRequest
+	case errors.Is(err, invoice.ErrFontMissing):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, syscall.ENOSPC):
+		return http.StatusInsufficientStorage
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestServiceStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+		code string
+	}{
+		{"validation", `{"id": "R-1", "items": [{"description": "Beratung", "rate": 100}], "discountType": "bogus"}`, http.StatusBadRequest, "validation"},
+		{"config", `{"id": "R-1", "useConfig": true, "configFile": "missing.yaml"}`, http.StatusBadRequest, "config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newTestRouter(t, testWebConfig(t), &stubUploader{})
+			response := serve(router, http.MethodPost, "/api/generate", tt.body)
+			if response.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", response.Code, tt.want, response.Body)
+			}
+			if code := `"code":"` + tt.code + `"`; !strings.Contains(response.Body.String(), code) {
+				t.Errorf("body = %s, want %s", response.Body, code)
+			}
+		})
+	}
+	
+	if status := serviceStatus(&fs.PathError{Op: "write", Path: "R-1.pdf", Err: syscall.ENOSPC}); status != http.StatusInsufficientStorage {
+		t.Errorf("status of a full disk = %d, want 507", status)
+	}
+	if status := serviceStatus(errors.New("unclassified")); status != http.StatusInternalServerError {
+		t.Errorf("status of an unclassified error = %d, want 500", status)
+	}
+}
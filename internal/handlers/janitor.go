@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// janitorInterval is how often the janitor looks for expired files
+const janitorInterval = time.Minute
+
+// FileJanitor deletes the PDFs generated by the web server once they are
+// older than the TTL. Only files passed to Track are ever deleted, so config
+// files, fonts and PDFs generated otherwise are left alone.
+type FileJanitor struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	files map[string]time.Time
+}
+
+// NewFileJanitor creates a janitor for files older than ttl. A TTL of zero
+// or less keeps the files forever.
+func NewFileJanitor(ttl time.Duration) *FileJanitor {
+	return &FileJanitor{
+		ttl:   ttl,
+		files: make(map[string]time.Time),
+	}
+}
+
+// Track registers a generated file for deletion once it expires
+func (j *FileJanitor) Track(path string) {
+	if j.ttl <= 0 {
+		return
+	}
+	
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.files[path] = time.Now()
+}
+
+// Run deletes expired files every minute until ctx is done
+func (j *FileJanitor) Run(ctx context.Context) {
+	if j.ttl <= 0 {
+		return
+	}
+	
+	ticker := time.NewTicker(janitorInterval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			j.sweep(now)
+		}
+	}
+}
+
+// sweep deletes the tracked files that have expired by now. A file that is
+// already gone is forgotten, one that can't be deleted is retried next time.
+func (j *FileJanitor) sweep(now time.Time) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	
+	for path, generated := range j.files {
+		if now.Sub(generated) < j.ttl {
+			continue
+		}
+		
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, "Warning: Unable to delete expired invoice %s: %v\n", path, err)
+			continue
+		}
+		delete(j.files, path)
+	}
+}
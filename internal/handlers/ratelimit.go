@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// rateLimitWindow is the period the request limit applies to
+const rateLimitWindow = time.Minute
+
+// rateWindow counts the requests of one client in the current window
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// rateLimiter allows each client IP a fixed number of requests per minute
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	windows map[string]*rateWindow
+}
+
+// RateLimit returns a middleware that allows each client IP at most limit
+// requests per minute and answers further requests with 429 Too Many Requests.
+// A limit of zero or less lets every request through.
+func RateLimit(limit int) gin.HandlerFunc {
+	if limit <= 0 {
+		return func(c *gin.Context) {
+			c.Next()
+		}
+	}
+	
+	limiter := &rateLimiter{
+		limit:   limit,
+		windows: make(map[string]*rateWindow),
+	}
+	return func(c *gin.Context) {
+		if wait, ok := limiter.allow(c.ClientIP(), time.Now()); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"success": false, "message": "Too many requests, please try again later"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// allow counts a request of a client and reports whether it is within the
+// limit, or else how long the client has to wait
+func (l *rateLimiter) allow(client string, now time.Time) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	
+	// Drop expired windows so the map doesn't grow with every client ever seen
+	for key, window := range l.windows {
+		if now.Sub(window.start) >= rateLimitWindow {
+			delete(l.windows, key)
+		}
+	}
+	
+	window, ok := l.windows[client]
+	if !ok {
+		window = &rateWindow{start: now}
+		l.windows[client] = window
+	}
+	
+	if window.count >= l.limit {
+		return window.start.Add(rateLimitWindow).Sub(now), false
+	}
+	window.count++
+	return 0, true
+}
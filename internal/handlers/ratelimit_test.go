@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	
+	tests := []struct {
+		name   string
+		client string
+		offset time.Duration
+		allow  bool
+		wait   time.Duration
+	}{
+		{"first request", "10.0.0.1", 0, true, 0},
+		{"second request", "10.0.0.1", 10 * time.Second, true, 0},
+		{"over the limit", "10.0.0.1", 20 * time.Second, false, 40 * time.Second},
+		{"other client has its own budget", "10.0.0.2", 20 * time.Second, true, 0},
+		{"still over the limit", "10.0.0.1", 59 * time.Second, false, time.Second},
+		{"next window", "10.0.0.1", time.Minute, true, 0},
+	}
+	
+	// The cases run in order against one limiter of two requests per minute
+	limiter := &rateLimiter{limit: 2, windows: map[string]*rateWindow{}}
+	for _, tt := range tests {
+		wait, ok := limiter.allow(tt.client, start.Add(tt.offset))
+		if ok != tt.allow || wait != tt.wait {
+			t.Errorf("%s: allow = %v, %v, want %v, %v", tt.name, wait, ok, tt.wait, tt.allow)
+		}
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	tests := []struct {
+		name  string
+		limit int
+		want  []int
+	}{
+		{"disabled", 0, []int{http.StatusOK, http.StatusOK, http.StatusOK}},
+		{"two per minute", 2, []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/api/generate", RateLimit(tt.limit), func(c *gin.Context) { c.Status(http.StatusOK) })
+			
+			for i, want := range tt.want {
+				recorder := httptest.NewRecorder()
+				router.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/api/generate", nil))
+				if recorder.Code != want {
+					t.Fatalf("request %d: status = %d, want %d", i+1, recorder.Code, want)
+				}
+				if want == http.StatusTooManyRequests && recorder.Header().Get("Retry-After") == "" {
+					t.Errorf("request %d: no Retry-After header", i+1)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	
+	"invoice/internal/models"
+	"invoice/internal/services/currency"
+)
+
+// indexTemplateFile is the name of the web UI page inside the template directory
+const indexTemplateFile = "index.html"
+
+// IndexData holds the values injected into the index page
+type IndexData struct {
+	Currencies []currency.Currency
+	Currency   string
+	Tax        float64
+}
+
+// NewIndexData collects the currencies and the invoice defaults shown in the form
+func NewIndexData(currencyService currency.Service) IndexData {
+	defaults := models.DefaultInvoice()
+	return IndexData{
+		Currencies: currency.SortedCurrencies(currencyService),
+		Currency:   defaults.Currency,
+		Tax:        defaults.Tax,
+	}
+}
+
+// TaxPercent returns the default tax rate as a percentage, e.g. "19"
+func (d IndexData) TaxPercent() string {
+	return strconv.FormatFloat(math.Round(d.Tax*10000)/100, 'f', -1, 64)
+}
+
+// LoadIndexTemplate parses index.html from the template directory, so the web
+// UI can be customized without recompiling. Without such a file the page
+// built into the binary is used.
+func LoadIndexTemplate(dir string, fallback []byte) (*template.Template, error) {
+	text := fallback
+	path := filepath.Join(dir, indexTemplateFile)
+	if data, err := os.ReadFile(path); err == nil {
+		text = data
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("unable to read template %s: %v", path, err)
+	}
+	
+	tmpl, err := template.New(indexTemplateFile).Parse(string(text))
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %v", path, err)
+	}
+	return tmpl, nil
+}
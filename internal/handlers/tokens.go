@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+	
+	"invoice/internal/services/invoice"
+)
+
+// pdfTokenTTL is how long a rendered invoice stays available under its token
+const pdfTokenTTL = 30 * time.Minute
+
+// pdfToken is a pending invoice waiting to be streamed
+type pdfToken struct {
+	options *invoice.GenerateOptions
+	created time.Time
+}
+
+// pdfTokenStore maps opaque tokens to parsed invoices so PDFs can be streamed
+// without exposing filenames or writing anything to disk
+type pdfTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]pdfToken
+}
+
+// newPDFTokenStore creates an empty token store
+func newPDFTokenStore() *pdfTokenStore {
+	return &pdfTokenStore{
+		tokens: make(map[string]pdfToken),
+	}
+}
+
+// Add stores the options under a new random token and returns it
+func (s *pdfTokenStore) Add(options *invoice.GenerateOptions) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+	
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	// Drop expired tokens so the store doesn't grow without bound
+	now := time.Now()
+	for key, entry := range s.tokens {
+		if now.Sub(entry.created) > pdfTokenTTL {
+			delete(s.tokens, key)
+		}
+	}
+	
+	s.tokens[token] = pdfToken{options: options, created: now}
+	return token, nil
+}
+
+// Get returns the options for a token if it exists and hasn't expired
+func (s *pdfTokenStore) Get(token string) (*invoice.GenerateOptions, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	entry, ok := s.tokens[token]
+	if !ok || time.Since(entry.created) > pdfTokenTTL {
+		return nil, false
+	}
+	return entry.options, true
+}
@@ -2,57 +2,115 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"html/template"
 	"net/http"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 	
+	"invoice/internal/config"
 	"invoice/internal/models"
+	"invoice/internal/services/currency"
+	"invoice/internal/services/email"
 	"invoice/internal/services/invoice"
+	"invoice/internal/services/ledger"
+	"invoice/internal/services/sequence"
+	"invoice/internal/services/upload"
 	
 	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
 )
 
 // WebHandler handles web interface requests
 type WebHandler struct {
 	invoiceService   invoice.Service
-	configLoader     models.ConfigLoader
+	currencyService  currency.Service
+	configLoader     config.ConfigLoader
 	webConfig        models.WebConfig
-	htmlTemplateText string
+	indexTemplate    *template.Template
+	uploader         upload.Uploader
+	sequenceStore    sequence.Store
+	pdfTokens        *pdfTokenStore
+	janitor          *FileJanitor
+	
+	// Ledger the generated invoices are recorded in, off if empty
+	ledgerPath string
 }
 
-// NewWebHandler creates a new WebHandler instance
+// NewWebHandler creates a new WebHandler instance. Invoices with the id
+// "next" are numbered from sequenceStore, and generated invoices are recorded
+// in the ledger at ledgerPath unless it is empty.
 func NewWebHandler(
 	invoiceService invoice.Service,
-	configLoader models.ConfigLoader,
+	currencyService currency.Service,
+	configLoader config.ConfigLoader,
 	webConfig models.WebConfig,
-	htmlTemplateText string,
+	indexTemplate *template.Template,
+	uploader upload.Uploader,
+	sequenceStore sequence.Store,
+	ledgerPath string,
 ) *WebHandler {
 	return &WebHandler{
 		invoiceService:   invoiceService,
+		currencyService:  currencyService,
 		configLoader:     configLoader,
 		webConfig:        webConfig,
-		htmlTemplateText: htmlTemplateText,
+		indexTemplate:    indexTemplate,
+		uploader:         uploader,
+		sequenceStore:    sequenceStore,
+		pdfTokens:        newPDFTokenStore(),
+		janitor:          NewFileJanitor(time.Duration(webConfig.FileTTL) * time.Minute),
+		ledgerPath:       ledgerPath,
 	}
 }
 
+// RunJanitor deletes generated PDFs once they expire, until ctx is done
+func (h *WebHandler) RunJanitor(ctx context.Context) {
+	h.janitor.Run(ctx)
+}
+
 // RegisterRoutes registers all web routes to the provided router
 func (h *WebHandler) RegisterRoutes(router *gin.Engine) {
+	// Match routes on the escaped path, so an encoded slash in a file name
+	// reaches the handler and is rejected instead of falling through to a 404
+	router.UseRawPath = true
+	router.UnescapePathValues = true
+	
+	// Credentials, if configured, are required for everything but the health check
+	router.Use(RequireAuth(h.webConfig))
+	
 	// Serve static files
-	router.Static("/static", "./web/static")
+	router.Static("/static", h.webConfig.StaticDir)
+	
+	// Health check for load balancers, outside of the API
+	router.GET("/healthz", h.handleHealth)
+	
+	// Generating and uploading share one per-client budget of requests per minute
+	rateLimit := RateLimit(h.webConfig.RateLimit)
 	
 	// API routes
 	api := router.Group("/api")
 	{
 		// Generate invoice
-		api.POST("/generate", h.handleGenerateInvoice)
+		api.POST("/generate", rateLimit, h.handleGenerateInvoice)
+		
+		// Compute totals without rendering a PDF
+		api.POST("/preview", h.handlePreview)
+		
+		// Prepare an invoice for streaming and stream it by token
+		api.POST("/render", h.handleRenderInvoice)
+		api.GET("/pdf/:token", h.handleStreamPDF)
 		
 		// List available configuration files
 		api.GET("/config-files", h.handleListConfigFiles)
 		
+		// List the supported currencies for the currency dropdown
+		api.GET("/currencies", h.handleListCurrencies)
+		
 		// Get config file data for pre-filling form
 		api.GET("/config-data/:filename", h.handleGetConfigData)
 		
@@ -62,18 +120,42 @@ func (h *WebHandler) RegisterRoutes(router *gin.Engine) {
 		// Download generated PDF
 		api.GET("/download/:filename", h.handleDownloadPDF)
 		
-		// Upload to Nextcloud
-		api.POST("/upload/:filename", h.handleUpload)
+		// Upload to the configured backend
+		api.POST("/upload/:filename", rateLimit, h.handleUpload)
+		
+		// Email a generated PDF
+		api.POST("/email/:filename", h.handleEmail)
 	}
 	
-	// Handle index route - serve the HTML template directly
+	// Handle index route - serve the rendered HTML template
 	router.GET("/", h.handleIndex)
 }
 
-// handleIndex serves the index page
+// handleIndex renders the index page with the supported currencies and defaults
 func (h *WebHandler) handleIndex(c *gin.Context) {
-	c.Header("Content-Type", "text/html")
-	c.String(http.StatusOK, h.htmlTemplateText)
+	var page bytes.Buffer
+	if err := h.indexTemplate.Execute(&page, NewIndexData(h.currencyService)); err != nil {
+		c.String(http.StatusInternalServerError, "Failed to render page: %v", err)
+		return
+	}
+	
+	c.Data(http.StatusOK, "text/html; charset=utf-8", page.Bytes())
+}
+
+// handleHealth reports whether the server is ready to generate invoices
+func (h *WebHandler) handleHealth(c *gin.Context) {
+	if err := h.invoiceService.CheckReady(); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "message": err.Error()})
+		return
+	}
+	
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// handleListCurrencies returns the supported currencies, including those from
+// currency.json, sorted by code
+func (h *WebHandler) handleListCurrencies(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"success": true, "currencies": currency.SortedCurrencies(h.currencyService)})
 }
 
 // handleGenerateInvoice generates an invoice from a web request
@@ -87,32 +169,131 @@ func (h *WebHandler) handleGenerateInvoice(c *gin.Context) {
 	// Parse the request into generate options
 	options, err := h.invoiceService.ParseRequest(&request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false, 
-			"message": "Failed to parse request: " + err.Error(),
-		})
+		ServiceError(c, "Failed to parse request: ", err)
 		return
 	}
 	
+	// Draw a number only once the invoice is known to be valid
+	if options.Invoice.Id == sequence.NextId {
+		id, err := h.sequenceStore.Next(time.Now().Year())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Failed to get the next invoice number: " + err.Error()})
+			return
+		}
+		options.Invoice.Id = id
+		options.OutputPath = filepath.Join(filepath.Dir(options.OutputPath), id+options.Invoice.IdSuffix+".pdf")
+	}
+	
 	// Generate the invoice
-	filename, err := h.invoiceService.Generate(options)
+	result, err := h.invoiceService.Generate(options)
+	if err != nil {
+		ServiceError(c, "Failed to generate invoice: ", err)
+		return
+	}
+	
+	h.janitor.Track(result.Path)
+	h.recordInvoice(&options.Invoice, result.Path)
+	
+	// Only the bare name is exposed - files are always served from the output directory
+	response := gin.H{
+		"success":  true,
+		"filename": filepath.Base(result.Path),
+		"subtotal": result.Totals.Subtotal,
+		"tax":      result.Totals.Tax,
+		"total":    result.Totals.Total,
+		"currency": result.Currency,
+	}
+	if err := options.Invoice.CheckTotal(); err != nil {
+		response["warning"] = err.Error()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handlePreview returns the computed totals for a web request so the form
+// can show a live total without generating a PDF
+func (h *WebHandler) handlePreview(c *gin.Context) {
+	var request models.InvoiceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+	
+	options, err := h.invoiceService.ParseRequest(&request)
+	if err != nil {
+		ServiceError(c, "Failed to parse request: ", err)
+		return
+	}
+	
+	response := gin.H{
+		"success":  true,
+		"currency": options.Invoice.Currency,
+		"totals":   models.ComputeInvoice(&options.Invoice),
+	}
+	if err := options.Invoice.CheckTotal(); err != nil {
+		response["warning"] = err.Error()
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// handleRenderInvoice parses a web request and returns an opaque token
+// under which the PDF can be streamed, without writing a file
+func (h *WebHandler) handleRenderInvoice(c *gin.Context) {
+	var request models.InvoiceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+		return
+	}
+	
+	options, err := h.invoiceService.ParseRequest(&request)
+	if err != nil {
+		ServiceError(c, "Failed to parse request: ", err)
+		return
+	}
+	
+	token, err := h.pdfTokens.Add(options)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false, 
-			"message": "Failed to generate invoice: " + err.Error(),
+			"success": false,
+			"message": "Failed to create token: " + err.Error(),
 		})
 		return
 	}
 	
 	c.JSON(http.StatusOK, gin.H{
-		"success":  true,
-		"filename": filename,
+		"success": true,
+		"token":   token,
+		"url":     "/api/pdf/" + token,
 	})
 }
 
+// handleStreamPDF renders the invoice for a token straight into the response.
+// Pass ?download=true to get an attachment instead of an inline view.
+func (h *WebHandler) handleStreamPDF(c *gin.Context) {
+	options, ok := h.pdfTokens.Get(c.Param("token"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Unknown or expired invoice token"})
+		return
+	}
+	
+	disposition := "inline"
+	if c.Query("download") == "true" {
+		disposition = "attachment"
+	}
+	c.Header("Content-Type", "application/pdf")
+	c.Header("Content-Disposition", fmt.Sprintf("%s; filename=%q", disposition, filepath.Base(options.OutputPath)))
+	
+	// The renderer lays out the whole document before writing, so a layout
+	// failure can still be reported as JSON
+	if err := h.invoiceService.Render(options, c.Writer); err != nil && !c.Writer.Written() {
+		c.Header("Content-Type", "")
+		c.Header("Content-Disposition", "")
+		ServiceError(c, "Failed to render invoice: ", err)
+	}
+}
+
 // handleListConfigFiles lists all available config files
 func (h *WebHandler) handleListConfigFiles(c *gin.Context) {
-	files, err := h.findConfigFiles()
+	files, err := config.FindConfigFiles(h.webConfig.ConfigDir)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
 		return
@@ -123,6 +304,11 @@ func (h *WebHandler) handleListConfigFiles(c *gin.Context) {
 // handleGetConfigData returns the data from a config file
 func (h *WebHandler) handleGetConfigData(c *gin.Context) {
 	filename := c.Param("filename")
+	if err := checkBareFilename(filename); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+	
 	configData, err := h.getConfigData(filename)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
@@ -133,21 +319,30 @@ func (h *WebHandler) handleGetConfigData(c *gin.Context) {
 
 // handleViewPDF serves a PDF file for viewing
 func (h *WebHandler) handleViewPDF(c *gin.Context) {
-	filename := c.Param("filename")
-	c.File(filename)
+	path, ok := h.outputFilePath(c)
+	if !ok {
+		return
+	}
+	c.File(path)
 }
 
 // handleDownloadPDF serves a PDF file for download
 func (h *WebHandler) handleDownloadPDF(c *gin.Context) {
-	filename := c.Param("filename")
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
-	c.File(filename)
+	path, ok := h.outputFilePath(c)
+	if !ok {
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(path)))
+	c.File(path)
 }
 
-// handleUpload handles file upload to Nextcloud
+// handleUpload uploads a generated file using the configured upload backend
 func (h *WebHandler) handleUpload(c *gin.Context) {
-	filename := c.Param("filename")
-	result, err := h.uploadToNextcloud(filename, h.webConfig.UploadScript, h.webConfig.NextcloudURL, h.webConfig.NextcloudShare)
+	path, ok := h.outputFilePath(c)
+	if !ok {
+		return
+	}
+	result, err := h.uploader.Upload(path)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
@@ -159,50 +354,116 @@ func (h *WebHandler) handleUpload(c *gin.Context) {
 	c.JSON(http.StatusOK, result)
 }
 
-// findConfigFiles returns a list of JSON and YAML config files
-func (h *WebHandler) findConfigFiles() ([]string, error) {
-	var files []string
+// emailRequest is the optional body of an email request. ConfigFile supplies
+// the invoice id, total and recipient for the message.
+type emailRequest struct {
+	To         string `json:"to"`
+	ConfigFile string `json:"configFile"`
+}
+
+// handleEmail sends a generated PDF to the client via SMTP
+func (h *WebHandler) handleEmail(c *gin.Context) {
+	path, ok := h.outputFilePath(c)
+	if !ok {
+		return
+	}
 	
-	// Find JSON and YAML files in the config directory
-	configDir := "config"
-	jsonFiles, err := filepath.Glob(filepath.Join(configDir, "*.json"))
-	if err != nil {
-		return nil, err
+	var request emailRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Invalid request data"})
+			return
+		}
+	}
+	
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "File not found"})
+		return
+	}
+	
+	// Without a config the invoice number is taken from the file name
+	inv := models.Invoice{Id: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))}
+	if request.ConfigFile != "" {
+		loaded, err := h.configLoader.LoadInvoice(filepath.Join(h.webConfig.ConfigDir, filepath.Base(request.ConfigFile)))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		inv = *loaded
 	}
-	ymlFiles, err := filepath.Glob(filepath.Join(configDir, "*.yml"))
+	
+	message, err := email.InvoiceMessage(&inv, path, request.To)
 	if err != nil {
-		return nil, err
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
 	}
-	yamlFiles, err := filepath.Glob(filepath.Join(configDir, "*.yaml"))
+	
+	sender, err := email.NewSMTPSender(h.webConfig.Email)
 	if err != nil {
-		return nil, err
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": err.Error()})
+		return
 	}
 	
-	// Merge all files
-	files = append(files, jsonFiles...)
-	files = append(files, ymlFiles...)
-	files = append(files, yamlFiles...)
+	if err := sender.Send(message); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Sending failed: " + err.Error(),
+		})
+		return
+	}
 	
-	// Filter out non-invoice config files
-	var configFiles []string
-	for _, file := range files {
-		// Skip known non-invoice config files
-		basename := filepath.Base(file)
-		if basename == "currency.json" || basename == "web_config.json" {
-			continue
-		}
-		configFiles = append(configFiles, file)
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Invoice sent to " + message.To})
+}
+
+// recordInvoice adds a generated invoice to the ledger, if one is set. A
+// failure is only a warning, as the invoice itself was written.
+func (h *WebHandler) recordInvoice(inv *models.Invoice, file string) {
+	if h.ledgerPath == "" {
+		return
 	}
 	
-	return configFiles, nil
+	if err := ledger.Append(h.ledgerPath, ledger.NewRecord(inv, file)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invoice %s was not recorded in the ledger: %v\n", inv.Id, err)
+	}
+}
+
+// outputFilePath resolves the :filename param to a PDF inside the output directory.
+// Anything that could escape it is rejected with 400 Bad Request.
+func (h *WebHandler) outputFilePath(c *gin.Context) (string, bool) {
+	filename, err := sanitizeFilename(c.Param("filename"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return "", false
+	}
+	return filepath.Join(h.webConfig.OutputDir, filename), true
+}
+
+// sanitizeFilename accepts only a bare PDF file name without any path components
+func sanitizeFilename(filename string) (string, error) {
+	if err := checkBareFilename(filename); err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(filepath.Ext(filename), ".pdf") {
+		return "", fmt.Errorf("invalid filename: only .pdf files can be served")
+	}
+	return filename, nil
 }
 
-// getConfigData gets the data from a config file
-func (h *WebHandler) getConfigData(filename string) (map[string]interface{}, error) {
-	// Ensure we're looking in the config directory
-	if filepath.Dir(filename) == "." {
-		filename = filepath.Join("config", filename)
+// checkBareFilename rejects file names with path components, which could
+// reach outside the directory the name is looked up in
+func checkBareFilename(filename string) error {
+	if filename == "" || strings.Contains(filename, "..") ||
+		strings.ContainsAny(filename, `/\`) ||
+		filepath.Base(filename) != filename {
+		return fmt.Errorf("invalid filename: %q", filename)
 	}
+	return nil
+}
+
+// getConfigData gets the data from a config file in the config directory,
+// name must be a bare file name
+func (h *WebHandler) getConfigData(name string) (map[string]interface{}, error) {
+	filename := filepath.Join(h.webConfig.ConfigDir, name)
 	
 	// Read the file
 	fileText, err := os.ReadFile(filename)
@@ -224,59 +485,20 @@ func (h *WebHandler) getConfigData(filename string) (map[string]interface{}, err
 		if err != nil {
 			return nil, fmt.Errorf("invalid JSON: %v", err)
 		}
-		
-		// Ensure tax exemption is properly reflected in the UI
-		// If taxExempt is true, ensure tax is set to 0
-		if taxExempt, ok := configData["taxExempt"].(bool); ok && taxExempt {
-			configData["tax"] = 0
-		}
-		
 	} else if strings.HasSuffix(filename, ".yaml") || strings.HasSuffix(filename, ".yml") {
-		return nil, fmt.Errorf("YAML files not supported for web interface preview")
+		err = yaml.Unmarshal(fileText, &configData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %v", err)
+		}
 	} else {
-		return nil, fmt.Errorf("unsupported file type: only .json is supported for preview")
-	}
-	
-	return configData, nil
-}
-
-// uploadToNextcloud uploads a file to Nextcloud using the provided script
-func (h *WebHandler) uploadToNextcloud(filename, scriptPath, nextcloudURL, shareID string) (models.UploadResult, error) {
-	result := models.UploadResult{
-		Success: false,
-	}
-	
-	// Check if the upload script exists
-	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
-		return result, fmt.Errorf("upload script not found: %s", scriptPath)
+		return nil, fmt.Errorf("unsupported file type: only .json, .yaml, or .yml are supported for preview")
 	}
 	
-	// Check if the file exists
-	if _, err := os.Stat(filename); os.IsNotExist(err) {
-		return result, fmt.Errorf("file not found: %s", filename)
+	// Ensure tax exemption is properly reflected in the UI
+	// If taxExempt is true, ensure tax is set to 0
+	if taxExempt, ok := configData["taxExempt"].(bool); ok && taxExempt {
+		configData["tax"] = 0
 	}
 	
-	// Construct the share URL
-	shareURL := nextcloudURL + shareID
-	
-	// Run the upload script
-	cmd := exec.Command(scriptPath, filename, shareURL)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	
-	err := cmd.Run()
-	if err != nil {
-		return result, fmt.Errorf("upload failed: %v\nStderr: %s", err, stderr.String())
-	}
-	
-	// Format the correct Nextcloud share URL
-	// This creates a URL like: https://cloud.seiffert.me/index.php/s/CAr4Gfs9NFd9RqG?path=&files=filename.pdf
-	formattedURL := fmt.Sprintf("%s?path=&files=%s", shareURL, filename)
-	
-	result.Success = true
-	result.URL = formattedURL
-	result.Message = "File uploaded successfully"
-	
-	return result, nil
+	return configData, nil
 }
@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	
+	"invoice/internal/config"
+	"invoice/internal/models"
+	"invoice/internal/services/currency"
+	"invoice/internal/services/invoice"
+	
+	"github.com/gin-gonic/gin"
+)
+
+// stubRenderer writes a placeholder PDF instead of laying out the invoice,
+// so the handlers can be tested without fonts
+type stubRenderer struct{}
+
+func (stubRenderer) Render(inv *models.Invoice, w io.Writer) error {
+	_, err := io.WriteString(w, "%PDF-1.4 "+inv.Id+"\n")
+	return err
+}
+
+func (r stubRenderer) RenderToFile(inv *models.Invoice, filePath string) error {
+	file, err := os.Create(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return r.Render(inv, file)
+}
+
+func (stubRenderer) CheckFonts() error {
+	return nil
+}
+
+// stubUploader records the uploaded paths
+type stubUploader struct {
+	uploaded []string
+}
+
+func (u *stubUploader) Upload(localPath string) (models.UploadResult, error) {
+	u.uploaded = append(u.uploaded, localPath)
+	return models.UploadResult{Success: true}, nil
+}
+
+// testWebConfig returns a web config with its directories in temporary directories
+func testWebConfig(t *testing.T) models.WebConfig {
+	t.Helper()
+	webConfig := models.DefaultWebConfig()
+	webConfig.ConfigDir = t.TempDir()
+	webConfig.OutputDir = t.TempDir()
+	webConfig.StaticDir = t.TempDir()
+	return webConfig
+}
+
+// newTestRouter registers the routes of a WebHandler backed by the stubs
+func newTestRouter(t *testing.T, webConfig models.WebConfig, uploader *stubUploader) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	
+	loader := config.NewConfigLoader()
+	service := invoice.NewInvoiceService(stubRenderer{}, loader, webConfig.ConfigDir, webConfig.OutputDir)
+	index := template.Must(template.New("index").Parse("index"))
+	handler := NewWebHandler(service, currency.NewCurrencyService(), loader, webConfig, index, uploader, nil, "")
+	
+	router := gin.New()
+	handler.RegisterRoutes(router)
+	return router
+}
+
+// serve sends a request to the router and returns the recorded response
+func serve(router http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func TestRenderAndStreamPDF(t *testing.T) {
+	router := newTestRouter(t, testWebConfig(t), &stubUploader{})
+	
+	response := serve(router, http.MethodPost, "/api/render", `{"id": "R-1", "items": [{"description": "Beratung", "quantity": 2, "rate": 100}]}`)
+	if response.Code != http.StatusOK {
+		t.Fatalf("POST /api/render = %d %s", response.Code, response.Body)
+	}
+	var rendered struct {
+		Token string `json:"token"`
+		URL   string `json:"url"`
+	}
+	if err := json.Unmarshal(response.Body.Bytes(), &rendered); err != nil {
+		t.Fatal(err)
+	}
+	if rendered.Token == "" || rendered.URL != "/api/pdf/"+rendered.Token {
+		t.Fatalf("token, url = %q, %q", rendered.Token, rendered.URL)
+	}
+	
+	tests := []struct {
+		query       string
+		disposition string
+	}{
+		{"", `inline; filename="R-1.pdf"`},
+		{"?download=true", `attachment; filename="R-1.pdf"`},
+	}
+	for _, tt := range tests {
+		response := serve(router, http.MethodGet, rendered.URL+tt.query, "")
+		if response.Code != http.StatusOK {
+			t.Fatalf("GET %s%s = %d", rendered.URL, tt.query, response.Code)
+		}
+		if got := response.Header().Get("Content-Type"); got != "application/pdf" {
+			t.Errorf("Content-Type = %q, want application/pdf", got)
+		}
+		if got := response.Header().Get("Content-Disposition"); got != tt.disposition {
+			t.Errorf("Content-Disposition = %q, want %q", got, tt.disposition)
+		}
+		if !strings.HasPrefix(response.Body.String(), "%PDF-") {
+			t.Errorf("body = %q, want a PDF", response.Body)
+		}
+	}
+}
+
+func TestStreamPDFUnknownToken(t *testing.T) {
+	router := newTestRouter(t, testWebConfig(t), &stubUploader{})
+	
+	if response := serve(router, http.MethodGet, "/api/pdf/0123456789abcdef", ""); response.Code != http.StatusNotFound {
+		t.Errorf("GET unknown token = %d, want 404", response.Code)
+	}
+}
+
+func TestRenderRejectsInvalidInvoice(t *testing.T) {
+	router := newTestRouter(t, testWebConfig(t), &stubUploader{})
+	
+	response := serve(router, http.MethodPost, "/api/render", `{"id": "../evil"}`)
+	if response.Code != http.StatusBadRequest {
+		t.Errorf("POST /api/render with a bad id = %d, want 400", response.Code)
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		filename string
+		valid    bool
+	}{
+		{"Rechnung-1.pdf", true},
+		{"invoice.PDF", true},
+		{"", false},
+		{"..", false},
+		{"../secret.pdf", false},
+		{"..\\secret.pdf", false},
+		{"/etc/passwd.pdf", false},
+		{"dir/invoice.pdf", false},
+		{`C:\invoice.pdf`, false},
+		{"secret.txt", false},
+		{"invoice", false},
+	}
+	for _, tt := range tests {
+		_, err := sanitizeFilename(tt.filename)
+		if (err == nil) != tt.valid {
+			t.Errorf("sanitizeFilename(%q) error = %v, want valid %v", tt.filename, err, tt.valid)
+		}
+	}
+}
+
+func TestFileRoutesRejectInvalidNames(t *testing.T) {
+	uploader := &stubUploader{}
+	router := newTestRouter(t, testWebConfig(t), uploader)
+	
+	names := []string{"..%5Csecret.pdf", "..%2Fsecret.pdf", "%2Fetc%2Fpasswd.pdf", "secret.txt", ".."}
+	for _, name := range names {
+		for _, route := range []string{"/api/view/", "/api/download/"} {
+			response := serve(router, http.MethodGet, route+name, "")
+			if response.Code != http.StatusBadRequest {
+				t.Errorf("GET %s%s = %d, want 400", route, name, response.Code)
+			}
+		}
+		response := serve(router, http.MethodPost, "/api/upload/"+name, "")
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("POST /api/upload/%s = %d, want 400", name, response.Code)
+		}
+	}
+	if len(uploader.uploaded) != 0 {
+		t.Errorf("uploaded %v, want nothing", uploader.uploaded)
+	}
+}
+
+func TestConfigDataStaysInConfigDir(t *testing.T) {
+	webConfig := testWebConfig(t)
+	router := newTestRouter(t, webConfig, &stubUploader{})
+	
+	if err := os.WriteFile(filepath.Join(webConfig.ConfigDir, "kunde.yaml"), []byte("to: Kunde AG\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(t.TempDir(), "secret.json")
+	if err := os.WriteFile(secret, []byte(`{"password": "geheim"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	response := serve(router, http.MethodGet, "/api/config-data/kunde.yaml", "")
+	if response.Code != http.StatusOK || !strings.Contains(response.Body.String(), "Kunde AG") {
+		t.Errorf("GET /api/config-data/kunde.yaml = %d %q", response.Code, response.Body)
+	}
+	
+	escaped := strings.ReplaceAll(secret, "/", "%2F")
+	for _, name := range []string{escaped, "..%2Fsecret.json", "..%5Csecret.json", ".."} {
+		response := serve(router, http.MethodGet, "/api/config-data/"+name, "")
+		if response.Code != http.StatusBadRequest || strings.Contains(response.Body.String(), "geheim") {
+			t.Errorf("GET /api/config-data/%s = %d %q, want 400", name, response.Code, response.Body)
+		}
+	}
+}
+
+func TestFileRoutesServeFromOutputDir(t *testing.T) {
+	webConfig := testWebConfig(t)
+	uploader := &stubUploader{}
+	router := newTestRouter(t, webConfig, uploader)
+	
+	if err := os.WriteFile(filepath.Join(webConfig.OutputDir, "R-1.pdf"), []byte("%PDF-1.4 R-1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	response := serve(router, http.MethodGet, "/api/view/R-1.pdf", "")
+	if response.Code != http.StatusOK || !strings.HasPrefix(response.Body.String(), "%PDF-") {
+		t.Errorf("GET /api/view/R-1.pdf = %d %q", response.Code, response.Body)
+	}
+	response = serve(router, http.MethodGet, "/api/download/R-1.pdf", "")
+	if got := response.Header().Get("Content-Disposition"); got != `attachment; filename="R-1.pdf"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	
+	response = serve(router, http.MethodPost, "/api/upload/R-1.pdf", "")
+	if response.Code != http.StatusOK {
+		t.Errorf("POST /api/upload/R-1.pdf = %d", response.Code)
+	}
+	if want := filepath.Join(webConfig.OutputDir, "R-1.pdf"); len(uploader.uploaded) != 1 || uploader.uploaded[0] != want {
+		t.Errorf("uploaded %v, want [%s]", uploader.uploaded, want)
+	}
+	
+	// A file outside the output directory is not found, even in the working directory
+	cwd := t.TempDir()
+	if err := os.WriteFile(filepath.Join(cwd, "R-2.pdf"), []byte("%PDF-1.4 R-2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(previous)
+	if response := serve(router, http.MethodGet, "/api/view/R-2.pdf", ""); response.Code != http.StatusNotFound {
+		t.Errorf("GET /api/view/R-2.pdf = %d, want 404", response.Code)
+	}
+}
+
+func TestGenerateItemsWithCommas(t *testing.T) {
+	webConfig := testWebConfig(t)
+	router := newTestRouter(t, webConfig, &stubUploader{})
+	
+	body := `{"id": "R-1", "items": [{"description": "Consulting, March", "quantity": 2, "rate": 100}]}`
+	for _, route := range []string{"/api/preview", "/api/generate"} {
+		response := serve(router, http.MethodPost, route, body)
+		if response.Code != http.StatusOK {
+			t.Fatalf("POST %s = %d %s", route, response.Code, response.Body)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(webConfig.OutputDir, "R-1.pdf")); err != nil {
+		t.Errorf("generated file: %v", err)
+	}
+}
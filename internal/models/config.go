@@ -1,12 +1,107 @@
 package models
 
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+)
+
 // WebConfig holds the configuration for the web server
 type WebConfig struct {
+	// Host is the address to listen on, e.g. 127.0.0.1 behind a reverse
+	// proxy; empty listens on all interfaces
+	Host           string `json:"host" yaml:"host" env:"HOST"`
 	Port           int    `json:"port" yaml:"port" env:"PORT"`
 	NextcloudURL   string `json:"nextcloudUrl" yaml:"nextcloudUrl" env:"NEXTCLOUD_URL"`
 	NextcloudShare string `json:"nextcloudShare" yaml:"nextcloudShare" env:"NEXTCLOUD_SHARE"`
 	UploadScript   string `json:"uploadScript" yaml:"uploadScript" env:"UPLOAD_SCRIPT"`
+	UploadBackend  string `json:"uploadBackend" yaml:"uploadBackend" env:"UPLOAD_BACKEND"` // nextcloud (default), s3 or webdav
+	
+	// Link returned after a Nextcloud upload, with {url}, {share} and {file}
+	// filled in; defaults to "{url}{share}?path=&files={file}"
+	ShareURLTemplate string `json:"shareUrlTemplate" yaml:"shareUrlTemplate" env:"SHARE_URL_TEMPLATE"`
+	
+	// S3 upload backend
+	S3Endpoint  string `json:"s3Endpoint" yaml:"s3Endpoint" env:"S3_ENDPOINT"`
+	S3Region    string `json:"s3Region" yaml:"s3Region" env:"S3_REGION"`
+	S3Bucket    string `json:"s3Bucket" yaml:"s3Bucket" env:"S3_BUCKET"`
+	S3Prefix    string `json:"s3Prefix" yaml:"s3Prefix" env:"S3_PREFIX"`
+	S3AccessKey string `json:"s3AccessKey" yaml:"s3AccessKey" env:"S3_ACCESS_KEY"`
+	S3SecretKey string `json:"s3SecretKey" yaml:"s3SecretKey" env:"S3_SECRET_KEY"`
+	
+	// WebDAV upload backend
+	WebDAVURL      string `json:"webdavUrl" yaml:"webdavUrl" env:"WEBDAV_URL"`
+	WebDAVUser     string `json:"webdavUser" yaml:"webdavUser" env:"WEBDAV_USER"`
+	WebDAVPassword string `json:"webdavPassword" yaml:"webdavPassword" env:"WEBDAV_PASSWORD"`
+	
 	TemplateDir    string `json:"templateDir" yaml:"templateDir" env:"TEMPLATE_DIR"`
+	StaticDir      string `json:"staticDir" yaml:"staticDir" env:"STATIC_DIR"`
+	ConfigDir      string `json:"configDir" yaml:"configDir" env:"CONFIG_DIR"`
+	OutputDir      string `json:"outputDir" yaml:"outputDir" env:"OUTPUT_DIR"`
+	
+	// Optional protection of everything but the health check, by basic
+	// auth, a bearer token or both. Without either the server is open.
+	AuthUsername string `json:"authUsername" yaml:"authUsername" env:"AUTH_USERNAME"`
+	AuthPassword string `json:"authPassword" yaml:"authPassword" env:"AUTH_PASSWORD"`
+	AuthToken    string `json:"authToken" yaml:"authToken" env:"AUTH_TOKEN"`
+	
+	// Requests per minute each client may send to generate and upload
+	// invoices; zero means no limit
+	RateLimit int `json:"rateLimit" yaml:"rateLimit" env:"RATE_LIMIT"`
+	
+	// Minutes generated PDFs are kept before the server deletes them;
+	// zero keeps them forever
+	FileTTL int `json:"fileTTL" yaml:"fileTTL" env:"FILE_TTL"`
+	
+	// Where consecutive invoice numbers for --id next come from: a JSON file
+	// (the default) or Redis, which several servers can share
+	SequenceBackend       string `json:"sequenceBackend" yaml:"sequenceBackend" env:"SEQUENCE_BACKEND"` // file (default) or redis
+	SequenceFile          string `json:"sequenceFile" yaml:"sequenceFile" env:"SEQUENCE_FILE"`
+	SequenceRedisAddr     string `json:"sequenceRedisAddr" yaml:"sequenceRedisAddr" env:"SEQUENCE_REDIS_ADDR"`
+	SequenceRedisPassword string `json:"sequenceRedisPassword" yaml:"sequenceRedisPassword" env:"SEQUENCE_REDIS_PASSWORD"`
+	
+	// Seconds to wait for active requests to finish when the server is stopped
+	ShutdownTimeout int `json:"shutdownTimeout" yaml:"shutdownTimeout" env:"SHUTDOWN_TIMEOUT"`
+	
+	// SMTP settings for emailing invoices
+	Email EmailConfig `json:"email" yaml:"email"`
+}
+
+// Addr returns the host and port the web server listens on, e.g. "127.0.0.1:8080"
+func (c WebConfig) Addr() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
+
+// AuthEnabled reports whether the web server requires credentials
+func (c WebConfig) AuthEnabled() bool {
+	return c.AuthUsername != "" || c.AuthToken != ""
+}
+
+// ValidateAuth rejects incomplete basic auth credentials, which would
+// otherwise accept an empty password
+func (c WebConfig) ValidateAuth() error {
+	if c.AuthUsername != "" && c.AuthPassword == "" {
+		return fmt.Errorf("authPassword is required when authUsername is set")
+	}
+	if c.AuthPassword != "" && c.AuthUsername == "" {
+		return fmt.Errorf("authUsername is required when authPassword is set")
+	}
+	return nil
+}
+
+// EmailConfig holds the SMTP settings and message template for sending invoices.
+// Subject and Body are Go templates that can use {{.Id}}, {{.Total}} and {{.Due}}.
+// Total is empty when only the PDF is known, without the invoice data.
+type EmailConfig struct {
+	Host     string `json:"host" yaml:"host" env:"SMTP_HOST"`
+	Port     int    `json:"port" yaml:"port" env:"SMTP_PORT"`
+	Username string `json:"username" yaml:"username" env:"SMTP_USERNAME"`
+	Password string `json:"password" yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `json:"from" yaml:"from" env:"SMTP_FROM"`
+	TLS      string `json:"tls" yaml:"tls" env:"SMTP_TLS"` // starttls (default), tls or none
+	Subject  string `json:"subject" yaml:"subject"`
+	Body     string `json:"body" yaml:"body"`
 }
 
 // CurrencyConfig represents the currency configuration
@@ -24,11 +119,27 @@ type AppConfig struct {
 // DefaultWebConfig returns a WebConfig with default values
 func DefaultWebConfig() WebConfig {
 	return WebConfig{
-		Port:           8080,
-		NextcloudURL:   "https://cloud.example.com",
-		NextcloudShare: "/s/share-id",
-		UploadScript:   "/var/scripts/cloudsend.sh",
-		TemplateDir:    "web/templates",
+		Port:            8080,
+		NextcloudURL:    "https://cloud.example.com",
+		NextcloudShare:  "/s/share-id",
+		UploadScript:    "/var/scripts/cloudsend.sh",
+		TemplateDir:     "web/templates",
+		StaticDir:       "web/static",
+		ConfigDir:       "config",
+		OutputDir:       ".",
+		SequenceFile:    "config/sequence.json",
+		ShutdownTimeout: 30,
+		Email:           DefaultEmailConfig(),
+	}
+}
+
+// DefaultEmailConfig returns an EmailConfig with default values
+func DefaultEmailConfig() EmailConfig {
+	return EmailConfig{
+		Port:    587,
+		TLS:     "starttls",
+		Subject: "Rechnung {{.Id}}",
+		Body:    "Guten Tag,\n\nanbei erhalten Sie unsere Rechnung {{.Id}}{{with .Total}} über {{.}}{{end}}.\n\nMit freundlichen Grüßen",
 	}
 }
 
@@ -43,23 +154,82 @@ func DefaultAppConfig() AppConfig {
 
 // InvoiceRequest represents the form data from the web UI
 type InvoiceRequest struct {
-	From             string   `json:"from"`
-	To               string   `json:"to"`
-	Items            string   `json:"items"`
-	Quantities       string   `json:"quantities"`
-	Rates            string   `json:"rates"`
-	Tax              float64  `json:"tax"`
-	TaxExempt        bool     `json:"taxExempt"`
-	Discount         float64  `json:"discount"`
-	Currency         string   `json:"currency"`
-	Note             string   `json:"note"`
-	Id               string   `json:"id"`
-	IdSuffix         string   `json:"idSuffix"`
-	ConfigFile       string   `json:"configFile"`
-	UseConfig        bool     `json:"useConfig"`
-	ShowRegistration bool     `json:"showRegistration"`
-	ShowVatId        bool     `json:"showVatId"`
-	CompanyName      string   `json:"companyName"`
+	From             string               `json:"from"`
+	To               string               `json:"to"`
+	Items            []InvoiceItemRequest `json:"items"`
+	Tax              float64              `json:"tax"`
+	TaxExempt        bool                 `json:"taxExempt"`
+	Discount         float64              `json:"discount"`
+	DiscountType     string               `json:"discountType"`
+	Currency         string               `json:"currency"`
+	Note             string               `json:"note"`
+	Id               string               `json:"id"`
+	IdSuffix         string               `json:"idSuffix"`
+	ConfigFile       string               `json:"configFile"`
+	UseConfig        bool                 `json:"useConfig"`
+	ShowRegistration bool                 `json:"showRegistration"`
+	ShowVatId        bool                 `json:"showVatId"`
+	CompanyName      string               `json:"companyName"`
+	
+	// Deprecated: the old format sent items, quantities and rates as
+	// ||-joined strings. It is still accepted for one release.
+	LegacyItems string `json:"-"`
+	Quantities  string `json:"quantities"`
+	Rates       string `json:"rates"`
+}
+
+// InvoiceItemRequest is a single line item from the web UI. Quantity defaults
+// to 1. TaxRate is optional, items without one are taxed at the invoice's rate.
+type InvoiceItemRequest struct {
+	Description string   `json:"description"`
+	Quantity    int      `json:"quantity"`
+	Rate        float64  `json:"rate"`
+	TaxRate     *float64 `json:"taxRate,omitempty"`
+}
+
+// UnmarshalJSON accepts items either as a list of line items or, from clients
+// still using the old format, as a ||-joined string
+func (r *InvoiceRequest) UnmarshalJSON(data []byte) error {
+	type plain InvoiceRequest
+	aux := struct {
+		*plain
+		Items json.RawMessage `json:"items"`
+	}{plain: (*plain)(r)}
+	
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	
+	if len(aux.Items) > 0 && aux.Items[0] == '"' {
+		return json.Unmarshal(aux.Items, &r.LegacyItems)
+	}
+	if len(aux.Items) > 0 && string(aux.Items) != "null" {
+		return json.Unmarshal(aux.Items, &r.Items)
+	}
+	return nil
+}
+
+// ItemTaxRates returns the tax rate of every item for Invoice.ItemTaxRates,
+// its own or defaultRate, or nil if no item sets one
+func ItemTaxRates(items []InvoiceItemRequest, defaultRate float64) []float64 {
+	var rates []float64
+	for _, item := range items {
+		if item.TaxRate != nil {
+			rates = make([]float64, len(items))
+			break
+		}
+	}
+	if rates == nil {
+		return nil
+	}
+	
+	for i, item := range items {
+		rates[i] = defaultRate
+		if item.TaxRate != nil {
+			rates[i] = *item.TaxRate
+		}
+	}
+	return rates
 }
 
 // UploadResult represents the result of an upload operation
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultDateFormat is the German day.month.year format used unless an
+// invoice sets its own DateFormat
+const DefaultDateFormat = "02.01.2006"
+
+// monthNames are the month names per invoice language, independent of the
+// locale of the machine the invoice is generated on
+var monthNames = map[string][12]string{
+	"de": {"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+	"en": {"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+}
+
+// inputDateFormats are the date formats accepted in config files: German,
+// ISO 8601 and US month/day/year
+var inputDateFormats = []string{"02.01.2006", "2006-01-02", "01/02/2006"}
+
+// ParseDate parses a date in any of the accepted input formats
+func ParseDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range inputDateFormats {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q, use DD.MM.YYYY, YYYY-MM-DD or MM/DD/YYYY", value)
+}
+
+// MonthName returns the name of a month in the given language, "März" in
+// German. Unknown languages fall back to German like the labels.
+func MonthName(month time.Month, language string) string {
+	names, ok := monthNames[strings.ToLower(language)]
+	if !ok {
+		names = monthNames["de"]
+	}
+	return names[month-1]
+}
+
+// FormatDate formats a date with a Go layout, with the month names of a
+// layout such as "2. January 2006" or "Jan 2006" in the given language
+func FormatDate(date time.Time, layout, language string) string {
+	text := date.Format(layout)
+	name := MonthName(date.Month(), language)
+	
+	// "January" contains "Jan", so the full name is checked first
+	switch {
+	case strings.Contains(layout, "January"):
+		text = strings.Replace(text, date.Month().String(), name, 1)
+	case strings.Contains(layout, "Jan"):
+		text = strings.Replace(text, date.Month().String()[:3], string([]rune(name)[:3]), 1)
+	}
+	return text
+}
+
+// ParseFormattedDate parses a date printed by FormatDate with the same
+// layout and language
+func ParseFormattedDate(value, layout, language string) (time.Time, error) {
+	full := strings.Contains(layout, "January")
+	if full || strings.Contains(layout, "Jan") {
+		for month := time.January; month <= time.December; month++ {
+			name, english := MonthName(month, language), month.String()
+			if !full {
+				name, english = string([]rune(name)[:3]), english[:3]
+			}
+			if strings.Contains(value, name) {
+				value = strings.Replace(value, name, english, 1)
+				break
+			}
+		}
+	}
+	return time.Parse(layout, value)
+}
+
+// SkontoDate returns the last day the early payment discount may be taken,
+// SkontoDays after the invoice date, in the invoice's DateFormat. It is ""
+// if the invoice date can't be read.
+func (invoice *Invoice) SkontoDate() string {
+	format := invoice.DateFormat
+	if format == "" {
+		format = DefaultDateFormat
+	}
+	
+	date, err := ParseFormattedDate(invoice.Date, format, invoice.Language)
+	if err != nil {
+		if date, err = ParseDate(invoice.Date); err != nil {
+			return ""
+		}
+	}
+	return FormatDate(date.AddDate(0, 0, invoice.SkontoDays), format, invoice.Language)
+}
+
+// dateField names a date of the invoice for error messages
+type dateField struct {
+	name  string
+	value *string
+}
+
+// NormalizeDates rewrites the invoice, due, service, paid and item dates in the
+// invoice's DateFormat, so configs written with another locale's dates print
+// the same. Month names are printed in the invoice language. Empty dates are
+// left empty.
+func (invoice *Invoice) NormalizeDates() error {
+	format := invoice.DateFormat
+	if format == "" {
+		format = DefaultDateFormat
+	}
+	
+	fields := []dateField{
+		{"date", &invoice.Date},
+		{"due", &invoice.Due},
+		{"serviceDateFrom", &invoice.ServiceDateFrom},
+		{"serviceDateTo", &invoice.ServiceDateTo},
+		{"paidDate", &invoice.PaidDate},
+	}
+	for i := range invoice.ItemDates {
+		fields = append(fields, dateField{fmt.Sprintf("itemDates[%d]", i), &invoice.ItemDates[i]})
+	}
+	
+	for _, field := range fields {
+		if strings.TrimSpace(*field.value) == "" {
+			continue
+		}
+		date, err := ParseDate(*field.value)
+		if err != nil {
+			return fmt.Errorf("%s: %v", field.name, err)
+		}
+		*field.value = FormatDate(date, format, invoice.Language)
+	}
+	return nil
+}
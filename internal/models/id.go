@@ -0,0 +1,23 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// idPattern is the allowlist for invoice numbers and suffixes from web
+// requests. They end up in file names and in the page, so only letters,
+// digits, dashes and underscores are accepted.
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]*$`)
+
+// ValidateIds rejects an id or id suffix with characters outside the allowlist,
+// such as path separators or HTML
+func (request *InvoiceRequest) ValidateIds() error {
+	if !idPattern.MatchString(request.Id) {
+		return fmt.Errorf("invalid id %q: only letters, digits, '-' and '_' are allowed", request.Id)
+	}
+	if !idPattern.MatchString(request.IdSuffix) {
+		return fmt.Errorf("invalid id suffix %q: only letters, digits, '-' and '_' are allowed", request.IdSuffix)
+	}
+	return nil
+}
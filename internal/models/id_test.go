@@ -0,0 +1,27 @@
+package models
+
+import "testing"
+
+func TestValidateIds(t *testing.T) {
+	tests := []struct {
+		id     string
+		suffix string
+		valid  bool
+	}{
+		{"", "", true},
+		{"R-2024_001", "-A", true},
+		{"next", "", true},
+		{"<script>alert(1)</script>", "", false},
+		{"R-1", `"><img src=x onerror=alert(1)>`, false},
+		{"../evil", "", false},
+		{"R/1", "", false},
+		{"R 1", "", false},
+		{"R-1", ".pdf", false},
+	}
+	for _, tt := range tests {
+		request := InvoiceRequest{Id: tt.id, IdSuffix: tt.suffix}
+		if err := request.ValidateIds(); (err == nil) != tt.valid {
+			t.Errorf("ValidateIds(%q, %q) error = %v, want valid %v", tt.id, tt.suffix, err, tt.valid)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -20,27 +22,210 @@ type Footer struct {
 	BankName         string `json:"bankName" yaml:"bankName"`
 	BankIban         string `json:"bankIban" yaml:"bankIban"`
 	BankBic          string `json:"bankBic" yaml:"bankBic"`
+	
+	// Layout arranges the footer as "3col" (the default: company, contact and
+	// bank side by side), "2col" (company and contact left, bank right) or
+	// "1col-centered" (one centered line per group)
+	Layout string `json:"layout" yaml:"layout"`
+	
+	// Columns replaces the generated footer with custom lines per column
+	Columns [][]string `json:"columns" yaml:"columns"`
 }
 
-// Invoice represents an invoice with all its data
+// Footer layouts
+const (
+	FooterLayout3Col         = "3col"
+	FooterLayout2Col         = "2col"
+	FooterLayout1ColCentered = "1col-centered"
+)
+
+// Invoice represents an invoice with all its data. The fields with an env tag
+// can be overridden by environment variables such as INVOICE_CURRENCY.
 type Invoice struct {
-	Id            string  `json:"id" yaml:"id"`
-	IdSuffix      string  `json:"idSuffix" yaml:"idSuffix"`
-	Title         string  `json:"title" yaml:"title"`
-	Logo          string  `json:"logo" yaml:"logo"`
-	From          string  `json:"from" yaml:"from"`
-	To            string  `json:"to" yaml:"to"`
-	Date          string  `json:"date" yaml:"date"`
-	Due           string  `json:"due" yaml:"due"`
+	// Optional base config this invoice inherits from, see config.ExtendedBase
+	Extends string `json:"extends" yaml:"extends"`
+	
+	Id            string  `json:"id" yaml:"id" env:"INVOICE_ID"`
+	IdSuffix      string  `json:"idSuffix" yaml:"idSuffix" env:"INVOICE_ID_SUFFIX"`
+	Title         string  `json:"title" yaml:"title" env:"INVOICE_TITLE"`
+	
+	// The kind of document: "invoice" (the default), "credit-note", "quote"
+	// or "reminder". It sets the default title and the tax exemption note,
+	// a quote has no due date and a credit note negates the amounts.
+	DocumentType string `json:"documentType" yaml:"documentType" env:"INVOICE_DOCUMENT_TYPE"`
+	
+	Language      string  `json:"language" yaml:"language" env:"INVOICE_LANGUAGE"`
+	Logo          string  `json:"logo" yaml:"logo" env:"INVOICE_LOGO"`
+	
+	// Optional logo sizing in points; the aspect ratio is always kept.
+	// LogoAlign is "left" (the default), "center" or "right".
+	LogoWidth     float64 `json:"logoWidth" yaml:"logoWidth"`
+	LogoMaxHeight float64 `json:"logoMaxHeight" yaml:"logoMaxHeight"`
+	LogoAlign     string  `json:"logoAlign" yaml:"logoAlign"`
+	
+	// Optional letterhead drawn behind the content, scaled to the page width
+	// with its aspect ratio kept. The margins in points keep the content
+	// clear of the letterhead's header and footer zones. BackgroundPages is
+	// "all" (the default) or "first".
+	BackgroundImage        string  `json:"backgroundImage" yaml:"backgroundImage" env:"INVOICE_BACKGROUND_IMAGE"`
+	BackgroundMarginTop    float64 `json:"backgroundMarginTop" yaml:"backgroundMarginTop"`
+	BackgroundMarginBottom float64 `json:"backgroundMarginBottom" yaml:"backgroundMarginBottom"`
+	BackgroundPages        string  `json:"backgroundPages" yaml:"backgroundPages"`
+	
+	From          string  `json:"from" yaml:"from" env:"INVOICE_FROM"`
+	
+	// Optional structured sender, printed instead of From when set
+	Sender *Sender `json:"sender,omitempty" yaml:"sender,omitempty"`
+	
+	// Draw a rule below the sender block (the default)
+	ShowSenderRule bool `json:"showSenderRule" yaml:"showSenderRule"`
+	
+	To            string  `json:"to" yaml:"to" env:"INVOICE_TO"`
+	
+	// Customer number, printed next to the invoice number and date
+	CustomerNumber string `json:"customerNumber" yaml:"customerNumber" env:"INVOICE_CUSTOMER_NUMBER"`
+	
+	// Optional structured recipient, printed instead of To when set
+	Recipient *Recipient `json:"recipient,omitempty" yaml:"recipient,omitempty"`
+	
+	// Optional delivery address, printed next to To when it differs
+	ShipTo string `json:"shipTo" yaml:"shipTo" env:"INVOICE_SHIP_TO"`
+	
+	Date          string  `json:"date" yaml:"date" env:"INVOICE_DATE"`
+	Due           string  `json:"due" yaml:"due" env:"INVOICE_DUE"`
+	
+	// Service/delivery date (§14 UStG) - set only From for a single date
+	ServiceDateFrom string `json:"serviceDateFrom" yaml:"serviceDateFrom" env:"INVOICE_SERVICE_DATE_FROM"`
+	ServiceDateTo   string `json:"serviceDateTo" yaml:"serviceDateTo" env:"INVOICE_SERVICE_DATE_TO"`
+	
+	// Go layout the dates are printed in, e.g. "2006-01-02"; empty uses
+	// DefaultDateFormat. Dates may be written in any supported format.
+	DateFormat string `json:"dateFormat" yaml:"dateFormat"`
+	
 	Items         []string  `json:"items" yaml:"items"`
 	Quantities    []int     `json:"quantities" yaml:"quantities"`
 	Rates         []float64 `json:"rates" yaml:"rates"`
-	Tax           float64 `json:"tax" yaml:"tax"`
-	TaxExempt     bool    `json:"taxExempt" yaml:"taxExempt"`
-	Discount      float64 `json:"discount" yaml:"discount"`
-	Currency      string  `json:"currency" yaml:"currency"`
-	Note          string  `json:"note" yaml:"note"`
+	
+	// Allow negative rates and quantities, e.g. to credit a previous
+	// overcharge on an invoice. They are rejected otherwise, except on
+	// credit notes, as a stray minus is more likely a typo.
+	AllowCredits bool `json:"allowCredits" yaml:"allowCredits"`
+	
+	// Check the items in PercentUnit, the parts of a project billed in
+	// percentages, e.g. 30 and 70 for two milestones at a rate of 1% of the
+	// project price, and warn unless they add up to 100
+	ValidatePercentageItems bool `json:"validatePercentageItems" yaml:"validatePercentageItems"`
+	
+	// Optional date per item, e.g. from a time-tracking export
+	ItemDates []string `json:"itemDates" yaml:"itemDates"`
+	
+	// Optional section per item, e.g. "Entwicklung" or "Hosting". Consecutive
+	// items of the same section are printed below its name and followed by
+	// their subtotal; items without a section are printed as usual.
+	ItemSections []string `json:"itemSections" yaml:"itemSections"`
+	
+	// Optional unit per item, e.g. "h" or PercentUnit, printed after its
+	// quantity
+	ItemUnits []string `json:"itemUnits" yaml:"itemUnits"`
+	
+	// Optional tax rate per item for invoices mixing rates, e.g. 0.19 and
+	// 0.07; items past the end of the list are taxed at Tax
+	ItemTaxRates []float64 `json:"itemTaxRates" yaml:"itemTaxRates"`
+	
+	// Decimal places the quantities are printed with, e.g. 2 for "8.00"
+	// hours; the default prints whole numbers ("8")
+	QuantityDecimals int `json:"quantityDecimals" yaml:"quantityDecimals"`
+	
+	// Print the number of items and their total quantity below the last item
+	ShowItemSummary bool `json:"showItemSummary" yaml:"showItemSummary"`
+	
+	// Spacing and font size of the item table, notes and totals: "normal"
+	// (the default) or "compact" to fit more items on a page
+	Density string `json:"density" yaml:"density" env:"INVOICE_DENSITY"`
+	
+	// Lines of the item table: "plain" (the default), "ruled" for a rule
+	// below the header row or "striped" for the rule and a light background
+	// on every other row
+	TableStyle string `json:"tableStyle" yaml:"tableStyle" env:"INVOICE_TABLE_STYLE"`
+	
+	Tax           float64 `json:"tax" yaml:"tax" env:"INVOICE_TAX"`
+	TaxExempt     bool    `json:"taxExempt" yaml:"taxExempt" env:"INVOICE_TAX_EXEMPT"`
+	Discount      float64 `json:"discount" yaml:"discount" env:"INVOICE_DISCOUNT"`
+	
+	// Optional wording of the note printed on TaxExempt invoices instead of
+	// the § 19 UStG note of the invoice language, e.g. for other exemption
+	// grounds. Line breaks are kept and long lines wrapped.
+	TaxExemptNote string `json:"taxExemptNote" yaml:"taxExemptNote" env:"INVOICE_TAX_EXEMPT_NOTE"`
+	
+	// "percent" (the default) treats Discount as a rate, e.g. 0.1 for 10%,
+	// "fixed" as an amount in the invoice currency, e.g. 50 for €50 off
+	DiscountType string `json:"discountType" yaml:"discountType" env:"INVOICE_DISCOUNT_TYPE"`
+	
+	// Apply the discount before tax, so tax is charged on the discounted
+	// amount (the default), or tax the full subtotal and discount afterwards
+	DiscountBeforeTax bool `json:"discountBeforeTax" yaml:"discountBeforeTax"`
+	
+	// The rates are gross prices with the tax included, e.g. for consumers.
+	// The tax is then backed out of the total instead of added to it.
+	PricesIncludeTax bool `json:"pricesIncludeTax" yaml:"pricesIncludeTax" env:"INVOICE_PRICES_INCLUDE_TAX"`
+	
+	AmountPaid    float64 `json:"amountPaid" yaml:"amountPaid" env:"INVOICE_AMOUNT_PAID"`
+	
+	// Date the invoice was paid in full; adds a "BEZAHLT am" stamp and
+	// leaves no balance due
+	PaidDate string `json:"paidDate" yaml:"paidDate" env:"INVOICE_PAID_DATE"`
+	
+	// Optional early payment discount (Skonto), e.g. 2 percent if paid
+	// within 10 days of the invoice date. The customer may take it, so the
+	// total stays the full amount and the discounted amount is shown below.
+	SkontoPercent float64 `json:"skontoPercent" yaml:"skontoPercent"`
+	SkontoDays    int     `json:"skontoDays" yaml:"skontoDays"`
+	
+	// How the total is rounded: "none" (the default), "swiss5" to the nearest
+	// 0.05 as in Switzerland (Rappenrundung) or "nearest" to a whole amount
+	RoundingMode string `json:"roundingMode" yaml:"roundingMode" env:"INVOICE_ROUNDING_MODE"`
+	
+	Currency      string  `json:"currency" yaml:"currency" env:"INVOICE_CURRENCY"`
+	
+	// Optional currency the customer pays in, e.g. USD for an invoice in EUR.
+	// The total is also shown converted at ExchangeRate (units of the
+	// settlement currency per unit of Currency); the amounts in Currency stay
+	// the binding ones.
+	SettlementCurrency string  `json:"settlementCurrency" yaml:"settlementCurrency" env:"INVOICE_SETTLEMENT_CURRENCY"`
+	ExchangeRate       float64 `json:"exchangeRate" yaml:"exchangeRate" env:"INVOICE_EXCHANGE_RATE"`
+	
+	// How negative amounts such as credits are printed: "minus" (-€50.00, the
+	// default) or "parentheses" (€50.00 in brackets)
+	NegativeFormat string `json:"negativeFormat" yaml:"negativeFormat"`
+	
+	// Separators of the printed amounts, e.g. "," and "." for 1.234,56.
+	// Amounts use a "." and are not grouped unless set.
+	DecimalSeparator   string `json:"decimalSeparator" yaml:"decimalSeparator"`
+	ThousandsSeparator string `json:"thousandsSeparator" yaml:"thousandsSeparator"`
+	
+	Note          string  `json:"note" yaml:"note" env:"INVOICE_NOTE"`
+	
+	// Where the note is printed: "before-items", "after-items" (the default,
+	// between the items and the totals) or "after-totals", e.g. for payment terms
+	NotePosition string `json:"notePosition" yaml:"notePosition" env:"INVOICE_NOTE_POSITION"`
+	
+	// Terms and conditions printed on pages of their own after the invoice,
+	// given inline or as the path to a text or Markdown file
+	Terms     string `json:"terms" yaml:"terms"`
+	TermsFile string `json:"termsFile" yaml:"termsFile"`
+	
+	// PDFs appended with all their pages after the invoice and its terms,
+	// such as a signed contract or a timesheet
+	AppendPDFs []string `json:"appendPdfs" yaml:"appendPdfs"`
+	
 	Footer        Footer  `json:"footer" yaml:"footer"`
+	
+	// Optional per-invoice label overrides keyed by label name, e.g. "itemLabel"
+	Labels map[string]string `json:"labels" yaml:"labels"`
+	
+	// Optional font overrides, e.g. a licensed corporate font
+	FontRegularPath string `json:"fontRegularPath" yaml:"fontRegularPath"`
+	FontBoldPath    string `json:"fontBoldPath" yaml:"fontBoldPath"`
 }
 
 // DefaultFooter returns a new footer with default values
@@ -68,22 +253,84 @@ func DefaultInvoice() Invoice {
 	return Invoice{
 		Id:         time.Now().Format("20060102"),
 		IdSuffix:   "",  // Default empty suffix
-		Title:      "RECHNUNG", // Use German title
+		Title:      "", // Empty uses the localized title (RECHNUNG)
+		Language:   "de", // German labels by default
 		Rates:      []float64{25},
 		Quantities: []int{2},
 		Items:      []string{"Dienstleistung"}, // Changed to German default
 		From:       "Firma GmbH",  // Changed to German default
 		To:         "Kunde GmbH",  // Changed to German default
-		Date:       time.Now().Format("02.01.2006"), // German date format (day.month.year)
-		Due:        time.Now().AddDate(0, 0, 14).Format("02.01.2006"), // German date format
+		Date:       time.Now().Format(DefaultDateFormat), // German date format (day.month.year)
+		Due:        time.Now().AddDate(0, 0, 14).Format(DefaultDateFormat), // German date format
 		Tax:        0.19, // Default German VAT rate (19%)
 		TaxExempt:  false, // Default to tax inclusion
 		Discount:   0,
+		DiscountBeforeTax: true, // Tax is charged on the discounted amount
+		ShowSenderRule: true, // Rule below the sender block
+		AmountPaid: 0, // No deposit by default
 		Currency:   "EUR", // Default to Euro
 		Footer:     DefaultFooter(), // Default footer information
 	}
 }
 
+// Logo alignments
+const (
+	LogoAlignLeft   = "left"
+	LogoAlignCenter = "center"
+	LogoAlignRight  = "right"
+)
+
+// Pages a background image is drawn on
+const (
+	BackgroundPagesAll   = "all"
+	BackgroundPagesFirst = "first"
+)
+
+// Discount types
+const (
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+// PercentUnit is the unit of items billed as a percentage of a project, see
+// ValidatePercentageItems
+const PercentUnit = "%"
+
+// Rounding modes for the invoice total
+const (
+	RoundingNone    = "none"
+	RoundingSwiss5  = "swiss5"
+	RoundingNearest = "nearest"
+)
+
+// Document types
+const (
+	DocumentInvoice    = "invoice"
+	DocumentCreditNote = "credit-note"
+	DocumentQuote      = "quote"
+	DocumentReminder   = "reminder"
+)
+
+// Layout densities
+const (
+	DensityNormal  = "normal"
+	DensityCompact = "compact"
+)
+
+// Note positions
+const (
+	NoteBeforeItems = "before-items"
+	NoteAfterItems  = "after-items"
+	NoteAfterTotals = "after-totals"
+)
+
+// Item table styles
+const (
+	TableStylePlain   = "plain"
+	TableStyleRuled   = "ruled"
+	TableStyleStriped = "striped"
+)
+
 // InvoiceItem represents a single item in an invoice
 type InvoiceItem struct {
 	Description string  `json:"description"`
@@ -94,32 +341,214 @@ type InvoiceItem struct {
 
 // CalculateTotal calculates the total amount for the invoice
 func CalculateTotal(invoice *Invoice) float64 {
-	subtotal := 0.0
+	return ComputeInvoice(invoice).Total
+}
+
+// Validate checks that every item has a rate and, if quantities are given,
+// a quantity, so mismatched lists don't silently produce a wrong total. It also
+// rejects unknown discount types and rounding modes and fixed discounts above
+// the subtotal.
+func (invoice *Invoice) Validate() error {
+	var problems []string
 	
-	// Calculate subtotal from items
+	credits := invoice.AllowCredits || invoice.DocumentType == DocumentCreditNote
 	for i, item := range invoice.Items {
-		quantity := 1
-		if i < len(invoice.Quantities) {
-			quantity = invoice.Quantities[i]
+		if i >= len(invoice.Rates) {
+			problems = append(problems, fmt.Sprintf("item %d (%q) has no rate", i+1, item))
+		} else if invoice.Rates[i] < 0 && !credits {
+			problems = append(problems, fmt.Sprintf("item %d (%q) has a negative rate %.2f (set allowCredits for credits)", i+1, item, invoice.Rates[i]))
 		}
-		
-		rate := 0.0
-		if i < len(invoice.Rates) {
-			rate = invoice.Rates[i]
+		// Quantities may be omitted entirely, in which case every item counts once
+		if len(invoice.Quantities) > 0 && i >= len(invoice.Quantities) {
+			problems = append(problems, fmt.Sprintf("item %d (%q) has no quantity", i+1, item))
+		} else if i < len(invoice.Quantities) && invoice.Quantities[i] < 0 && !credits {
+			problems = append(problems, fmt.Sprintf("item %d (%q) has a negative quantity %d (set allowCredits for credits)", i+1, item, invoice.Quantities[i]))
 		}
-		
-		subtotal += float64(quantity) * rate
 	}
 	
-	// Apply discount if any
-	discountAmount := subtotal * invoice.Discount
-	afterDiscount := subtotal - discountAmount
+	switch invoice.DiscountType {
+	case "", DiscountPercent:
+	case DiscountFixed:
+		// A flat discount larger than the items would turn the invoice into a credit
+		// (or a credit note into an invoice)
+		if subtotal := ComputeInvoice(invoice).Subtotal * invoice.AmountSign(); invoice.Discount > subtotal {
+			problems = append(problems, fmt.Sprintf("fixed discount %.2f exceeds the subtotal %.2f", invoice.Discount, subtotal))
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown discount type %q (supported: %s, %s)", invoice.DiscountType, DiscountPercent, DiscountFixed))
+	}
+	
+	switch invoice.RoundingMode {
+	case "", RoundingNone, RoundingSwiss5, RoundingNearest:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown rounding mode %q (supported: %s, %s, %s)", invoice.RoundingMode, RoundingNone, RoundingSwiss5, RoundingNearest))
+	}
+	
+	switch invoice.DocumentType {
+	case "", DocumentInvoice, DocumentCreditNote, DocumentQuote, DocumentReminder:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown document type %q (supported: %s, %s, %s, %s)", invoice.DocumentType, DocumentInvoice, DocumentCreditNote, DocumentQuote, DocumentReminder))
+	}
+	
+	switch invoice.Density {
+	case "", DensityNormal, DensityCompact:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown density %q (supported: %s, %s)", invoice.Density, DensityNormal, DensityCompact))
+	}
+	
+	switch invoice.TableStyle {
+	case "", TableStylePlain, TableStyleRuled, TableStyleStriped:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown table style %q (supported: %s, %s, %s)", invoice.TableStyle, TableStylePlain, TableStyleRuled, TableStyleStriped))
+	}
+	
+	switch invoice.NotePosition {
+	case "", NoteBeforeItems, NoteAfterItems, NoteAfterTotals:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown note position %q (supported: %s, %s, %s)", invoice.NotePosition, NoteBeforeItems, NoteAfterItems, NoteAfterTotals))
+	}
+	
+	switch invoice.BackgroundPages {
+	case "", BackgroundPagesAll, BackgroundPagesFirst:
+	default:
+		problems = append(problems, fmt.Sprintf("unknown background pages %q (supported: %s, %s)", invoice.BackgroundPages, BackgroundPagesAll, BackgroundPagesFirst))
+	}
+	
+	if invoice.BackgroundMarginTop < 0 || invoice.BackgroundMarginBottom < 0 {
+		problems = append(problems, fmt.Sprintf("background margins %g and %g must not be negative", invoice.BackgroundMarginTop, invoice.BackgroundMarginBottom))
+	}
+	
+	switch {
+	case invoice.SkontoPercent < 0 || invoice.SkontoPercent >= 100:
+		problems = append(problems, fmt.Sprintf("skonto percent %g must be between 0 and 100", invoice.SkontoPercent))
+	case invoice.SkontoDays < 0:
+		problems = append(problems, fmt.Sprintf("skonto days %d must not be negative", invoice.SkontoDays))
+	case (invoice.SkontoPercent > 0) != (invoice.SkontoDays > 0):
+		problems = append(problems, "skontoPercent and skontoDays must be set together")
+	}
+	
+	if problem := invoice.separatorProblem(); problem != "" {
+		problems = append(problems, problem)
+	}
+	
+	switch {
+	case invoice.SettlementCurrency != "" && invoice.ExchangeRate <= 0:
+		problems = append(problems, fmt.Sprintf("settlement currency %s needs a positive exchange rate", invoice.SettlementCurrency))
+	case invoice.SettlementCurrency == "" && invoice.ExchangeRate != 0:
+		problems = append(problems, fmt.Sprintf("exchange rate %g has no settlement currency", invoice.ExchangeRate))
+	}
+	
+	for i := len(invoice.Items); i < len(invoice.Rates); i++ {
+		problems = append(problems, fmt.Sprintf("rate %d (%.2f) has no matching item", i+1, invoice.Rates[i]))
+	}
+	for i := len(invoice.Items); i < len(invoice.Quantities); i++ {
+		problems = append(problems, fmt.Sprintf("quantity %d (%d) has no matching item", i+1, invoice.Quantities[i]))
+	}
+	for i := len(invoice.Items); i < len(invoice.ItemSections); i++ {
+		problems = append(problems, fmt.Sprintf("section %d (%q) has no matching item", i+1, invoice.ItemSections[i]))
+	}
+	for i := len(invoice.Items); i < len(invoice.ItemUnits); i++ {
+		problems = append(problems, fmt.Sprintf("unit %d (%q) has no matching item", i+1, invoice.ItemUnits[i]))
+	}
+	for i := len(invoice.Items); i < len(invoice.ItemTaxRates); i++ {
+		problems = append(problems, fmt.Sprintf("tax rate %d (%g) has no matching item", i+1, invoice.ItemTaxRates[i]))
+	}
 	
-	// Apply tax if not exempt
-	if !invoice.TaxExempt {
-		taxAmount := afterDiscount * invoice.Tax
-		return afterDiscount + taxAmount
+	if len(problems) == 0 {
+		return nil
 	}
 	
-	return afterDiscount
-}
\ No newline at end of file
+	// The counts only help when the lists differ in length
+	if len(invoice.Rates) != len(invoice.Items) || (len(invoice.Quantities) > 0 && len(invoice.Quantities) != len(invoice.Items)) {
+		return fmt.Errorf("invalid invoice, %d items, %d quantities and %d rates do not match: %s",
+			len(invoice.Items), len(invoice.Quantities), len(invoice.Rates), strings.Join(problems, "; "))
+	}
+	return fmt.Errorf("invalid invoice: %s", strings.Join(problems, "; "))
+}
+
+// AmountSign returns -1 for credit notes, whose amounts are printed and
+// totalled as negative, and 1 for every other document type
+func (invoice *Invoice) AmountSign() float64 {
+	if invoice.DocumentType == DocumentCreditNote {
+		return -1
+	}
+	return 1
+}
+
+// ItemSection returns the section of item i, or "" if it has none
+func (invoice *Invoice) ItemSection(i int) string {
+	if i < 0 || i >= len(invoice.ItemSections) {
+		return ""
+	}
+	return invoice.ItemSections[i]
+}
+
+// ItemUnit returns the unit of item i, or "" if it has none
+func (invoice *Invoice) ItemUnit(i int) string {
+	if i < 0 || i >= len(invoice.ItemUnits) {
+		return ""
+	}
+	return invoice.ItemUnits[i]
+}
+
+// HasSettlement reports whether the total is also shown in a settlement currency
+func (invoice *Invoice) HasSettlement() bool {
+	return invoice.SettlementCurrency != "" && invoice.ExchangeRate > 0
+}
+
+// HasSkonto reports whether the invoice offers an early payment discount.
+// A quote has no payment to discount.
+func (invoice *Invoice) HasSkonto() bool {
+	return invoice.SkontoPercent > 0 && invoice.SkontoDays > 0 && invoice.DocumentType != DocumentQuote
+}
+
+// ItemTaxRate returns the tax rate of item i, its own or the invoice's Tax
+func (invoice *Invoice) ItemTaxRate(i int) float64 {
+	if i < len(invoice.ItemTaxRates) {
+		return invoice.ItemTaxRates[i]
+	}
+	return invoice.Tax
+}
+
+// StartsSection reports whether item i is the first of a section
+func (invoice *Invoice) StartsSection(i int) bool {
+	section := invoice.ItemSection(i)
+	return section != "" && section != invoice.ItemSection(i-1)
+}
+
+// EndsSection reports whether item i is the last of a section
+func (invoice *Invoice) EndsSection(i int) bool {
+	section := invoice.ItemSection(i)
+	return section != "" && (i == len(invoice.Items)-1 || section != invoice.ItemSection(i+1))
+}
+
+// DecimalSep returns the decimal separator of printed amounts, "." unless set
+func (invoice *Invoice) DecimalSep() string {
+	if invoice.DecimalSeparator == "" {
+		return "."
+	}
+	return invoice.DecimalSeparator
+}
+
+// separatorProblem describes separators that would make amounts ambiguous,
+// or returns ""
+func (invoice *Invoice) separatorProblem() string {
+	switch {
+	case strings.ContainsAny(invoice.DecimalSeparator+invoice.ThousandsSeparator, "0123456789-"):
+		return fmt.Sprintf("separators %q and %q must not contain digits or a minus sign", invoice.DecimalSeparator, invoice.ThousandsSeparator)
+	case invoice.DecimalSep() == invoice.ThousandsSeparator:
+		return fmt.Sprintf("decimal and thousands separator are both %q", invoice.ThousandsSeparator)
+	}
+	return ""
+}
+
+// ShowsDueDate reports whether the due date is printed, which a quote has none of
+func (invoice *Invoice) ShowsDueDate() bool {
+	return invoice.Due != "" && invoice.DocumentType != DocumentQuote
+}
+
+// CalculateBalanceDue calculates the amount still owed after any deposit.
+// A negative result means the customer has overpaid and holds a credit.
+func CalculateBalanceDue(invoice *Invoice) float64 {
+	return ComputeInvoice(invoice).BalanceDue
+}
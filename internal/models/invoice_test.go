@@ -0,0 +1,74 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		invoice Invoice
+		wantErr string
+	}{
+		{"valid", Invoice{Items: []string{"A", "B"}, Quantities: []int{1, 2}, Rates: []float64{10, 20}}, ""},
+		{"quantities omitted", Invoice{Items: []string{"A", "B"}, Rates: []float64{10, 20}}, ""},
+		{"missing rate", Invoice{Items: []string{"A", "B"}, Rates: []float64{10}}, `item 2 ("B") has no rate`},
+		{"missing quantity", Invoice{Items: []string{"A", "B"}, Quantities: []int{1}, Rates: []float64{10, 20}}, `item 2 ("B") has no quantity`},
+		{"extra rate", Invoice{Items: []string{"A"}, Rates: []float64{10, 20}}, "rate 2 (20.00) has no matching item"},
+		{"extra unit", Invoice{Items: []string{"A"}, Rates: []float64{10}, ItemUnits: []string{"h", "%"}}, `unit 2 ("%") has no matching item`},
+		{"negative rate", Invoice{Items: []string{"A"}, Rates: []float64{-10}}, `item 1 ("A") has a negative rate -10.00 (set allowCredits for credits)`},
+		{"negative rate allowed", Invoice{Items: []string{"A", "B"}, Rates: []float64{20, -10}, AllowCredits: true}, ""},
+		{"negative rate on a credit note", Invoice{Items: []string{"A"}, Rates: []float64{-10}, DocumentType: DocumentCreditNote}, ""},
+		{"fixed discount above the subtotal", Invoice{Items: []string{"A"}, Rates: []float64{10}, Discount: 20, DiscountType: DiscountFixed}, "fixed discount 20.00 exceeds the subtotal 10.00"},
+		{"unknown discount type", Invoice{DiscountType: "half"}, `unknown discount type "half" (supported: percent, fixed)`},
+		{"unknown rounding mode", Invoice{RoundingMode: "up"}, `unknown rounding mode "up" (supported: none, swiss5, nearest)`},
+		{"skonto without days", Invoice{SkontoPercent: 2}, "skontoPercent and skontoDays must be set together"},
+		{"exchange rate without currency", Invoice{ExchangeRate: 1.1}, "exchange rate 1.1 has no settlement currency"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.invoice.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		invoice Invoice
+		want    string
+	}{
+		{
+			"lists of different lengths",
+			Invoice{Items: []string{"A", "B"}, Rates: []float64{10}},
+			`invalid invoice, 2 items, 0 quantities and 1 rates do not match: item 2 ("B") has no rate`,
+		},
+		{
+			"matching lists",
+			Invoice{Items: []string{"A"}, Rates: []float64{10}, RoundingMode: "up"},
+			`invalid invoice: unknown rounding mode "up" (supported: none, swiss5, nearest)`,
+		},
+		{
+			"no items",
+			Invoice{SkontoPercent: 2},
+			"invalid invoice: skontoPercent and skontoDays must be set together",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.invoice.Validate(); err == nil || err.Error() != tt.want {
+				t.Errorf("Validate() = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
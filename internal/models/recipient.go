@@ -0,0 +1,38 @@
+package models
+
+import "strings"
+
+// Recipient holds the customer's address as structured fields. When set, it
+// is printed instead of the free-text To, with the VAT id and customer
+// number below the address as many B2B customers require.
+type Recipient struct {
+	Name           string `json:"name" yaml:"name"`
+	Address        string `json:"address" yaml:"address"`
+	VatId          string `json:"vatId" yaml:"vatId"`
+	CustomerNumber string `json:"customerNumber" yaml:"customerNumber"`
+}
+
+// RecipientLines returns the lines of the bill-to block, company name first.
+// The structured Recipient wins over the free-text To. In either a literal \n
+// starts a new line.
+func (invoice *Invoice) RecipientLines() []string {
+	if invoice.Recipient == nil || invoice.Recipient.Name == "" {
+		return splitLines(invoice.To)
+	}
+	
+	lines := []string{invoice.Recipient.Name}
+	if invoice.Recipient.Address != "" {
+		lines = append(lines, splitLines(invoice.Recipient.Address)...)
+	}
+	return lines
+}
+
+// RecipientName returns the customer's name, the first line of the bill-to block
+func (invoice *Invoice) RecipientName() string {
+	return invoice.RecipientLines()[0]
+}
+
+// splitLines splits text at its line breaks, also written as \n in configs
+func splitLines(text string) []string {
+	return strings.Split(strings.ReplaceAll(text, `\n`, "\n"), "\n")
+}
@@ -0,0 +1,55 @@
+package models
+
+import "strings"
+
+// Sender holds the issuing company's address as structured fields. When set,
+// it is printed instead of the free-text From.
+type Sender struct {
+	Name    string `json:"name" yaml:"name"`
+	Address string `json:"address" yaml:"address"`
+	City    string `json:"city" yaml:"city"`
+	Zip     string `json:"zip" yaml:"zip"`
+	Email   string `json:"email" yaml:"email"`
+	Phone   string `json:"phone" yaml:"phone"`
+}
+
+// SenderLines returns the lines of the sender block, company name first. The
+// structured Sender wins over the free-text From, where a literal \n also
+// starts a new line.
+func (invoice *Invoice) SenderLines() []string {
+	if invoice.Sender == nil || invoice.Sender.Name == "" {
+		return strings.Split(strings.ReplaceAll(invoice.From, `\n`, "\n"), "\n")
+	}
+	
+	sender := invoice.Sender
+	var lines []string
+	for _, line := range []string{
+		sender.Name,
+		sender.Address,
+		strings.TrimSpace(sender.Zip + " " + sender.City),
+		sender.Email,
+		sender.Phone,
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// SenderName returns the issuing company's name, the first line of the sender block
+func (invoice *Invoice) SenderName() string {
+	return invoice.SenderLines()[0]
+}
+
+// ApplySenderDefaults takes the footer's company name from the structured
+// sender, unless the footer names a company of its own. The placeholder name
+// of DefaultFooter doesn't count, so configs with only a sender work as is.
+func (invoice *Invoice) ApplySenderDefaults() {
+	if invoice.Sender == nil || invoice.Sender.Name == "" {
+		return
+	}
+	if name := invoice.Footer.CompanyName; name == "" || name == DefaultFooter().CompanyName {
+		invoice.Footer.CompanyName = invoice.Sender.Name
+	}
+}
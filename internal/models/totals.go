@@ -0,0 +1,245 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// TaxLine is the tax charged at a single rate, on the net amount of the items
+// taxed at that rate
+type TaxLine struct {
+	Rate float64 `json:"rate"`
+	Net  float64 `json:"net"`
+	Tax  float64 `json:"tax"`
+}
+
+// SectionTotal is the subtotal of a section of consecutive items, see
+// Invoice.ItemSections
+type SectionTotal struct {
+	Name     string  `json:"name"`
+	Subtotal float64 `json:"subtotal"`
+}
+
+// Totals holds the computed amounts of an invoice
+type Totals struct {
+	Subtotal     float64   `json:"subtotal"`
+	Discount     float64   `json:"discount"`
+	Tax          float64   `json:"tax"`
+	TaxBreakdown []TaxLine `json:"taxBreakdown"`
+	Rounding     float64   `json:"rounding"`
+	Total        float64   `json:"total"`
+	AmountPaid   float64   `json:"amountPaid"`
+	BalanceDue   float64   `json:"balanceDue"`
+	
+	// The total converted to the settlement currency, if the invoice has one
+	SettlementTotal float64 `json:"settlementTotal,omitempty"`
+	
+	// The early payment discount on the balance due and what is left to pay
+	// when it is taken, if the invoice offers one
+	Skonto            float64 `json:"skonto,omitempty"`
+	BalanceWithSkonto float64 `json:"balanceWithSkonto,omitempty"`
+	
+	// Subtotals of the item sections, if any
+	Sections []SectionTotal `json:"sections,omitempty"`
+}
+
+// ComputeInvoice calculates the amounts of an invoice: its subtotal, discount,
+// tax per rate, total and balance due, and the subtotal of every section. The
+// PDF renderer, the web API and CalculateTotal all use it, so they always agree.
+func ComputeInvoice(invoice *Invoice) Totals {
+	totals := Totals{TaxBreakdown: []TaxLine{}}
+	
+	// Net amount of the items per tax rate
+	var rates []float64
+	net := make(map[float64]float64)
+	
+	// Items without a quantity count once, and negative rates such as credits
+	// reduce the subtotal. The amounts of a credit note are negated.
+	for i := range invoice.Items {
+		quantity := 1
+		if i < len(invoice.Quantities) {
+			quantity = invoice.Quantities[i]
+		}
+		
+		rate := 0.0
+		if i < len(invoice.Rates) {
+			rate = invoice.Rates[i]
+		}
+		
+		amount := float64(quantity) * rate * invoice.AmountSign()
+		totals.Subtotal += amount
+		
+		taxRate := invoice.ItemTaxRate(i)
+		if _, ok := net[taxRate]; !ok {
+			rates = append(rates, taxRate)
+		}
+		net[taxRate] += amount
+		
+		// Sections are listed in the order of their items; the subtotal above
+		// covers all items with or without a section
+		if invoice.StartsSection(i) {
+			totals.Sections = append(totals.Sections, SectionTotal{Name: invoice.ItemSection(i)})
+		}
+		if invoice.ItemSection(i) != "" {
+			totals.Sections[len(totals.Sections)-1].Subtotal += amount
+		}
+	}
+	
+	// The discount is a share of the subtotal or a fixed amount
+	if invoice.DiscountType == DiscountFixed {
+		totals.Discount = invoice.Discount * invoice.AmountSign()
+	} else {
+		totals.Discount = totals.Subtotal * invoice.Discount
+	}
+	
+	// Apply tax if not exempt, with one TaxLine per rate the items are taxed
+	// at, in ascending order. Without items the invoice's rate still shows in
+	// the breakdown.
+	if len(rates) == 0 {
+		rates = []float64{invoice.Tax}
+	}
+	sort.Float64s(rates)
+	if !invoice.TaxExempt && hasTax(rates) {
+		for _, rate := range rates {
+			// With DiscountBeforeTax tax is charged on the discounted amount,
+			// the discount shared between the rates by their share of the
+			// subtotal. Either way tax only turns negative when the whole
+			// invoice is a credit.
+			taxBase := net[rate]
+			if invoice.DiscountBeforeTax && totals.Subtotal != 0 {
+				taxBase -= totals.Discount * (net[rate] / totals.Subtotal)
+			}
+			
+			line := TaxLine{Rate: rate, Net: taxBase, Tax: taxBase * rate}
+			if invoice.PricesIncludeTax {
+				// The rates are gross prices, so the tax is backed out of the
+				// base instead of added and Net plus Tax is the gross amount
+				line.Tax = taxBase * rate / (1 + rate)
+				line.Net = taxBase - line.Tax
+			}
+			totals.Tax += line.Tax
+			totals.TaxBreakdown = append(totals.TaxBreakdown, line)
+		}
+	}
+	
+	// Gross prices already include the tax
+	totals.Total = totals.Subtotal - totals.Discount
+	if !invoice.PricesIncludeTax {
+		totals.Total += totals.Tax
+	}
+	if step := roundingStep(invoice.RoundingMode); step != 0 {
+		// Round from the total in cents, as printed, so 19.97 becomes 19.95,
+		// and keep the difference in Rounding
+		cents := math.Round(totals.Total*100) / 100
+		rounded := math.Round(cents/step) * step
+		totals.Rounding = math.Round((rounded-cents)*100) / 100
+		totals.Total = math.Round((cents+totals.Rounding)*100) / 100
+	}
+	
+	// A paid invoice has nothing left to pay
+	totals.AmountPaid = invoice.AmountPaid
+	if invoice.PaidDate != "" {
+		totals.AmountPaid = totals.Total
+	}
+	totals.BalanceDue = totals.Total - totals.AmountPaid
+	
+	// The converted total is only shown for information
+	if invoice.HasSettlement() {
+		totals.SettlementTotal = totals.Total * invoice.ExchangeRate
+	}
+	
+	// The early payment discount is rounded to cents; the total stays the
+	// full amount as the customer chooses whether to take it
+	if invoice.HasSkonto() && totals.BalanceDue > 0 {
+		totals.Skonto = math.Round(totals.BalanceDue*invoice.SkontoPercent) / 100
+		totals.BalanceWithSkonto = totals.BalanceDue - totals.Skonto
+	}
+	return totals
+}
+
+// CheckTotal reports an invoice that bills nothing: a total of zero or less,
+// or for a credit note zero or more, with its likely cause such as forgotten
+// rates. An invoice without items, like a reminder with only a note, has no
+// total to check. With ValidatePercentageItems it first reports percentages
+// that don't add up to 100.
+func (invoice *Invoice) CheckTotal() error {
+	if len(invoice.Items) == 0 {
+		return nil
+	}
+	if err := invoice.checkPercentages(); err != nil {
+		return err
+	}
+	
+	// Compare credit notes as if they were invoices
+	totals := ComputeInvoice(invoice)
+	sign := invoice.AmountSign()
+	if totals.Total*sign > 0 {
+		return nil
+	}
+	
+	switch subtotal := totals.Subtotal * sign; {
+	case subtotal == 0:
+		return fmt.Errorf("total is %.2f, the items add up to nothing (are the rates or quantities missing?)", totals.Total)
+	case subtotal < 0:
+		return fmt.Errorf("total is %.2f, the items add up to %.2f", totals.Total, totals.Subtotal)
+	case totals.Discount*sign >= subtotal:
+		return fmt.Errorf("total is %.2f, the discount %.2f is not less than the subtotal %.2f", totals.Total, totals.Discount, totals.Subtotal)
+	default:
+		return fmt.Errorf("total is %.2f", totals.Total)
+	}
+}
+
+// checkPercentages reports an invoice with ValidatePercentageItems whose
+// items in PercentUnit don't add up to 100. Other items, such as travel
+// expenses billed next to the milestones, are not counted.
+func (invoice *Invoice) checkPercentages() error {
+	if !invoice.ValidatePercentageItems {
+		return nil
+	}
+	
+	// Items without a quantity count once, as everywhere else
+	sum, percentages := 0, 0
+	for i := range invoice.Items {
+		if invoice.ItemUnit(i) != PercentUnit {
+			continue
+		}
+		percentages++
+		if i < len(invoice.Quantities) {
+			sum += invoice.Quantities[i]
+		} else {
+			sum++
+		}
+	}
+	if percentages == 0 {
+		return fmt.Errorf("no item is billed in %s, set the unit of the percentage items", PercentUnit)
+	}
+	if sum != 100 {
+		return fmt.Errorf("the percentages of the items add up to %d%%, not 100%%", sum)
+	}
+	return nil
+}
+
+// hasTax reports whether any of the rates charges tax. Items at a 0% rate
+// are only listed in the breakdown next to items that are taxed.
+func hasTax(rates []float64) bool {
+	for _, rate := range rates {
+		if rate != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// roundingStep returns the amount a rounding mode rounds the total to, or 0
+// if the total is not rounded
+func roundingStep(mode string) float64 {
+	switch mode {
+	case RoundingSwiss5:
+		return 0.05
+	case RoundingNearest:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,278 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+// near reports whether two amounts agree to a tenth of a cent
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 0.001
+}
+
+// itemsInvoice returns an invoice with the given rates, each item counted
+// once, taxed at 19%
+func itemsInvoice(rates ...float64) Invoice {
+	invoice := Invoice{Tax: 0.19}
+	for range rates {
+		invoice.Items = append(invoice.Items, "Item")
+	}
+	invoice.Rates = rates
+	return invoice
+}
+
+func TestComputeInvoiceDiscount(t *testing.T) {
+	tests := []struct {
+		name              string
+		discount          float64
+		discountType      string
+		discountBeforeTax bool
+		wantDiscount      float64
+		wantTax           float64
+		wantTotal         float64
+	}{
+		{"no discount", 0, "", false, 0, 19, 119},
+		{"percent, tax on the full subtotal", 0.1, DiscountPercent, false, 10, 19, 109},
+		{"percent, tax on the discounted amount", 0.1, DiscountPercent, true, 10, 17.1, 107.1},
+		{"fixed, tax on the full subtotal", 25, DiscountFixed, false, 25, 19, 94},
+		{"fixed, tax on the discounted amount", 25, DiscountFixed, true, 25, 14.25, 89.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := itemsInvoice(60, 40)
+			invoice.Discount = tt.discount
+			invoice.DiscountType = tt.discountType
+			invoice.DiscountBeforeTax = tt.discountBeforeTax
+			
+			totals := ComputeInvoice(&invoice)
+			if !near(totals.Subtotal, 100) || !near(totals.Discount, tt.wantDiscount) || !near(totals.Tax, tt.wantTax) || !near(totals.Total, tt.wantTotal) {
+				t.Errorf("subtotal, discount, tax, total = %.2f, %.2f, %.2f, %.2f, want 100.00, %.2f, %.2f, %.2f",
+					totals.Subtotal, totals.Discount, totals.Tax, totals.Total, tt.wantDiscount, tt.wantTax, tt.wantTotal)
+			}
+			if total := CalculateTotal(&invoice); !near(total, totals.Total) {
+				t.Errorf("CalculateTotal = %.2f, ComputeInvoice = %.2f", total, totals.Total)
+			}
+		})
+	}
+}
+
+func TestComputeInvoiceRounding(t *testing.T) {
+	tests := []struct {
+		name         string
+		mode         string
+		rate         float64
+		wantRounding float64
+		wantTotal    float64
+	}{
+		{"not rounded", "", 16.78, 0, 19.9682},
+		{"none", RoundingNone, 16.78, 0, 19.9682},
+		{"swiss5 down", RoundingSwiss5, 16.78, -0.02, 19.95},
+		{"swiss5 up", RoundingSwiss5, 16.79, 0.02, 20},
+		{"swiss5 exact", RoundingSwiss5, 10, 0, 11.9},
+		{"nearest down", RoundingNearest, 16, -0.04, 19},
+		{"nearest up", RoundingNearest, 16.78, 0.03, 20},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := itemsInvoice(tt.rate)
+			invoice.RoundingMode = tt.mode
+			
+			totals := ComputeInvoice(&invoice)
+			if !near(totals.Rounding, tt.wantRounding) || !near(totals.Total, tt.wantTotal) {
+				t.Errorf("rounding, total = %.4f, %.4f, want %.4f, %.4f", totals.Rounding, totals.Total, tt.wantRounding, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestComputeInvoicePricesIncludeTax(t *testing.T) {
+	tests := []struct {
+		name         string
+		invoice      Invoice
+		wantSubtotal float64
+		wantTax      float64
+		wantTotal    float64
+		wantNet      []float64
+	}{
+		{
+			name:         "net prices",
+			invoice:      Invoice{Items: []string{"A"}, Rates: []float64{100}, Tax: 0.19},
+			wantSubtotal: 100, wantTax: 19, wantTotal: 119, wantNet: []float64{100},
+		},
+		{
+			name:         "gross prices",
+			invoice:      Invoice{Items: []string{"A"}, Rates: []float64{119}, Tax: 0.19, PricesIncludeTax: true},
+			wantSubtotal: 119, wantTax: 19, wantTotal: 119, wantNet: []float64{100},
+		},
+		{
+			name: "gross prices at two rates",
+			invoice: Invoice{Items: []string{"A", "B"}, Rates: []float64{119, 107}, Tax: 0.19,
+				ItemTaxRates: []float64{0.19, 0.07}, PricesIncludeTax: true},
+			wantSubtotal: 226, wantTax: 26, wantTotal: 226, wantNet: []float64{100, 100},
+		},
+		{
+			name: "gross prices with a discount before tax",
+			invoice: Invoice{Items: []string{"A"}, Rates: []float64{119}, Tax: 0.19, PricesIncludeTax: true,
+				Discount: 0.1, DiscountType: DiscountPercent, DiscountBeforeTax: true},
+			wantSubtotal: 119, wantTax: 17.1, wantTotal: 107.1, wantNet: []float64{90},
+		},
+		{
+			name:         "gross prices, tax exempt",
+			invoice:      Invoice{Items: []string{"A"}, Rates: []float64{119}, Tax: 0.19, PricesIncludeTax: true, TaxExempt: true},
+			wantSubtotal: 119, wantTax: 0, wantTotal: 119,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			totals := ComputeInvoice(&tt.invoice)
+			if !near(totals.Subtotal, tt.wantSubtotal) || !near(totals.Tax, tt.wantTax) || !near(totals.Total, tt.wantTotal) {
+				t.Errorf("subtotal, tax, total = %.2f, %.2f, %.2f, want %.2f, %.2f, %.2f",
+					totals.Subtotal, totals.Tax, totals.Total, tt.wantSubtotal, tt.wantTax, tt.wantTotal)
+			}
+			if len(totals.TaxBreakdown) != len(tt.wantNet) {
+				t.Fatalf("tax breakdown = %+v, want %d lines", totals.TaxBreakdown, len(tt.wantNet))
+			}
+			for i, line := range totals.TaxBreakdown {
+				if !near(line.Net, tt.wantNet[i]) {
+					t.Errorf("net at %g%% = %.2f, want %.2f", line.Rate*100, line.Net, tt.wantNet[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComputeInvoiceSkonto(t *testing.T) {
+	tests := []struct {
+		name         string
+		percent      float64
+		days         int
+		documentType string
+		amountPaid   float64
+		wantSkonto   float64
+		wantBalance  float64
+	}{
+		{"no skonto", 0, 0, "", 0, 0, 0},
+		{"percent without days", 2, 0, "", 0, 0, 0},
+		{"2% within 10 days", 2, 10, "", 0, 2.38, 116.62},
+		{"on the balance due", 2, 10, "", 19, 2, 98},
+		{"not on quotes", 2, 10, DocumentQuote, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := itemsInvoice(100)
+			invoice.SkontoPercent = tt.percent
+			invoice.SkontoDays = tt.days
+			invoice.DocumentType = tt.documentType
+			invoice.AmountPaid = tt.amountPaid
+			
+			totals := ComputeInvoice(&invoice)
+			if !near(totals.Skonto, tt.wantSkonto) || !near(totals.BalanceWithSkonto, tt.wantBalance) {
+				t.Errorf("skonto, balance = %.2f, %.2f, want %.2f, %.2f", totals.Skonto, totals.BalanceWithSkonto, tt.wantSkonto, tt.wantBalance)
+			}
+			if !near(totals.Total, 119) {
+				t.Errorf("total = %.2f, want the full 119.00", totals.Total)
+			}
+		})
+	}
+}
+
+func TestSkontoDate(t *testing.T) {
+	tests := []struct {
+		date       string
+		dateFormat string
+		language   string
+		want       string
+	}{
+		{"01.03.2024", "", "", "11.03.2024"},
+		{"2024-03-25", "", "", "04.04.2024"},
+		{"March 1, 2024", "January 2, 2006", "en", "March 11, 2024"},
+		{"1. März 2024", "2. January 2006", "de", "11. März 2024"},
+		{"someday", "", "", ""},
+	}
+	for _, tt := range tests {
+		invoice := Invoice{Date: tt.date, DateFormat: tt.dateFormat, Language: tt.language, SkontoDays: 10}
+		if got := invoice.SkontoDate(); got != tt.want {
+			t.Errorf("SkontoDate of %q = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTotal(t *testing.T) {
+	tests := []struct {
+		name    string
+		invoice Invoice
+		wantErr string
+	}{
+		{"positive total", itemsInvoice(100), ""},
+		{"no items", Invoice{Tax: 0.19}, ""},
+		{"forgotten rates", Invoice{Items: []string{"A"}, Rates: []float64{0}}, "total is 0.00, the items add up to nothing (are the rates or quantities missing?)"},
+		{"credits only", Invoice{Items: []string{"A"}, Rates: []float64{-10}, AllowCredits: true}, "total is -10.00, the items add up to -10.00"},
+		{"discount eats the subtotal", Invoice{Items: []string{"A"}, Rates: []float64{10}, Discount: 10, DiscountType: DiscountFixed}, "total is 0.00, the discount 10.00 is not less than the subtotal 10.00"},
+		{"credit note", Invoice{Items: []string{"A"}, Rates: []float64{10}, DocumentType: DocumentCreditNote}, ""},
+		{
+			name:    "percentages adding up to 100",
+			invoice: Invoice{Items: []string{"Anzahlung", "Abschluss"}, Quantities: []int{30, 70}, Rates: []float64{50, 50}, ItemUnits: []string{"%", "%"}, ValidatePercentageItems: true},
+		},
+		{
+			name:    "percentages not adding up to 100",
+			invoice: Invoice{Items: []string{"Anzahlung", "Abschluss"}, Quantities: []int{30, 60}, Rates: []float64{50, 50}, ItemUnits: []string{"%", "%"}, ValidatePercentageItems: true},
+			wantErr: "the percentages of the items add up to 90%, not 100%",
+		},
+		{
+			name:    "percentages without quantities count once each",
+			invoice: Invoice{Items: []string{"A", "B"}, Rates: []float64{50, 50}, ItemUnits: []string{"%", "%"}, ValidatePercentageItems: true},
+			wantErr: "the percentages of the items add up to 2%, not 100%",
+		},
+		{
+			name: "mixed invoice counts only the percentages",
+			invoice: Invoice{
+				Items:                   []string{"Anzahlung", "Reisekosten", "Abschluss", "Support"},
+				Quantities:              []int{30, 2, 70, 8},
+				Rates:                   []float64{50, 120, 50, 95},
+				ItemUnits:               []string{"%", "", "%", "h"},
+				ValidatePercentageItems: true,
+			},
+		},
+		{
+			name: "mixed invoice with percentages not adding up to 100",
+			invoice: Invoice{
+				Items:                   []string{"Anzahlung", "Reisekosten", "Support"},
+				Quantities:              []int{30, 2, 8},
+				Rates:                   []float64{50, 120, 95},
+				ItemUnits:               []string{"%", "", "h"},
+				ValidatePercentageItems: true,
+			},
+			wantErr: "the percentages of the items add up to 30%, not 100%",
+		},
+		{
+			name:    "percentages checked when the total is not positive",
+			invoice: Invoice{Items: []string{"Anzahlung", "Abschluss"}, Quantities: []int{30, 60}, Rates: []float64{0, 0}, ItemUnits: []string{"%", "%"}, ValidatePercentageItems: true},
+			wantErr: "the percentages of the items add up to 90%, not 100%",
+		},
+		{
+			name:    "no percentage items",
+			invoice: Invoice{Items: []string{"Beratung"}, Quantities: []int{8}, Rates: []float64{95}, ItemUnits: []string{"h"}, ValidatePercentageItems: true},
+			wantErr: "no item is billed in %, set the unit of the percentage items",
+		},
+		{
+			name:    "percentages not checked unless enabled",
+			invoice: Invoice{Items: []string{"Anzahlung"}, Quantities: []int{30}, Rates: []float64{50}, ItemUnits: []string{"%"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.invoice.CheckTotal()
+			if got := errorText(err); got != tt.wantErr {
+				t.Errorf("CheckTotal() = %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errorText returns the message of err, "" for nil
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
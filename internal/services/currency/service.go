@@ -3,28 +3,96 @@ package currency
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 )
 
 // Service defines the interface for currency operations
 type Service interface {
 	GetSymbol(currency string) string
+	GetDecimals(currency string) int
 	GetAvailableCurrencies() map[string]string
 	LoadConfig(configPath string) error
 	ExportConfig(configPath string) error
 }
 
+// Currency is a currency code with its symbol
+type Currency struct {
+	Code   string `json:"code"`
+	Symbol string `json:"symbol"`
+}
+
+// SortedCurrencies returns the available currencies of a service sorted by code
+func SortedCurrencies(s Service) []Currency {
+	var currencies []Currency
+	for code, symbol := range s.GetAvailableCurrencies() {
+		currencies = append(currencies, Currency{Code: code, Symbol: symbol})
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Code < currencies[j].Code
+	})
+	return currencies
+}
+
+// defaultDecimals is the number of decimal places of currencies not listed
+// in the decimals config
+const defaultDecimals = 2
+
+// FormatAmount formats an amount with the given number of decimal places,
+// e.g. "1250" for yen and "1250.00" for euros
+func FormatAmount(amount float64, decimals int) string {
+	return strconv.FormatFloat(RoundAmount(amount, decimals), 'f', decimals, 64)
+}
+
+// FormatAmountSeparated formats an amount like FormatAmount, with the given
+// decimal separator and the whole part grouped in thousands by thousandsSep,
+// e.g. "1.234,56" or "1 234,56". An empty thousandsSep leaves it ungrouped.
+func FormatAmountSeparated(amount float64, decimals int, decimalSep, thousandsSep string) string {
+	formatted := FormatAmount(amount, decimals)
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	
+	whole, fraction, hasFraction := strings.Cut(formatted, ".")
+	if thousandsSep != "" {
+		var grouped strings.Builder
+		for i, digit := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				grouped.WriteString(thousandsSep)
+			}
+			grouped.WriteRune(digit)
+		}
+		whole = grouped.String()
+	}
+	
+	if !hasFraction {
+		return sign + whole
+	}
+	return sign + whole + decimalSep + fraction
+}
+
+// RoundAmount rounds an amount to the given number of decimal places
+func RoundAmount(amount float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(amount*scale) / scale
+}
+
 // DefaultCurrencyService implements the Service interface
 type DefaultCurrencyService struct {
-	symbols map[string]string
+	symbols  map[string]string
+	decimals map[string]int
 }
 
 // NewCurrencyService creates a new DefaultCurrencyService instance
 func NewCurrencyService() Service {
 	service := &DefaultCurrencyService{
-		symbols: make(map[string]string),
+		symbols:  make(map[string]string),
+		decimals: make(map[string]int),
 	}
 	
 	// Initialize with default symbols
@@ -69,6 +137,10 @@ func (s *DefaultCurrencyService) initDefaultSymbols() {
 	for code, symbol := range defaultSymbols {
 		s.symbols[code] = symbol
 	}
+	
+	// Currencies without minor units, all others have two decimal places
+	s.decimals["JPY"] = 0
+	s.decimals["KRW"] = 0
 }
 
 // loadConfigFromStandardLocations tries to load configuration from standard locations
@@ -104,6 +176,15 @@ func (s *DefaultCurrencyService) GetSymbol(currency string) string {
 	return symbol
 }
 
+// GetDecimals returns the number of decimal places amounts in the given
+// currency are printed with, 2 unless configured otherwise
+func (s *DefaultCurrencyService) GetDecimals(currency string) int {
+	if decimals, exists := s.decimals[strings.ToUpper(currency)]; exists {
+		return decimals
+	}
+	return defaultDecimals
+}
+
 // GetAvailableCurrencies returns all available currencies and their symbols
 func (s *DefaultCurrencyService) GetAvailableCurrencies() map[string]string {
 	// Create a copy to avoid modifying the internal map
@@ -139,7 +220,8 @@ func (s *DefaultCurrencyService) LoadConfig(configPath string) error {
 	
 	// If direct unmarshaling failed, try with a structured format
 	var config struct {
-		Symbols map[string]string `json:"symbols"`
+		Symbols  map[string]string `json:"symbols"`
+		Decimals map[string]int    `json:"decimals"`
 	}
 	
 	err = json.Unmarshal(data, &config)
@@ -147,10 +229,19 @@ func (s *DefaultCurrencyService) LoadConfig(configPath string) error {
 		return fmt.Errorf("invalid JSON format: %v", err)
 	}
 	
+	for code, decimals := range config.Decimals {
+		if decimals < 0 || decimals > 4 {
+			return fmt.Errorf("invalid decimals %d for %s, use 0 to 4", decimals, code)
+		}
+	}
+	
 	// Merge with existing symbols
 	for code, symbol := range config.Symbols {
 		s.symbols[strings.ToUpper(code)] = symbol
 	}
+	for code, decimals := range config.Decimals {
+		s.decimals[strings.ToUpper(code)] = decimals
+	}
 	
 	return nil
 }
@@ -159,9 +250,11 @@ func (s *DefaultCurrencyService) LoadConfig(configPath string) error {
 func (s *DefaultCurrencyService) ExportConfig(configPath string) error {
 	// Create a config struct
 	config := struct {
-		Symbols map[string]string `json:"symbols"`
+		Symbols  map[string]string `json:"symbols"`
+		Decimals map[string]int    `json:"decimals"`
 	}{
-		Symbols: s.symbols,
+		Symbols:  s.symbols,
+		Decimals: s.decimals,
 	}
 	
 	// Marshal to JSON with indentation
@@ -0,0 +1,96 @@
+package currency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestService returns a service with the built-in defaults only, without
+// any currency config of the machine running the tests
+func newTestService() *DefaultCurrencyService {
+	service := &DefaultCurrencyService{
+		symbols:  make(map[string]string),
+		decimals: make(map[string]int),
+	}
+	service.initDefaultSymbols()
+	return service
+}
+
+func TestFormatAmount(t *testing.T) {
+	tests := []struct {
+		amount       float64
+		decimals     int
+		decimalSep   string
+		thousandsSep string
+		want         string
+	}{
+		{1250, 0, ".", "", "1250"},
+		{1250.4, 0, ".", ",", "1,250"},
+		{1250.5, 0, ".", ",", "1,251"},
+		{1234.5, 2, ".", "", "1234.50"},
+		{1234567.891, 2, ",", ".", "1.234.567,89"},
+		{1234.5, 2, ",", " ", "1 234,50"},
+		{-1234.5, 2, ",", ".", "-1.234,50"},
+		{999.999, 2, ".", ",", "1,000.00"},
+		{0.125, 3, ".", "", "0.125"},
+	}
+	for _, tt := range tests {
+		if got := FormatAmountSeparated(tt.amount, tt.decimals, tt.decimalSep, tt.thousandsSep); got != tt.want {
+			t.Errorf("FormatAmountSeparated(%v, %d, %q, %q) = %q, want %q", tt.amount, tt.decimals, tt.decimalSep, tt.thousandsSep, got, tt.want)
+		}
+	}
+}
+
+func TestGetDecimals(t *testing.T) {
+	service := newTestService()
+	tests := []struct {
+		currency string
+		want     int
+	}{
+		{"EUR", 2},
+		{"JPY", 0},
+		{"jpy", 0},
+		{"KRW", 0},
+		{"XYZ", 2},
+		{"", 2},
+	}
+	for _, tt := range tests {
+		if got := service.GetDecimals(tt.currency); got != tt.want {
+			t.Errorf("GetDecimals(%q) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestLoadConfigDecimals(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    map[string]int
+		wantErr bool
+	}{
+		{"symbols only", `{"ABC": "A"}`, map[string]int{"ABC": 2, "JPY": 0}, false},
+		{"decimals", `{"symbols": {"BHD": "BD"}, "decimals": {"bhd": 3, "isk": 0}}`, map[string]int{"BHD": 3, "ISK": 0, "EUR": 2}, false},
+		{"too many decimals", `{"decimals": {"XYZ": 5}}`, nil, true},
+		{"negative decimals", `{"decimals": {"XYZ": -1}}`, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "currency.json")
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+			
+			service := newTestService()
+			err := service.LoadConfig(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, want error %v", err, tt.wantErr)
+			}
+			for currency, want := range tt.want {
+				if got := service.GetDecimals(currency); got != want {
+					t.Errorf("GetDecimals(%q) = %d, want %d", currency, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package email
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	
+	"invoice/internal/models"
+	"invoice/internal/services/currency"
+)
+
+// TemplateData holds the values available to the subject and body templates
+type TemplateData struct {
+	Id    string
+	Total string
+	Due   string
+}
+
+// Message describes a single invoice email
+type Message struct {
+	To             string
+	AttachmentPath string
+	Data           TemplateData
+}
+
+// Sender defines the interface for emailing invoices
+type Sender interface {
+	Send(message Message) error
+}
+
+// SMTPSender sends invoices through an SMTP server
+type SMTPSender struct {
+	config models.EmailConfig
+}
+
+// NewSMTPSender creates a new SMTPSender after checking the required settings
+func NewSMTPSender(config models.EmailConfig) (Sender, error) {
+	if config.Host == "" {
+		return nil, fmt.Errorf("email host is not configured")
+	}
+	if config.From == "" {
+		return nil, fmt.Errorf("email sender address is not configured")
+	}
+	if config.Port == 0 {
+		config.Port = models.DefaultEmailConfig().Port
+	}
+	
+	switch strings.ToLower(config.TLS) {
+	case "", "starttls", "tls", "none":
+	default:
+		return nil, fmt.Errorf("unsupported email tls mode: %s (supported: starttls, tls, none)", config.TLS)
+	}
+	
+	return &SMTPSender{config: config}, nil
+}
+
+// NewTemplateData collects the template values for an invoice. The total is
+// given with the currency code, e.g. "119.00 EUR". An invoice without items,
+// such as one known only by its PDF's file name, has no total to report, so
+// Total is left empty rather than claiming 0.00.
+func NewTemplateData(invoice *models.Invoice) TemplateData {
+	data := TemplateData{
+		Id:  invoice.Id + invoice.IdSuffix,
+		Due: invoice.Due,
+	}
+	if len(invoice.Items) > 0 {
+		decimals := currency.NewCurrencyService().GetDecimals(invoice.Currency)
+		data.Total = strings.TrimSpace(currency.FormatAmount(models.CalculateTotal(invoice), decimals) + " " + invoice.Currency)
+	}
+	return data
+}
+
+// InvoiceMessage builds the message for a rendered invoice PDF. Without an
+// explicit recipient the first address in the invoice's bill-to block is used.
+func InvoiceMessage(invoice *models.Invoice, pdfPath, to string) (Message, error) {
+	if to == "" {
+		var err error
+		to, err = RecipientFromText(strings.Join(invoice.RecipientLines(), "\n"))
+		if err != nil {
+			return Message{}, err
+		}
+	}
+	
+	return Message{
+		To:             to,
+		AttachmentPath: pdfPath,
+		Data:           NewTemplateData(invoice),
+	}, nil
+}
+
+// RecipientFromText finds the first email address in free text such as the
+// invoice's To field
+func RecipientFromText(text string) (string, error) {
+	formatted := strings.ReplaceAll(text, `\n`, "\n")
+	for _, field := range strings.Fields(formatted) {
+		if !strings.Contains(field, "@") {
+			continue
+		}
+		candidate := strings.Trim(field, "<>()[],;:\"'")
+		if address, err := mail.ParseAddress(candidate); err == nil {
+			return address.Address, nil
+		}
+	}
+	return "", fmt.Errorf("no email address found in recipient, use an explicit address")
+}
+
+// Send renders the templates and sends the message with the PDF attached
+func (s *SMTPSender) Send(message Message) error {
+	to, err := mail.ParseAddress(message.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient address %q: %v", message.To, err)
+	}
+	from, err := mail.ParseAddress(s.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender address %q: %v", s.config.From, err)
+	}
+	
+	subject, err := renderTemplate("subject", s.config.Subject, message.Data)
+	if err != nil {
+		return err
+	}
+	body, err := renderTemplate("body", s.config.Body, message.Data)
+	if err != nil {
+		return err
+	}
+	
+	attachment, err := os.ReadFile(message.AttachmentPath)
+	if err != nil {
+		return fmt.Errorf("unable to read attachment: %v", err)
+	}
+	
+	data, err := buildMessage(from, to, subject, body, filepath.Base(message.AttachmentPath), attachment)
+	if err != nil {
+		return err
+	}
+	
+	return s.deliver(from.Address, to.Address, data)
+}
+
+// deliver connects to the SMTP server, secures and authenticates the
+// connection as configured and transmits the message
+func (s *SMTPSender) deliver(from, to string, data []byte) error {
+	addr := net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port))
+	tlsConfig := &tls.Config{ServerName: s.config.Host}
+	mode := strings.ToLower(s.config.TLS)
+	
+	var client *smtp.Client
+	if mode == "tls" {
+		// Implicit TLS, usually on port 465
+		conn, err := tls.Dial("tcp", addr, tlsConfig)
+		if err != nil {
+			return fmt.Errorf("unable to connect to %s: %v", addr, err)
+		}
+		client, err = smtp.NewClient(conn, s.config.Host)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("unable to start smtp session: %v", err)
+		}
+	} else {
+		var err error
+		client, err = smtp.Dial(addr)
+		if err != nil {
+			return fmt.Errorf("unable to connect to %s: %v", addr, err)
+		}
+	}
+	defer client.Close()
+	
+	if mode == "" || mode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp server %s does not support STARTTLS", addr)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls failed: %v", err)
+		}
+	}
+	
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp authentication failed: %v", err)
+		}
+	}
+	
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp sender rejected: %v", err)
+	}
+	if err := client.Rcpt(to); err != nil {
+		return fmt.Errorf("smtp recipient rejected: %v", err)
+	}
+	
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data failed: %v", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("smtp data failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp data failed: %v", err)
+	}
+	
+	return client.Quit()
+}
+
+// renderTemplate executes a subject or body template
+func renderTemplate(name, text string, data TemplateData) (string, error) {
+	tmpl, err := template.New(name).Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("invalid email %s template: %v", name, err)
+	}
+	
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("unable to render email %s: %v", name, err)
+	}
+	return buf.String(), nil
+}
+
+// buildMessage assembles a multipart MIME message with a text body and the PDF attachment
+func buildMessage(from, to *mail.Address, subject, body, filename string, attachment []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	
+	// Headers
+	fmt.Fprintf(&buf, "From: %s\r\n", from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", writer.Boundary())
+	
+	// Text body
+	bodyPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {"text/plain; charset=utf-8"},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	qp := quotedprintable.NewWriter(bodyPart)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	qp.Close()
+	
+	// PDF attachment, base64 encoded in 76 character lines
+	attachmentPart, err := writer.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType("application/pdf", map[string]string{"name": filename})},
+		"Content-Transfer-Encoding": {"base64"},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+	})
+	if err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(attachment)
+	for len(encoded) > 76 {
+		fmt.Fprintf(attachmentPart, "%s\r\n", encoded[:76])
+		encoded = encoded[76:]
+	}
+	fmt.Fprintf(attachmentPart, "%s\r\n", encoded)
+	
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,38 @@
+package email
+
+import (
+	"testing"
+	
+	"invoice/internal/models"
+)
+
+func TestDefaultBody(t *testing.T) {
+	invoice := models.DefaultInvoice()
+	invoice.Id = "R-1"
+	invoice.Currency = "EUR"
+	invoice.Items = []string{"Beratung"}
+	invoice.Quantities = []int{2}
+	invoice.Rates = []float64{50}
+	invoice.Tax = 0
+	
+	tests := []struct {
+		name    string
+		invoice models.Invoice
+		want    string
+	}{
+		{"with invoice data", invoice, "anbei erhalten Sie unsere Rechnung R-1 über 100.00 EUR."},
+		{"from the file name only", models.Invoice{Id: "R-1"}, "anbei erhalten Sie unsere Rechnung R-1."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := renderTemplate("body", models.DefaultEmailConfig().Body, NewTemplateData(&tt.invoice))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "Guten Tag,\n\n" + tt.want + "\n\nMit freundlichen Grüßen"
+			if body != want {
+				t.Errorf("body = %q, want %q", body, want)
+			}
+		})
+	}
+}
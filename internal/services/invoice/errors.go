@@ -0,0 +1,83 @@
+package invoice
+
+import (
+	"errors"
+	"io/fs"
+	
+	"invoice/internal/services/pdf"
+)
+
+// Kinds of errors returned by the service. Match them with errors.Is, e.g.
+// errors.Is(err, ErrValidation); Code names them for API clients.
+var (
+	// ErrValidation is invalid input, such as items without rates
+	ErrValidation = errors.New("validation")
+	
+	// ErrConfig is an invoice config file that can't be read or parsed
+	ErrConfig = errors.New("config")
+	
+	// ErrFontMissing is a font that is missing or can't be loaded
+	ErrFontMissing = errors.New("font_missing")
+	
+	// ErrRender is a failure to lay out the PDF, e.g. a broken appended PDF
+	ErrRender = errors.New("render")
+	
+	// ErrStorage is a failure to write the PDF, e.g. a full disk
+	ErrStorage = errors.New("storage")
+)
+
+// Error is an error of the service, of one of the kinds above
+type Error struct {
+	Kind error
+	Err  error
+}
+
+// Error returns the message of the underlying error
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error is of the given kind
+func (e *Error) Is(target error) bool {
+	return e.Kind == target
+}
+
+// Code returns the machine-readable code of an error returned by the
+// service, e.g. "validation", or "internal" for errors of no known kind
+func Code(err error) string {
+	var serviceErr *Error
+	if errors.As(err, &serviceErr) {
+		return serviceErr.Kind.Error()
+	}
+	return "internal"
+}
+
+// newError wraps err as an error of the given kind, keeping nil
+func newError(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: kind, Err: err}
+}
+
+// renderError classifies an error of the renderer: fonts that can't be
+// loaded, files that can't be written, or any other layout failure
+func renderError(err error) error {
+	var fontErr *pdf.FontError
+	var pathErr *fs.PathError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &fontErr):
+		return newError(ErrFontMissing, err)
+	case errors.As(err, &pathErr):
+		return newError(ErrStorage, err)
+	default:
+		return newError(ErrRender, err)
+	}
+}
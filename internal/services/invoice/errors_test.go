@@ -0,0 +1,46 @@
+package invoice
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"syscall"
+	"testing"
+	
+	"invoice/internal/services/pdf"
+)
+
+func TestRenderErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+		code string
+	}{
+		{"missing font", &pdf.FontError{Err: fs.ErrNotExist}, ErrFontMissing, "font_missing"},
+		{"wrapped missing font", fmt.Errorf("setup: %w", &pdf.FontError{Err: fs.ErrNotExist}), ErrFontMissing, "font_missing"},
+		{"full disk", &fs.PathError{Op: "write", Path: "R-1.pdf", Err: syscall.ENOSPC}, ErrStorage, "storage"},
+		{"layout failure", errors.New("broken appendix"), ErrRender, "render"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := renderError(tt.err)
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("renderError(%v) is not %v", tt.err, tt.kind)
+			}
+			if code := Code(err); code != tt.code {
+				t.Errorf("Code = %q, want %q", code, tt.code)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Errorf("renderError(%v) doesn't wrap the original error", tt.err)
+			}
+		})
+	}
+	
+	if err := renderError(nil); err != nil {
+		t.Errorf("renderError(nil) = %v, want nil", err)
+	}
+	if code := Code(errors.New("unclassified")); code != "internal" {
+		t.Errorf("Code of an unclassified error = %q, want internal", code)
+	}
+}
@@ -0,0 +1,230 @@
+package invoice
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	
+	"invoice/internal/config"
+	"invoice/internal/models"
+	"invoice/internal/services/pdf"
+)
+
+// GenerateOptions holds a fully resolved invoice and where to write it
+type GenerateOptions struct {
+	Invoice    models.Invoice
+	OutputPath string
+}
+
+// GenerateResult describes a generated invoice file and its amounts
+type GenerateResult struct {
+	Path     string
+	Currency string
+	Totals   models.Totals
+}
+
+// Service defines the interface for invoice generation
+type Service interface {
+	ParseRequest(request *models.InvoiceRequest) (*GenerateOptions, error)
+	Generate(options *GenerateOptions) (*GenerateResult, error)
+	Render(options *GenerateOptions, w io.Writer) error
+	CheckReady() error
+}
+
+// DefaultInvoiceService implements the Service interface
+type DefaultInvoiceService struct {
+	renderer     pdf.Renderer
+	configLoader config.ConfigLoader
+	configDir    string
+	outputDir    string
+}
+
+// NewInvoiceService creates a new DefaultInvoiceService instance that reads
+// configs from configDir and writes generated PDFs to outputDir
+func NewInvoiceService(renderer pdf.Renderer, configLoader config.ConfigLoader, configDir, outputDir string) Service {
+	return &DefaultInvoiceService{
+		renderer:     renderer,
+		configLoader: configLoader,
+		configDir:    configDir,
+		outputDir:    outputDir,
+	}
+}
+
+// ParseRequest turns web form data into an invoice, starting from the selected
+// config file if any. Form fields that are set override the config values.
+// Errors are of the kind ErrValidation or ErrConfig.
+func (s *DefaultInvoiceService) ParseRequest(request *models.InvoiceRequest) (*GenerateOptions, error) {
+	if err := request.ValidateIds(); err != nil {
+		return nil, newError(ErrValidation, err)
+	}
+	
+	invoice := models.DefaultInvoice()
+	
+	if request.UseConfig && request.ConfigFile != "" {
+		// Resolve bare names against the configured directory
+		configFile := request.ConfigFile
+		if filepath.Dir(configFile) == "." {
+			configFile = filepath.Join(s.configDir, configFile)
+		}
+		
+		loaded, err := s.configLoader.LoadInvoice(configFile)
+		if err != nil {
+			return nil, newError(ErrConfig, err)
+		}
+		invoice = *loaded
+	} else {
+		// Form data only - take the footer company name and visibility from the form
+		if request.CompanyName != "" {
+			invoice.Footer.CompanyName = request.CompanyName
+		} else if request.From != "" {
+			invoice.From = request.From
+			invoice.Footer.CompanyName = invoice.SenderName()
+		}
+		invoice.Footer.ShowRegistration = request.ShowRegistration
+		invoice.Footer.ShowVatId = request.ShowVatId
+	}
+	
+	if request.Id != "" {
+		invoice.Id = request.Id
+	}
+	if request.IdSuffix != "" {
+		invoice.IdSuffix = request.IdSuffix
+	}
+	if request.From != "" {
+		// The form's free text replaces a structured sender from the config
+		invoice.From = request.From
+		invoice.Sender = nil
+	}
+	if request.To != "" {
+		// Likewise for a structured recipient
+		invoice.To = request.To
+		invoice.Recipient = nil
+	}
+	
+	if len(request.Items) > 0 {
+		invoice.Items, invoice.Quantities, invoice.Rates = nil, nil, nil
+		for _, item := range request.Items {
+			quantity := item.Quantity
+			if quantity == 0 {
+				quantity = 1
+			}
+			invoice.Items = append(invoice.Items, item.Description)
+			invoice.Quantities = append(invoice.Quantities, quantity)
+			invoice.Rates = append(invoice.Rates, item.Rate)
+		}
+	} else if request.LegacyItems != "" {
+		items, quantities, rates, err := parseItems(request.LegacyItems, request.Quantities, request.Rates)
+		if err != nil {
+			return nil, newError(ErrValidation, err)
+		}
+		invoice.Items = items
+		invoice.Quantities = quantities
+		invoice.Rates = rates
+	}
+	
+	// Handle tax exemption first - it forces the tax to 0
+	if request.TaxExempt {
+		invoice.TaxExempt = true
+		invoice.Tax = 0
+	} else if request.Tax != 0 {
+		invoice.Tax = request.Tax
+	}
+	
+	// Items may be taxed at their own rate, the others at the invoice's rate
+	if len(request.Items) > 0 && !invoice.TaxExempt {
+		invoice.ItemTaxRates = models.ItemTaxRates(request.Items, invoice.Tax)
+	}
+	
+	if request.Discount != 0 {
+		invoice.Discount = request.Discount
+	}
+	if request.DiscountType != "" {
+		invoice.DiscountType = request.DiscountType
+	}
+	if request.Currency != "" {
+		invoice.Currency = request.Currency
+	}
+	if request.Note != "" {
+		invoice.Note = request.Note
+	}
+	
+	if err := invoice.Validate(); err != nil {
+		return nil, newError(ErrValidation, err)
+	}
+	
+	return &GenerateOptions{
+		Invoice:    invoice,
+		OutputPath: filepath.Join(s.outputDir, invoice.Id+invoice.IdSuffix+".pdf"),
+	}, nil
+}
+
+// Generate renders the invoice to its output path and returns that path
+// together with the computed totals. Errors are of the kind ErrFontMissing,
+// ErrStorage or ErrRender.
+func (s *DefaultInvoiceService) Generate(options *GenerateOptions) (*GenerateResult, error) {
+	if err := s.renderer.RenderToFile(&options.Invoice, options.OutputPath); err != nil {
+		return nil, renderError(err)
+	}
+	return &GenerateResult{
+		Path:     options.OutputPath,
+		Currency: options.Invoice.Currency,
+		Totals:   models.ComputeInvoice(&options.Invoice),
+	}, nil
+}
+
+// Render writes the invoice PDF to w without touching the disk
+func (s *DefaultInvoiceService) Render(options *GenerateOptions, w io.Writer) error {
+	return renderError(s.renderer.Render(&options.Invoice, w))
+}
+
+// CheckReady reports whether invoices can be generated: the config directory
+// must be readable and the fonts loadable
+func (s *DefaultInvoiceService) CheckReady() error {
+	dir, err := os.Open(s.configDir)
+	if err != nil {
+		return fmt.Errorf("config directory not readable: %v", err)
+	}
+	defer dir.Close()
+	
+	if _, err := dir.Readdirnames(1); err != nil && err != io.EOF {
+		return fmt.Errorf("config directory not readable: %v", err)
+	}
+	
+	if err := s.renderer.CheckFonts(); err != nil {
+		return fmt.Errorf("fonts not loadable: %v", err)
+	}
+	
+	return nil
+}
+
+// parseItems splits the ||-joined form fields into item, quantity and rate lists
+func parseItems(itemsText, quantitiesText, ratesText string) ([]string, []int, []float64, error) {
+	items := strings.Split(itemsText, "||")
+	
+	var quantities []int
+	if quantitiesText != "" {
+		for i, value := range strings.Split(quantitiesText, "||") {
+			quantity, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid quantity for item %d: %q", i+1, value)
+			}
+			quantities = append(quantities, quantity)
+		}
+	}
+	
+	var rates []float64
+	if ratesText != "" {
+		for i, value := range strings.Split(ratesText, "||") {
+			rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid rate for item %d: %q", i+1, value)
+			}
+			rates = append(rates, rate)
+		}
+	}
+	
+	return items, quantities, rates, nil
+}
@@ -0,0 +1,143 @@
+package invoice
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	
+	"invoice/internal/config"
+	"invoice/internal/models"
+)
+
+func TestParseRequestItems(t *testing.T) {
+	tests := []struct {
+		name       string
+		request    models.InvoiceRequest
+		items      []string
+		quantities []int
+		rates      []float64
+	}{
+		{
+			name: "items with commas",
+			request: models.InvoiceRequest{Items: []models.InvoiceItemRequest{
+				{Description: "Consulting, March", Quantity: 10, Rate: 95},
+				{Description: "Travel, Berlin", Rate: 120.5},
+			}},
+			items:      []string{"Consulting, March", "Travel, Berlin"},
+			quantities: []int{10, 1},
+			rates:      []float64{95, 120.5},
+		},
+		{
+			name: "legacy items with commas",
+			request: models.InvoiceRequest{
+				LegacyItems: "Consulting, March||Travel, Berlin",
+				Quantities:  "10||1",
+				Rates:       "95||120.5",
+			},
+			items:      []string{"Consulting, March", "Travel, Berlin"},
+			quantities: []int{10, 1},
+			rates:      []float64{95, 120.5},
+		},
+	}
+	service := NewInvoiceService(nil, config.NewConfigLoader(), t.TempDir(), t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := service.ParseRequest(&tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			invoice := options.Invoice
+			if !reflect.DeepEqual(invoice.Items, tt.items) {
+				t.Errorf("items = %q, want %q", invoice.Items, tt.items)
+			}
+			if !reflect.DeepEqual(invoice.Quantities, tt.quantities) {
+				t.Errorf("quantities = %v, want %v", invoice.Quantities, tt.quantities)
+			}
+			if !reflect.DeepEqual(invoice.Rates, tt.rates) {
+				t.Errorf("rates = %v, want %v", invoice.Rates, tt.rates)
+			}
+		})
+	}
+}
+
+func TestParseRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.InvoiceRequest
+		kind    error
+	}{
+		{"invalid id", models.InvoiceRequest{Id: "../evil"}, ErrValidation},
+		{"invalid quantity", models.InvoiceRequest{LegacyItems: "Consulting, March", Quantities: "ten", Rates: "95"}, ErrValidation},
+		{"missing config", models.InvoiceRequest{UseConfig: true, ConfigFile: "missing.yaml"}, ErrConfig},
+	}
+	service := NewInvoiceService(nil, config.NewConfigLoader(), t.TempDir(), t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.ParseRequest(&tt.request)
+			if !errors.Is(err, tt.kind) {
+				t.Errorf("error = %v, want kind %v", err, tt.kind)
+			}
+		})
+	}
+}
+
+func TestParseRequestItemTaxRates(t *testing.T) {
+	reduced, standard := 0.07, 0.19
+	request := models.InvoiceRequest{
+		Tax: 0.19,
+		Items: []models.InvoiceItemRequest{
+			{Description: "Beratung", Quantity: 2, Rate: 100, TaxRate: &standard},
+			{Description: "Fachbuch", Rate: 50, TaxRate: &reduced},
+			{Description: "Reisekosten", Rate: 30},
+		},
+	}
+	service := NewInvoiceService(nil, config.NewConfigLoader(), t.TempDir(), t.TempDir())
+	options, err := service.ParseRequest(&request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	invoice := options.Invoice
+	if want := []float64{0.19, 0.07, 0.19}; !reflect.DeepEqual(invoice.ItemTaxRates, want) {
+		t.Errorf("item tax rates = %v, want %v", invoice.ItemTaxRates, want)
+	}
+	
+	// The totals are broken down per rate
+	totals := models.ComputeInvoice(&invoice)
+	want := []models.TaxLine{
+		{Rate: 0.07, Net: 50, Tax: 3.5},
+		{Rate: 0.19, Net: 230, Tax: 43.7},
+	}
+	if len(totals.TaxBreakdown) != len(want) {
+		t.Fatalf("tax breakdown = %+v, want %+v", totals.TaxBreakdown, want)
+	}
+	for i, line := range totals.TaxBreakdown {
+		if line.Rate != want[i].Rate || math.Abs(line.Net-want[i].Net) > 1e-9 || math.Abs(line.Tax-want[i].Tax) > 1e-9 {
+			t.Errorf("tax line %d = %+v, want %+v", i, line, want[i])
+		}
+	}
+}
+
+func TestParseRequestWithoutItemTaxRates(t *testing.T) {
+	reduced := 0.07
+	tests := []struct {
+		name    string
+		request models.InvoiceRequest
+	}{
+		{"no item sets a rate", models.InvoiceRequest{Items: []models.InvoiceItemRequest{{Description: "Beratung", Rate: 100}}}},
+		{"tax exempt", models.InvoiceRequest{TaxExempt: true, Items: []models.InvoiceItemRequest{{Description: "Fachbuch", Rate: 50, TaxRate: &reduced}}}},
+	}
+	service := NewInvoiceService(nil, config.NewConfigLoader(), t.TempDir(), t.TempDir())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options, err := service.ParseRequest(&tt.request)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if options.Invoice.ItemTaxRates != nil {
+				t.Errorf("item tax rates = %v, want none", options.Invoice.ItemTaxRates)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package ledger
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// dateLayout is the date format of the records, which sorts chronologically
+const dateLayout = "2006-01-02"
+
+// Record is a generated invoice as kept in the ledger
+type Record struct {
+	Id       string  `json:"id"`
+	Date     string  `json:"date"`
+	Customer string  `json:"customer"`
+	Total    float64 `json:"total"`
+	Currency string  `json:"currency"`
+	File     string  `json:"file"`
+	Type     string  `json:"type,omitempty"`
+}
+
+// NewRecord creates the record of an invoice written to file. The total is
+// the one printed on the invoice.
+func NewRecord(invoice *models.Invoice, file string) Record {
+	record := Record{
+		Id:       invoice.Id + invoice.IdSuffix,
+		Date:     invoice.Date,
+		Customer: invoice.RecipientName(),
+		Total:    models.ComputeInvoice(invoice).Total,
+		Currency: invoice.Currency,
+		File:     file,
+		Type:     invoice.DocumentType,
+	}
+	
+	// Dates are printed in the invoice's own format, the ledger keeps them sortable
+	format := invoice.DateFormat
+	if format == "" {
+		format = models.DefaultDateFormat
+	}
+	if date, err := models.ParseFormattedDate(invoice.Date, format, invoice.Language); err == nil {
+		record.Date = date.Format(dateLayout)
+	} else if date, err := models.ParseDate(invoice.Date); err == nil {
+		record.Date = date.Format(dateLayout)
+	}
+	return record
+}
+
+// Append adds a record to the ledger at path, a JSON Lines file with one
+// record per line that is created on first use. Each record is written with
+// a single append, so concurrent generations don't interleave.
+func Append(path string, record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to open ledger: %v", err)
+	}
+	defer file.Close()
+	
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("unable to write ledger: %v", err)
+	}
+	return file.Close()
+}
+
+// Read returns the records of the ledger at path in the order they were
+// added. A missing ledger has no records.
+func Read(path string) ([]Record, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to open ledger: %v", err)
+	}
+	defer file.Close()
+	
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		
+		var record Record
+		if err := json.Unmarshal([]byte(text), &record); err != nil {
+			return nil, fmt.Errorf("%s line %d: %v", path, line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read ledger: %v", err)
+	}
+	return records, nil
+}
+
+// Filter selects records by date range and customer. Empty fields match
+// every record.
+type Filter struct {
+	// From and To are inclusive dates in any accepted input format
+	From string
+	To   string
+	
+	// Customer matches any part of the customer name, ignoring case
+	Customer string
+}
+
+// Apply returns the records matching the filter
+func (f Filter) Apply(records []Record) ([]Record, error) {
+	from, err := filterDate(f.From)
+	if err != nil {
+		return nil, err
+	}
+	to, err := filterDate(f.To)
+	if err != nil {
+		return nil, err
+	}
+	customer := strings.ToLower(f.Customer)
+	
+	var matched []Record
+	for _, record := range records {
+		if from != "" && record.Date < from {
+			continue
+		}
+		if to != "" && record.Date > to {
+			continue
+		}
+		if customer != "" && !strings.Contains(strings.ToLower(record.Customer), customer) {
+			continue
+		}
+		matched = append(matched, record)
+	}
+	return matched, nil
+}
+
+// filterDate converts a filter date to the format of the records
+func filterDate(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	date, err := models.ParseDate(value)
+	if err != nil {
+		return "", err
+	}
+	return date.Format(dateLayout), nil
+}
+
+// Totals sums the totals of the records per currency
+func Totals(records []Record) map[string]float64 {
+	totals := make(map[string]float64)
+	for _, record := range records {
+		totals[record.Currency] += record.Total
+	}
+	return totals
+}
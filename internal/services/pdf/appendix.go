@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	
+	"github.com/phpdave11/gofpdi"
+	"github.com/signintech/gopdf"
+)
+
+// appendPDFs adds all pages of the given PDFs after the invoice, each on a
+// page of its own size. They are included as they are, without the page
+// number and footer of the invoice pages, which only count the invoice.
+//
+// gopdf lists imported pages in map order, so unlike other invoices one with
+// appended PDFs doesn't render to the same bytes every time.
+func appendPDFs(pdf *gopdf.GoPdf, paths []string) (err error) {
+	// gofpdi panics instead of returning an error on files it can't read
+	path := ""
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("unable to append %s, it is not a readable PDF: %v", path, recovered)
+		}
+	}()
+	
+	pdf.AddHeader(nil)
+	pdf.AddFooter(nil)
+	
+	for _, path = range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to append PDF: %v", err)
+		}
+		if !isCompletePDF(data) {
+			return fmt.Errorf("unable to append %s, it is not a complete PDF", path)
+		}
+		
+		// The importer and gopdf both read the pages from the same source
+		var source io.ReadSeeker = bytes.NewReader(data)
+		importer := gofpdi.NewImporter()
+		importer.SetSourceStream(&source)
+		
+		boxes := importer.GetPageSizes()
+		for page := 1; page <= len(boxes); page++ {
+			size := gopdf.Rect{W: boxes[page]["/MediaBox"]["w"], H: boxes[page]["/MediaBox"]["h"]}
+			pdf.AddPageWithOption(gopdf.PageOption{PageSize: &size})
+			template := pdf.ImportPageStream(&source, page, "/MediaBox")
+			pdf.UseImportedTemplate(template, 0, 0, size.W, size.H)
+		}
+	}
+	return nil
+}
+
+// isCompletePDF reports whether data starts with a PDF header and ends with
+// the offset of its cross-reference table. gofpdi doesn't return when the
+// offset is missing, e.g. in a truncated download.
+func isCompletePDF(data []byte) bool {
+	tail := data
+	if len(tail) > 1024 {
+		tail = tail[len(tail)-1024:]
+	}
+	return bytes.HasPrefix(data, []byte("%PDF-")) && bytes.Contains(tail, []byte("startxref"))
+}
@@ -0,0 +1,66 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	
+	"invoice/internal/models"
+	
+	"github.com/signintech/gopdf"
+)
+
+// background is a letterhead image, sized and placed for drawing behind the
+// content of a page
+type background struct {
+	holder    gopdf.ImageHolder
+	x         float64
+	rect      gopdf.Rect
+	firstOnly bool
+}
+
+// loadBackground loads the invoice's background image and scales it to the
+// page width, keeping its aspect ratio, so a header band stays at the top.
+// An image taller than the page is scaled to the page height and centered.
+// It returns nil if there is no background or it can't be loaded.
+func (r *PDFRenderer) loadBackground(invoice *models.Invoice) *background {
+	if invoice.BackgroundImage == "" {
+		return nil
+	}
+	
+	holder, width, height, err := r.loadLogo(invoice.BackgroundImage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add background to PDF: %v\n", err)
+		return nil
+	}
+	if width <= 0 || height <= 0 {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add background to PDF: %s has no size\n", invoice.BackgroundImage)
+		return nil
+	}
+	
+	page := gopdf.PageSizeA4
+	scaledWidth := page.W
+	scaledHeight := float64(height) * page.W / float64(width)
+	if scaledHeight > page.H {
+		scaledHeight = page.H
+		scaledWidth = float64(width) * page.H / float64(height)
+	}
+	
+	return &background{
+		holder:    holder,
+		x:         (page.W - scaledWidth) / 2,
+		rect:      gopdf.Rect{W: scaledWidth, H: scaledHeight},
+		firstOnly: invoice.BackgroundPages == models.BackgroundPagesFirst,
+	}
+}
+
+// writeBackground draws the background at the top of the current page. It
+// must be drawn before anything else on the page, which it would cover.
+func (r *PDFRenderer) writeBackground(pdf *gopdf.GoPdf, b *background) {
+	if b == nil || (b.firstOnly && pdf.GetNumberOfPages() > 1) {
+		return
+	}
+	
+	if err := pdf.ImageByHolder(b.holder, b.x, 0, &b.rect); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add background to PDF: %v\n", err)
+	}
+}
@@ -0,0 +1,32 @@
+package pdf
+
+import "invoice/internal/models"
+
+// density scales the spacing and font sizes of the invoice body, so the
+// compact density fits more item rows on a page. The header, logo and footer
+// keep their size.
+type density struct {
+	spacing float64
+	font    float64
+}
+
+// normalDensity is the default layout, every size as written
+var normalDensity = density{spacing: 1, font: 1}
+
+// densityFor returns the density for an invoice's Density setting
+func densityFor(name string) density {
+	if name == models.DensityCompact {
+		return density{spacing: 0.75, font: 0.9}
+	}
+	return normalDensity
+}
+
+// gap scales a row height or the gap between sections
+func (d density) gap(height float64) float64 {
+	return height * d.spacing
+}
+
+// size scales a font size, or the height of a line set in it
+func (d density) size(fontSize float64) float64 {
+	return fontSize * d.font
+}
@@ -0,0 +1,219 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	
+	"invoice/internal/models"
+	
+	"github.com/signintech/gopdf"
+)
+
+// Footer geometry
+const (
+	footerMarginX    = 40.0
+	footerColumnGap  = 15.0
+	footerFontSize   = 8.0
+	footerLineHeight = 10.0
+	
+	// footerRuleGap is the space between the rule and the first footer line
+	footerRuleGap = 15.0
+)
+
+// writeFooter adds the footer information to the PDF at the renderer's
+// footerTop, arranged as configured in the footer's layout. Empty columns are
+// left out and the remaining ones share the page width.
+func (r *PDFRenderer) writeFooter(pdf *gopdf.GoPdf, footer models.Footer, l labels) {
+	pdf.SetY(r.footerTop)
+	
+	// Add a line above the footer
+	pdf.SetStrokeColor(225, 225, 225)
+	pdf.Line(footerMarginX, pdf.GetY(), gopdf.PageSizeA4.W-footerMarginX, pdf.GetY())
+	pdf.Br(footerRuleGap)
+	
+	// Set font for footer text
+	_ = pdf.SetFont(fontRegular, "", footerFontSize)
+	pdf.SetTextColor(75, 75, 75)
+	
+	columns := r.wrapFooter(pdf, footer, l)
+	if footer.Layout == models.FooterLayout1ColCentered {
+		r.writeCenteredFooter(pdf, columns)
+		return
+	}
+	
+	// Column X positions follow from the page width and the number of columns
+	startY := pdf.GetY()
+	columnWidth := footerColumnWidth(len(columns))
+	
+	for i, column := range columns {
+		x := footerMarginX + float64(i)*(columnWidth+footerColumnGap)
+		pdf.SetY(startY)
+		for _, line := range column {
+			pdf.SetX(x)
+			_ = pdf.Cell(nil, line)
+			pdf.Br(footerLineHeight)
+		}
+	}
+}
+
+// writeCenteredFooter writes each column centered, as wrapped by wrapFooter
+func (r *PDFRenderer) writeCenteredFooter(pdf *gopdf.GoPdf, columns [][]string) {
+	width := gopdf.PageSizeA4.W - 2*footerMarginX
+	
+	for _, column := range columns {
+		for _, line := range column {
+			lineWidth, err := pdf.MeasureTextWidth(line)
+			if err != nil {
+				lineWidth = width
+			}
+			pdf.SetX(footerMarginX + (width-lineWidth)/2)
+			_ = pdf.Cell(nil, line)
+			pdf.Br(footerLineHeight)
+		}
+	}
+}
+
+// footerTopFor returns the Y position of the rule above the invoice's footer,
+// so that its longest column ends footerMarginBottom above the bottom of the
+// page, or above the letterhead's footer zone of a background image.
+// A footer without any content still leaves room for the rule.
+func (r *PDFRenderer) footerTopFor(pdf *gopdf.GoPdf, invoice *models.Invoice, l labels) float64 {
+	footer := invoice.Footer
+	_ = pdf.SetFont(fontRegular, "", footerFontSize)
+	columns := r.wrapFooter(pdf, footer, l)
+	
+	lines := 0
+	for _, column := range columns {
+		if footer.Layout == models.FooterLayout1ColCentered {
+			// Centered columns are stacked
+			lines += len(column)
+		} else if len(column) > lines {
+			lines = len(column)
+		}
+	}
+	return gopdf.PageSizeA4.H - invoice.BackgroundMarginBottom - footerMarginBottom - footerRuleGap - float64(lines)*footerLineHeight
+}
+
+// wrapFooter returns the footer columns with their lines wrapped to the
+// column width, or for the centered layout each column joined into a single
+// line wrapped to the page width. The footer font must be set.
+func (r *PDFRenderer) wrapFooter(pdf *gopdf.GoPdf, footer models.Footer, l labels) [][]string {
+	columns := footerColumns(footer, l)
+	
+	var wrapped [][]string
+	for _, column := range columns {
+		if footer.Layout == models.FooterLayout1ColCentered {
+			wrapped = append(wrapped, r.wrapText(pdf, strings.Join(column, " · "), gopdf.PageSizeA4.W-2*footerMarginX, footerFontSize))
+			continue
+		}
+		
+		var lines []string
+		for _, line := range column {
+			lines = append(lines, r.wrapText(pdf, line, footerColumnWidth(len(columns)), footerFontSize)...)
+		}
+		wrapped = append(wrapped, lines)
+	}
+	return wrapped
+}
+
+// footerColumnWidth returns the width of each of count footer columns
+func footerColumnWidth(count int) float64 {
+	width := gopdf.PageSizeA4.W - 2*footerMarginX
+	return (width - footerColumnGap*float64(count-1)) / float64(count)
+}
+
+// footerColumns returns the lines of each footer column, dropping empty
+// lines and columns. Custom columns from the config win over generated ones.
+func footerColumns(footer models.Footer, l labels) [][]string {
+	var columns [][]string
+	if len(footer.Columns) > 0 {
+		columns = footer.Columns
+	} else {
+		company := companyColumn(footer)
+		contact := contactColumn(footer, l)
+		bank := bankColumn(footer, l)
+		
+		switch footer.Layout {
+		case "", models.FooterLayout3Col, models.FooterLayout1ColCentered:
+			columns = [][]string{company, contact, bank}
+		case models.FooterLayout2Col:
+			columns = [][]string{append(company, contact...), bank}
+		default:
+			fmt.Fprintf(os.Stderr, "Warning: Unknown footer layout %q, using %q\n", footer.Layout, models.FooterLayout3Col)
+			columns = [][]string{company, contact, bank}
+		}
+	}
+	
+	var result [][]string
+	for _, column := range columns {
+		var lines []string
+		for _, line := range column {
+			if strings.TrimSpace(line) != "" {
+				lines = append(lines, line)
+			}
+		}
+		if len(lines) > 0 {
+			result = append(result, lines)
+		}
+	}
+	return result
+}
+
+// companyColumn lists the company name, registration info and VAT ID
+func companyColumn(footer models.Footer) []string {
+	lines := []string{footer.CompanyName}
+	
+	// Registration info - only if it should be shown
+	if footer.ShowRegistration {
+		lines = append(lines, strings.Split(strings.ReplaceAll(footer.RegistrationInfo, `\n`, "\n"), "\n")...)
+	}
+	
+	// VAT ID - only if it should be shown
+	if footer.ShowVatId {
+		lines = append(lines, footer.VatId)
+	}
+	
+	return lines
+}
+
+// contactColumn lists the address, phone, email and website. The phone label
+// is only printed together with a number.
+func contactColumn(footer models.Footer, l labels) []string {
+	zipCity := strings.TrimSpace(footer.Zip + " " + footer.City)
+	lines := []string{footer.Address, zipCity}
+	
+	if footer.Phone != "" {
+		lines = append(lines, l.get("phoneLabel")+" "+footer.Phone)
+	}
+	
+	// Email and website share a line, wrapped if too long for the column
+	var contact []string
+	for _, value := range []string{footer.Email, footer.Website} {
+		if value != "" {
+			contact = append(contact, value)
+		}
+	}
+	lines = append(lines, strings.Join(contact, " | "))
+	
+	return lines
+}
+
+// bankColumn lists the bank details below their header. Without any bank
+// details the column is left out entirely, header included.
+func bankColumn(footer models.Footer, l labels) []string {
+	if footer.BankName == "" && footer.BankIban == "" && footer.BankBic == "" {
+		return nil
+	}
+	
+	lines := []string{l.get("bankLabel"), footer.BankName}
+	
+	if footer.BankIban != "" {
+		lines = append(lines, "IBAN: "+footer.BankIban)
+	}
+	if footer.BankBic != "" {
+		lines = append(lines, "BIC: "+footer.BankBic)
+	}
+	
+	return lines
+}
@@ -0,0 +1,81 @@
+package pdf
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	
+	"invoice/internal/models"
+)
+
+func TestFooterColumns(t *testing.T) {
+	l := labelsFor("de", nil)
+	
+	tests := []struct {
+		name   string
+		footer models.Footer
+		want   [][]string
+	}{
+		{
+			name:   "company name and address only",
+			footer: models.Footer{CompanyName: "Firma GmbH", Address: "Hauptstraße 1", Zip: "10115", City: "Berlin"},
+			want:   [][]string{{"Firma GmbH"}, {"Hauptstraße 1", "10115 Berlin"}},
+		},
+		{
+			name:   "phone label only with a number",
+			footer: models.Footer{CompanyName: "Firma GmbH", Phone: "+49 30 1234", Email: "info@firma.de"},
+			want:   [][]string{{"Firma GmbH"}, {"Tel.: +49 30 1234", "info@firma.de"}},
+		},
+		{
+			name:   "bank header only with bank details",
+			footer: models.Footer{CompanyName: "Firma GmbH", BankIban: "DE00 1111"},
+			want:   [][]string{{"Firma GmbH"}, {"Bankverbindung:", "IBAN: DE00 1111"}},
+		},
+		{
+			name:   "registration and VAT ID only when shown",
+			footer: models.Footer{CompanyName: "Firma GmbH", RegistrationInfo: "HRB 1", VatId: "DE111", ShowVatId: true},
+			want:   [][]string{{"Firma GmbH", "DE111"}},
+		},
+		{
+			name:   "empty footer",
+			footer: models.Footer{},
+			want:   nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := footerColumns(tt.footer, l); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("footerColumns = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMinimalFooterLayout(t *testing.T) {
+	renderer := newTestRenderer()
+	invoice := testInvoice()
+	invoice.Footer = models.Footer{CompanyName: "Firma GmbH", Address: "Hauptstraße 1", Zip: "10115", City: "Berlin"}
+	
+	texts := renderTexts(t, renderer, invoice)
+	ruleY := footerRuleY(t, renderer, invoice)
+	footer := findTexts(texts, func(text layoutText) bool { return text.y < ruleY })
+	
+	// The two columns left share the page width, none is kept for the bank
+	columns := map[string]float64{}
+	for _, text := range footer {
+		columns[text.text] = text.x
+	}
+	want := map[string]float64{
+		"Firma GmbH":    footerMarginX,
+		"Hauptstraße 1": footerMarginX + footerColumnWidth(2) + footerColumnGap,
+		"10115 Berlin":  footerMarginX + footerColumnWidth(2) + footerColumnGap,
+	}
+	if len(columns) != len(want) {
+		t.Errorf("footer texts = %v, want %v", columns, want)
+	}
+	for text, x := range want {
+		if got, ok := columns[text]; !ok || math.Abs(got-x) > 0.01 {
+			t.Errorf("%q at x %.2f, want %.2f", text, got, x)
+		}
+	}
+}
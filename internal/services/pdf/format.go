@@ -0,0 +1,94 @@
+package pdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	
+	"invoice/internal/models"
+	"invoice/internal/services/currency"
+)
+
+// amountFormatter prints money values with the invoice's currency symbol and
+// the currency's number of decimal places
+type amountFormatter struct {
+	symbol      string
+	decimals    int
+	parentheses bool
+	
+	// Separators set on the invoice, "." and no grouping by default
+	decimalSep   string
+	thousandsSep string
+}
+
+// newAmountFormatter creates the formatter for an invoice's currency and
+// negative number style
+func newAmountFormatter(currencyService currency.Service, invoice *models.Invoice) amountFormatter {
+	return newCurrencyFormatter(currencyService, invoice.Currency, invoice)
+}
+
+// newSettlementFormatter creates the formatter for an invoice's settlement
+// currency, with the invoice's negative number style
+func newSettlementFormatter(currencyService currency.Service, invoice *models.Invoice) amountFormatter {
+	return newCurrencyFormatter(currencyService, invoice.SettlementCurrency, invoice)
+}
+
+// newCurrencyFormatter creates the formatter for a currency code
+func newCurrencyFormatter(currencyService currency.Service, code string, invoice *models.Invoice) amountFormatter {
+	return amountFormatter{
+		symbol:      currencyService.GetSymbol(code),
+		decimals:    currencyService.GetDecimals(code),
+		parentheses: strings.EqualFold(invoice.NegativeFormat, "parentheses"),
+		
+		decimalSep:   invoice.DecimalSep(),
+		thousandsSep: invoice.ThousandsSeparator,
+	}
+}
+
+// format puts the sign in front of the symbol (-€50.00) rather than between
+// the symbol and the number, or wraps negative amounts in parentheses
+func (f amountFormatter) format(amount float64) string {
+	// Amounts that round to zero never get a sign
+	rounded := currency.RoundAmount(amount, f.decimals)
+	value := f.symbol + currency.FormatAmountSeparated(math.Abs(rounded), f.decimals, f.decimalSep, f.thousandsSep)
+	
+	if rounded >= 0 {
+		return value
+	}
+	if f.parentheses {
+		return "(" + value + ")"
+	}
+	return "-" + value
+}
+
+// formatQuantity prints a quantity with the given number of decimal places,
+// "8" by default or e.g. "8.00" with two
+func formatQuantity(quantity int, decimals int) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+	return strconv.FormatFloat(float64(quantity), 'f', decimals, 64)
+}
+
+// formatItemQuantity formats a quantity followed by its unit, e.g. "8 h",
+// with a percentage written as "30%"
+func formatItemQuantity(quantity int, decimals int, unit string) string {
+	switch unit {
+	case "":
+		return formatQuantity(quantity, decimals)
+	case models.PercentUnit:
+		return formatQuantity(quantity, decimals) + unit
+	default:
+		return formatQuantity(quantity, decimals) + " " + unit
+	}
+}
+
+// formatPercent formats a rate such as 0.19 as "19%", with one decimal place
+// for rates that aren't a whole percentage, e.g. "7.5%"
+func formatPercent(rate float64) string {
+	percent := rate * 100
+	if rounded := math.Round(percent); math.Abs(percent-rounded) < 1e-9 {
+		return strconv.FormatFloat(rounded, 'f', 0, 64) + "%"
+	}
+	return strconv.FormatFloat(percent, 'f', 1, 64) + "%"
+}
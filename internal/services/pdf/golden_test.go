@@ -0,0 +1,416 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode/utf16"
+	
+	"invoice/internal/models"
+)
+
+// update rewrites the golden files instead of comparing against them:
+//
+//	go test ./internal/services/pdf -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// goldenInvoices are the representative invoices checked against
+// testdata/<name>.golden
+func goldenInvoices() map[string]models.Invoice {
+	invoices := map[string]models.Invoice{}
+	
+	invoices["basic"] = testInvoice()
+	
+	english := testInvoice()
+	english.Language = "en"
+	english.Currency = "USD"
+	english.DateFormat = "January 2, 2006"
+	english.Date = "March 1, 2024"
+	english.Due = "March 15, 2024"
+	english.ServiceDateFrom = "February 1, 2024"
+	english.Discount = 10
+	english.DiscountType = "percent"
+	invoices["english-discount"] = english
+	
+	exempt := testInvoice()
+	exempt.TaxExempt = true
+	exempt.Tax = 0
+	exempt.Note = "Vielen Dank für Ihren Auftrag."
+	invoices["tax-exempt"] = exempt
+	
+	credit := testInvoice()
+	credit.DocumentType = models.DocumentCreditNote
+	credit.Items = []string{"Gutschrift Beratung"}
+	credit.Quantities = []int{1}
+	credit.Rates = []float64{-120}
+	invoices["credit-note"] = credit
+	
+	long := testInvoice()
+	long.Items = []string{
+		"Konzeption, Entwicklung und Test der Schnittstelle zum Warenwirtschaftssystem einschließlich Dokumentation und Abnahme",
+		"Kurz",
+	}
+	invoices["long-descriptions"] = long
+	
+	sections := testInvoice()
+	sections.Items = []string{"Frontend", "Backend", "Server", "Backup"}
+	sections.Quantities = []int{8, 12, 1, 1}
+	sections.Rates = []float64{95, 95, 49, 9.9}
+	sections.ItemDates = []string{"05.02.2024", "12.02.2024", "01.02.2024", "01.02.2024"}
+	sections.ItemSections = []string{"Entwicklung", "Entwicklung", "Hosting", "Hosting"}
+	invoices["sections-dates"] = sections
+	
+	multipage := testInvoice()
+	multipage.Items, multipage.Quantities, multipage.Rates = nil, nil, nil
+	for i := 1; i <= 60; i++ {
+		multipage.Items = append(multipage.Items, fmt.Sprintf("Position %d", i))
+		multipage.Quantities = append(multipage.Quantities, i%5+1)
+		multipage.Rates = append(multipage.Rates, float64(i)*1.5)
+	}
+	invoices["multipage"] = multipage
+	
+	return invoices
+}
+
+func TestGolden(t *testing.T) {
+	renderer := newTestRenderer()
+	renderer.SetDeterministic(true)
+	
+	for name, invoice := range goldenInvoices() {
+		t.Run(name, func(t *testing.T) {
+			layout, err := extractLayout(render(t, renderer, invoice))
+			if err != nil {
+				t.Fatal(err)
+			}
+			
+			path := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.MkdirAll("testdata", 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(path, []byte(layout), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if layout != string(want) {
+				t.Errorf("layout differs from %s (run with -update to accept it):\n%s", path, diffLines(string(want), layout))
+			}
+		})
+	}
+}
+
+// diffLines lists the lines that differ between two layouts
+func diffLines(want, got string) string {
+	wantLines, gotLines := strings.Split(want, "\n"), strings.Split(got, "\n")
+	var b strings.Builder
+	for i := 0; i < len(wantLines) || i < len(gotLines); i++ {
+		var w, g string
+		if i < len(wantLines) {
+			w = wantLines[i]
+		}
+		if i < len(gotLines) {
+			g = gotLines[i]
+		}
+		if w != g {
+			fmt.Fprintf(&b, "line %d:\n  want %s\n  got  %s\n", i+1, w, g)
+		}
+	}
+	return b.String()
+}
+
+var (
+	xrefPattern      = regexp.MustCompile(`(?m)^xref\n0 (\d+)\n`)
+	lengthPattern    = regexp.MustCompile(`/Length\s+(\d+)`)
+	pagesRefPattern  = regexp.MustCompile(`/Pages\s+(\d+) 0 R`)
+	kidsListPattern  = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+	refPattern       = regexp.MustCompile(`(\d+) 0 R`)
+	contentsPattern  = regexp.MustCompile(`/Contents\s+(\d+) 0 R`)
+	resourcePattern  = regexp.MustCompile(`/Resources\s+(\d+) 0 R`)
+	fontEntryPattern = regexp.MustCompile(`/(F\d+)\s+(\d+) 0 R`)
+	baseFontPattern  = regexp.MustCompile(`/BaseFont\s+/(\S+)`)
+	toUnicodePattern = regexp.MustCompile(`/ToUnicode\s+(\d+) 0 R`)
+	bfrangePattern   = regexp.MustCompile(`<([0-9A-Fa-f]+)><([0-9A-Fa-f]+)><([0-9A-Fa-f]+)>`)
+	stringPattern    = regexp.MustCompile(`<([0-9A-Fa-f]*)>|\(((?:\\.|[^\\)])*)\)`)
+)
+
+// pdfObject is an object of a rendered PDF: its dictionary and, for
+// streams, the decoded data
+type pdfObject struct {
+	dict   string
+	stream []byte
+}
+
+// extractLayout lists the text, rules and images of each page of a PDF
+// written by gopdf, with their positions, one per line. Text is decoded
+// through the fonts' ToUnicode maps, so the golden files can be read.
+func extractLayout(data []byte) (string, error) {
+	objects, err := readObjects(data)
+	if err != nil {
+		return "", err
+	}
+	
+	// gopdf writes the catalog first
+	root := pagesRefPattern.FindStringSubmatch(objects[1].dict)
+	if root == nil {
+		return "", fmt.Errorf("no page tree")
+	}
+	pages := objects[atoi(root[1])]
+	kids := kidsListPattern.FindStringSubmatch(pages.dict)
+	if kids == nil {
+		return "", fmt.Errorf("no pages")
+	}
+	
+	var b strings.Builder
+	for i, ref := range refPattern.FindAllStringSubmatch(kids[1], -1) {
+		page := objects[atoi(ref[1])]
+		fmt.Fprintf(&b, "page %d\n", i+1)
+		
+		fonts := map[string]pdfFont{}
+		if resources := resourcePattern.FindStringSubmatch(page.dict); resources != nil {
+			for _, entry := range fontEntryPattern.FindAllStringSubmatch(objects[atoi(resources[1])].dict, -1) {
+				fonts[entry[1]] = readFont(objects, objects[atoi(entry[2])])
+			}
+		}
+		
+		contents := contentsPattern.FindStringSubmatch(page.dict)
+		if contents == nil {
+			continue
+		}
+		writeContent(&b, objects[atoi(contents[1])].stream, fonts)
+	}
+	return b.String(), nil
+}
+
+// readObjects reads the objects listed in the cross-reference table
+func readObjects(data []byte) (map[int]pdfObject, error) {
+	xref := xrefPattern.FindSubmatchIndex(data)
+	if xref == nil {
+		return nil, fmt.Errorf("no cross-reference table")
+	}
+	count := atoi(string(data[xref[2]:xref[3]]))
+	entries := strings.Split(string(data[xref[1]:]), "\n")
+	
+	objects := map[int]pdfObject{}
+	for id := 1; id < count; id++ {
+		offset := atoi(strings.Fields(entries[id])[0])
+		body := data[offset:]
+		
+		end := bytes.Index(body, []byte("endobj"))
+		start := bytes.Index(body, []byte("stream\n"))
+		if start < 0 || start > end {
+			objects[id] = pdfObject{dict: string(body[:end])}
+			continue
+		}
+		
+		dict := string(body[:start])
+		length := lengthPattern.FindStringSubmatch(dict)
+		if length == nil {
+			return nil, fmt.Errorf("object %d: stream without length", id)
+		}
+		stream := body[start+len("stream\n") : start+len("stream\n")+atoi(length[1])]
+		if strings.Contains(dict, "FlateDecode") {
+			r, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				return nil, fmt.Errorf("object %d: %v", id, err)
+			}
+			if stream, err = io.ReadAll(r); err != nil {
+				return nil, fmt.Errorf("object %d: %v", id, err)
+			}
+		}
+		objects[id] = pdfObject{dict: dict, stream: stream}
+	}
+	return objects, nil
+}
+
+// pdfFont is a font of a page: its name and the characters of its glyphs
+type pdfFont struct {
+	name  string
+	runes map[uint16][]rune
+}
+
+// readFont reads the name and ToUnicode map of a Type0 font
+func readFont(objects map[int]pdfObject, font pdfObject) pdfFont {
+	result := pdfFont{runes: map[uint16][]rune{}}
+	if name := baseFontPattern.FindStringSubmatch(font.dict); name != nil {
+		result.name = name[1]
+	}
+	toUnicode := toUnicodePattern.FindStringSubmatch(font.dict)
+	if toUnicode == nil {
+		return result
+	}
+	
+	for _, r := range bfrangePattern.FindAllStringSubmatch(string(objects[atoi(toUnicode[1])].stream), -1) {
+		first, last, target := hexNumber(r[1]), hexNumber(r[2]), hexNumber(r[3])
+		for glyph := first; glyph <= last; glyph++ {
+			result.runes[uint16(glyph)] = utf16.Decode([]uint16{uint16(target + glyph - first)})
+		}
+	}
+	return result
+}
+
+// writeContent lists the text, lines, rectangles and images drawn by a
+// content stream
+func writeContent(b *strings.Builder, content []byte, fonts map[string]pdfFont) {
+	var (
+		operands []string
+		font     pdfFont
+		size     string
+		x, y     string
+		matrix   []string
+	)
+	
+	for _, token := range tokenize(content) {
+		if !isOperator(token) {
+			operands = append(operands, token)
+			continue
+		}
+		
+		switch token {
+		case "Td", "TD", "m":
+			if len(operands) >= 2 {
+				x, y = operands[len(operands)-2], operands[len(operands)-1]
+			}
+		case "Tf":
+			if len(operands) >= 2 {
+				font, size = fonts[strings.TrimPrefix(operands[len(operands)-2], "/")], operands[len(operands)-1]
+			}
+		case "TJ", "Tj":
+			if len(operands) >= 1 {
+				fmt.Fprintf(b, "text %s %s %s %s %q\n", x, y, font.name, size, decodeText(operands[len(operands)-1], font))
+			}
+		case "l":
+			if len(operands) >= 2 {
+				fmt.Fprintf(b, "line %s %s %s %s\n", x, y, operands[len(operands)-2], operands[len(operands)-1])
+				x, y = operands[len(operands)-2], operands[len(operands)-1]
+			}
+		case "re":
+			if len(operands) >= 4 {
+				fmt.Fprintf(b, "rect %s\n", strings.Join(operands[len(operands)-4:], " "))
+			}
+		case "cm":
+			if len(operands) >= 6 {
+				matrix = operands[len(operands)-6:]
+			}
+		case "Do":
+			if len(operands) >= 1 {
+				fmt.Fprintf(b, "image %s %s\n", operands[len(operands)-1], strings.Join(matrix, " "))
+			}
+		}
+		operands = operands[:0]
+	}
+}
+
+// tokenize splits a content stream into operands and operators. Arrays,
+// hex strings and literal strings are single tokens.
+func tokenize(content []byte) []string {
+	var tokens []string
+	for i := 0; i < len(content); {
+		c := content[i]
+		switch {
+		case c == ' ' || c == '\n' || c == '\r' || c == '\t':
+			i++
+			continue
+		case c == '[' || c == '<' && (i+1 >= len(content) || content[i+1] != '<'):
+			closing := byte(']')
+			if c == '<' {
+				closing = '>'
+			}
+			end := bytes.IndexByte(content[i:], closing)
+			if end < 0 {
+				end = len(content) - i - 1
+			}
+			tokens = append(tokens, string(content[i:i+end+1]))
+			i += end + 1
+			continue
+		case c == '(':
+			end, depth := i, 0
+			for ; end < len(content); end++ {
+				if content[end] == '\\' {
+					end++
+					continue
+				}
+				if content[end] == '(' {
+					depth++
+				}
+				if content[end] == ')' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			tokens = append(tokens, string(content[i:end+1]))
+			i = end + 1
+			continue
+		}
+		
+		end := i
+		for end < len(content) && !strings.ContainsRune(" \n\r\t[<(", rune(content[end])) {
+			end++
+		}
+		if end == i {
+			end++
+		}
+		tokens = append(tokens, string(content[i:end]))
+		i = end
+	}
+	return tokens
+}
+
+// isOperator reports whether a token is a content stream operator
+// rather than an operand
+func isOperator(token string) bool {
+	c := token[0]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '\'' || c == '"'
+}
+
+// decodeText decodes the glyph ids of a TJ array or Tj string
+func decodeText(operand string, font pdfFont) string {
+	var b strings.Builder
+	for _, part := range stringPattern.FindAllStringSubmatch(operand, -1) {
+		if part[1] == "" {
+			b.WriteString(part[2])
+			continue
+		}
+		glyphs, err := hex.DecodeString(part[1])
+		if err != nil {
+			b.WriteString(part[1])
+			continue
+		}
+		for i := 0; i+1 < len(glyphs); i += 2 {
+			glyph := uint16(glyphs[i])<<8 | uint16(glyphs[i+1])
+			if runes, ok := font.runes[glyph]; ok {
+				b.WriteString(string(runes))
+			} else {
+				fmt.Fprintf(&b, "<%04X>", glyph)
+			}
+		}
+	}
+	return b.String()
+}
+
+// hexNumber parses a hexadecimal number, 0 if it is invalid
+func hexNumber(text string) int {
+	n, _ := strconv.ParseInt(text, 16, 32)
+	return int(n)
+}
+
+// atoi parses a decimal number, 0 if it is invalid
+func atoi(text string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(text))
+	return n
+}
@@ -0,0 +1,464 @@
+package pdf
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	
+	"invoice/internal/models"
+	"invoice/internal/services/currency"
+)
+
+// HTMLRenderer implements the Renderer interface for HTML output, e.g. to
+// embed an invoice in an email or web page. It has the same sections as the
+// PDF and takes its amounts from the same calculation.
+type HTMLRenderer struct {
+	currencyService currency.Service
+}
+
+// NewHTMLRenderer creates a new HTMLRenderer instance
+func NewHTMLRenderer(currencyService currency.Service) *HTMLRenderer {
+	return &HTMLRenderer{
+		currencyService: currencyService,
+	}
+}
+
+// CheckFonts always succeeds, HTML invoices use the fonts of the browser
+func (r *HTMLRenderer) CheckFonts() error {
+	return nil
+}
+
+// Render renders an invoice as an HTML document and writes it to the provided writer
+func (r *HTMLRenderer) Render(invoice *models.Invoice, w io.Writer) error {
+	page, err := r.pageData(invoice)
+	if err != nil {
+		return err
+	}
+	
+	return htmlTemplate.Execute(w, page)
+}
+
+// RenderToFile renders an invoice as HTML and saves it to the provided file path
+func (r *HTMLRenderer) RenderToFile(invoice *models.Invoice, filePath string) error {
+	out, err := os.Create(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to create %s: %v", filePath, err)
+	}
+	defer out.Close()
+	
+	if err := r.Render(invoice, out); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// htmlPage holds the values printed by htmlTemplate
+type htmlPage struct {
+	Language      string
+	Labels        map[string]string
+	Title         string
+	Id            string
+	Date          string
+	CustomerNo    string
+	ServicePeriod string
+	Due           string
+	Logo          template.URL
+	LogoWidth     float64
+	LogoMaxHeight float64
+	LogoAlign     string
+	Sender        []string
+	BillTo        []string
+	BillToDetails []string
+	ShipTo        []string
+	WithDates     bool
+	Compact       bool
+	TableClass    string
+	Rows          []htmlRow
+	ItemSummary   string
+	Note          string
+	NotePosition  string
+	PaidStamp     string
+	Totals        []totalLine
+	TaxSummary    [][]string
+	Terms         []string
+	Footer        [][]string
+}
+
+// htmlRow is an item of the invoice with its formatted amounts. The first
+// item of a section carries the section's name, the last its subtotal.
+type htmlRow struct {
+	Description string
+	Date        string
+	Quantity    string
+	Rate        string
+	Amount      string
+	Striped     bool
+	
+	Section           string
+	SectionTotalLabel string
+	SectionTotal      string
+}
+
+// pageData collects the content of the invoice in the order it is printed
+func (r *HTMLRenderer) pageData(invoice *models.Invoice) (htmlPage, error) {
+	terms, err := termsText(invoice)
+	if err != nil {
+		return htmlPage{}, err
+	}
+	
+	l := labelsFor(invoice.Language, invoice.Labels).forDocument(invoice.DocumentType)
+	money := newAmountFormatter(r.currencyService, invoice)
+	computed := models.ComputeInvoice(invoice)
+	
+	page := htmlPage{
+		Language:      invoice.Language,
+		Labels:        make(map[string]string),
+		Title:         invoice.Title,
+		Id:            invoice.Id + invoice.IdSuffix,
+		Date:          invoice.Date,
+		CustomerNo:    customerNumber(invoice, l),
+		ServicePeriod: servicePeriod(invoice, l),
+		LogoWidth:     defaultLogoWidth,
+		LogoMaxHeight: defaultLogoMaxHeight,
+		LogoAlign:     invoice.LogoAlign,
+		Sender:        invoice.SenderLines(),
+		BillTo:        invoice.RecipientLines(),
+		BillToDetails: recipientDetails(invoice, l),
+		WithDates:     len(invoice.ItemDates) > 0,
+		Compact:       invoice.Density == models.DensityCompact,
+		TableClass:    tableClass(invoice),
+		ItemSummary:   itemSummary(invoice, l),
+		Note:          strings.ReplaceAll(invoice.Note, `\n`, "\n"),
+		NotePosition:  invoice.NotePosition,
+		Totals:        totalLines(invoice, computed, money, newSettlementFormatter(r.currencyService, invoice), l),
+		TaxSummary:    taxSummaryRows(computed, money, l),
+		Footer:        footerColumns(invoice.Footer, l),
+	}
+	
+	// Every label is resolved up front, so the template can look them up by key
+	for key := range translations[defaultLanguage] {
+		page.Labels[key] = l.get(key)
+	}
+	if page.Language == "" {
+		page.Language = defaultLanguage
+	}
+	if page.Title == "" {
+		page.Title = l.get("title")
+	}
+	if invoice.ShowsDueDate() {
+		page.Due = invoice.Due
+	}
+	if invoice.ShipTo != "" {
+		page.ShipTo = addressLines(invoice.ShipTo)
+	}
+	if invoice.PaidDate != "" {
+		page.PaidStamp = l.get("paidStampLabel") + " " + invoice.PaidDate
+	}
+	if terms != "" {
+		page.Terms = strings.Split(strings.ReplaceAll(terms, "\r\n", "\n"), "\n\n")
+	}
+	
+	if invoice.Logo != "" {
+		logo, err := logoDataURL(invoice.Logo)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Unable to add logo to HTML: %v\n", err)
+		}
+		page.Logo = logo
+	}
+	if invoice.LogoWidth > 0 {
+		page.LogoWidth = invoice.LogoWidth
+	}
+	if invoice.LogoMaxHeight > 0 {
+		page.LogoMaxHeight = invoice.LogoMaxHeight
+	}
+	
+	_, striped := tableStyle(invoice)
+	sections := computed.Sections
+	
+	for i, item := range invoice.Items {
+		quantity := 1
+		if len(invoice.Quantities) > i {
+			quantity = invoice.Quantities[i]
+		}
+		
+		rate := 0.0
+		if len(invoice.Rates) > i {
+			rate = invoice.Rates[i] * invoice.AmountSign()
+		}
+		
+		row := htmlRow{
+			Description: item,
+			Quantity:    formatItemQuantity(quantity, invoice.QuantityDecimals, invoice.ItemUnit(i)),
+			Rate:        money.format(rate),
+			Amount:      money.format(float64(quantity) * rate),
+			Striped:     striped && i%2 == 1,
+		}
+		
+		if len(invoice.ItemDates) > i {
+			row.Date = invoice.ItemDates[i]
+		}
+		if invoice.StartsSection(i) {
+			row.Section = invoice.ItemSection(i)
+		}
+		if invoice.EndsSection(i) {
+			row.SectionTotalLabel = sectionTotalLabel(sections[0].Name, l)
+			row.SectionTotal = money.format(sections[0].Subtotal)
+			sections = sections[1:]
+		}
+		page.Rows = append(page.Rows, row)
+	}
+	
+	return page, nil
+}
+
+// recipientDetails returns the small print below the bill-to address: the
+// VAT id and customer number of a structured recipient
+func recipientDetails(invoice *models.Invoice, l labels) []string {
+	if invoice.Recipient == nil || invoice.Recipient.Name == "" {
+		return nil
+	}
+	
+	var details []string
+	if invoice.Recipient.VatId != "" {
+		details = append(details, l.get("vatIdLabel")+": "+invoice.Recipient.VatId)
+	}
+	// The invoice's own customer number is already printed below the title
+	if invoice.Recipient.CustomerNumber != "" && invoice.Recipient.CustomerNumber != invoice.CustomerNumber {
+		details = append(details, l.get("customerNoLabel")+": "+invoice.Recipient.CustomerNumber)
+	}
+	return details
+}
+
+// tableClass returns the classes of the item table, which draw the rule and
+// stripes of the invoice's TableStyle
+func tableClass(invoice *models.Invoice) string {
+	class := "items"
+	ruled, striped := tableStyle(invoice)
+	if ruled {
+		class += " ruled"
+	}
+	if striped {
+		class += " striped"
+	}
+	return class
+}
+
+// customerNumber formats the customer number printed next to the date, or
+// returns "" if none is set
+func customerNumber(invoice *models.Invoice, l labels) string {
+	if invoice.CustomerNumber == "" {
+		return ""
+	}
+	return l.get("customerNoLabel") + ": " + invoice.CustomerNumber
+}
+
+// addressLines splits an address at its line breaks, written as \n in configs
+func addressLines(address string) []string {
+	return strings.Split(strings.ReplaceAll(address, `\n`, "\n"), "\n")
+}
+
+// logoDataURL reads a logo and returns it as a data URL, so the HTML
+// document is self-contained
+func logoDataURL(path string) (template.URL, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to read logo %s: %v", path, err)
+	}
+	
+	mediaType := http.DetectContentType(data)
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		mediaType = "image/svg+xml"
+	}
+	if !strings.HasPrefix(mediaType, "image/") {
+		return "", fmt.Errorf("logo %s is not an image", path)
+	}
+	
+	// The URL is built here from the file's bytes, so it is safe to print as is
+	return template.URL("data:" + mediaType + ";base64," + base64.StdEncoding.EncodeToString(data)), nil
+}
+
+// htmlTemplate lays out an invoice like the PDF, with the styles inline so
+// the document can be sent on its own
+var htmlTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html lang="{{.Language}}">
+<head>
+<meta charset="UTF-8">
+<title>{{.Title}} {{.Id}}</title>
+<style>
+	body { font-family: Inter, Helvetica, Arial, sans-serif; color: #373737; max-width: 800px; margin: 40px auto; padding: 0 20px; font-size: 14px; }
+	.label { color: #4b4b4b; font-size: 12px; letter-spacing: 0.03em; }
+	.muted { color: #646464; }
+	header { border-bottom: 1px solid #e1e1e1; padding-bottom: 20px; margin-bottom: 20px; }
+	header .sender-name { font-size: 16px; }
+	h1 { color: #000; font-size: 28px; margin: 0 0 6px; }
+	.addresses { display: flex; gap: 60px; margin: 30px 0 40px; }
+	.addresses .name { font-size: 20px; }
+	.addresses .details { color: #646464; font-size: 11px; }
+	table.items { width: 100%; border-collapse: collapse; }
+	table.items th { text-align: left; font-weight: normal; padding-bottom: 12px; }
+	table.items td { padding: 4px 0 8px; vertical-align: top; }
+	table.items .number { text-align: right; padding-left: 20px; white-space: nowrap; }
+	.notes { margin-top: 20px; white-space: pre-line; }
+	.notes + table.items, .notes + .muted { margin-top: 20px; }
+	.summary { display: flex; justify-content: space-between; align-items: flex-start; margin-top: 20px; }
+	.stamp { border: 2px solid #1e823c; color: #1e823c; font-weight: bold; padding: 8px; }
+	table.totals { margin-left: auto; border-collapse: collapse; }
+	table.totals td { padding: 6px 0; }
+	table.totals .value { text-align: right; padding-left: 40px; color: #000; font-size: 16px; white-space: nowrap; }
+	table.totals .bold { font-weight: bold; }
+	table.totals .note { white-space: pre-line; }
+	table.tax-summary { margin: 16px 0 0 auto; border-collapse: collapse; font-size: 11px; }
+	table.tax-summary td { padding: 2px 0 2px 24px; text-align: right; }
+	table.tax-summary td:first-child { text-align: left; padding-left: 0; }
+	table.tax-summary tr:last-child { font-weight: bold; }
+	.terms { margin-top: 40px; font-size: 12px; }
+	footer { display: flex; gap: 15px; border-top: 1px solid #e1e1e1; margin-top: 40px; padding-top: 15px; font-size: 11px; color: #4b4b4b; }
+	footer div { flex: 1; }
+	body.compact table.items, body.compact .notes, body.compact table.totals { font-size: 12px; }
+	body.compact table.items th { padding-bottom: 6px; }
+	body.compact table.items td { padding: 2px 0 4px; }
+	body.compact table.totals td { padding: 3px 0; }
+	body.compact .addresses { margin: 20px 0 24px; }
+	table.items.ruled thead th { border-bottom: 1px solid #e1e1e1; }
+	table.items tr.stripe td { background: #f5f5f5; }
+	table.items tr.section td { font-weight: bold; color: #000; padding-top: 8px; }
+	table.items tr.section-total td { padding-bottom: 16px; }
+	table.items tr.section-total .number { font-weight: bold; color: #000; }
+</style>
+</head>
+<body{{if .Compact}} class="compact"{{end}}>
+<header>
+	{{- if .Logo}}
+	<div style="text-align: {{if .LogoAlign}}{{.LogoAlign}}{{else}}left{{end}}">
+		<img src="{{.Logo}}" alt="" style="max-width: {{.LogoWidth}}px; max-height: {{.LogoMaxHeight}}px">
+	</div>
+	{{- end}}
+	{{- range $i, $line := .Sender}}
+	<div{{if eq $i 0}} class="sender-name"{{end}}>{{$line}}</div>
+	{{- end}}
+</header>
+<h1>{{.Title}}</h1>
+<div class="muted">#{{.Id}} · {{.Date}}{{if .CustomerNo}} · {{.CustomerNo}}{{end}}</div>
+{{- if .ServicePeriod}}
+<div class="muted">{{.ServicePeriod}}</div>
+{{- end}}
+<div class="addresses">
+	<div>
+		<div class="label">{{index .Labels "billToLabel"}}</div>
+		{{- range $i, $line := .BillTo}}
+		<div{{if eq $i 0}} class="name"{{end}}>{{$line}}</div>
+		{{- end}}
+		{{- range .BillToDetails}}
+		<div class="details">{{.}}</div>
+		{{- end}}
+	</div>
+	{{- if .ShipTo}}
+	<div>
+		<div class="label">{{index .Labels "shipToLabel"}}</div>
+		{{- range $i, $line := .ShipTo}}
+		<div{{if eq $i 0}} class="name"{{end}}>{{$line}}</div>
+		{{- end}}
+	</div>
+	{{- end}}
+</div>
+{{- if and .Note (eq .NotePosition "before-items")}}
+{{template "notes" .}}
+{{- end}}
+{{- if .Rows}}
+<table class="{{.TableClass}}">
+	<thead>
+		<tr class="label">
+			<th>{{index .Labels "itemLabel"}}</th>
+			{{- if .WithDates}}
+			<th>{{index .Labels "dateLabel"}}</th>
+			{{- end}}
+			<th class="number">{{index .Labels "qtyLabel"}}</th>
+			<th class="number">{{index .Labels "rateLabel"}}</th>
+			<th class="number">{{index .Labels "amountLabel"}}</th>
+		</tr>
+	</thead>
+	<tbody>
+		{{- range .Rows}}
+		{{- if .Section}}
+		<tr class="section"><td colspan="{{if $.WithDates}}5{{else}}4{{end}}">{{.Section}}</td></tr>
+		{{- end}}
+		<tr{{if .Striped}} class="stripe"{{end}}>
+			<td>{{.Description}}</td>
+			{{- if $.WithDates}}
+			<td>{{.Date}}</td>
+			{{- end}}
+			<td class="number">{{.Quantity}}</td>
+			<td class="number">{{.Rate}}</td>
+			<td class="number">{{.Amount}}</td>
+		</tr>
+		{{- if .SectionTotal}}
+		<tr class="section-total label"><td colspan="{{if $.WithDates}}4{{else}}3{{end}}">{{.SectionTotalLabel}}</td><td class="number">{{.SectionTotal}}</td></tr>
+		{{- end}}
+		{{- end}}
+	</tbody>
+	{{- if .ItemSummary}}
+	<tfoot>
+		<tr class="label"><td colspan="{{if .WithDates}}5{{else}}4{{end}}">{{.ItemSummary}}</td></tr>
+	</tfoot>
+	{{- end}}
+</table>
+{{- else}}
+<div class="muted">{{index .Labels "noItemsLabel"}}</div>
+{{- end}}
+{{- if and .Note (or (eq .NotePosition "") (eq .NotePosition "after-items"))}}
+{{template "notes" .}}
+{{- end}}
+<div class="summary">
+	<div>{{if .PaidStamp}}<span class="stamp">{{.PaidStamp}}</span>{{end}}</div>
+	<table class="totals">
+		{{- range .Totals}}
+		{{- if .Note}}
+		<tr><td colspan="2" class="label note">{{.Label}}</td></tr>
+		{{- else}}
+		<tr{{if .Bold}} class="bold"{{end}}><td class="label">{{.Label}}</td><td class="value{{if .Bold}} bold{{end}}">{{.Value}}</td></tr>
+		{{- end}}
+		{{- end}}
+		{{- if .Due}}
+		<tr><td class="label">{{index .Labels "dueDateLabel"}}</td><td class="value">{{.Due}}</td></tr>
+		{{- end}}
+	</table>
+</div>
+{{- if .TaxSummary}}
+<table class="tax-summary">
+	{{- range $i, $row := .TaxSummary}}
+	<tr{{if eq $i 0}} class="label"{{end}}>
+		{{- range $row}}<td>{{.}}</td>{{end -}}
+	</tr>
+	{{- end}}
+</table>
+{{- end}}
+{{- if and .Note (eq .NotePosition "after-totals")}}
+{{template "notes" .}}
+{{- end}}
+{{- if .Terms}}
+<section class="terms">
+	<h2 class="label">{{index .Labels "termsLabel"}}</h2>
+	{{- range .Terms}}
+	<p>{{.}}</p>
+	{{- end}}
+</section>
+{{- end}}
+{{- if .Footer}}
+<footer>
+	{{- range .Footer}}
+	<div>
+		{{- range .}}
+		<div>{{.}}</div>
+		{{- end}}
+	</div>
+	{{- end}}
+</footer>
+{{- end}}
+</body>
+</html>
+{{define "notes"}}<div class="notes"><div class="label">{{index .Labels "notesLabel"}}</div>{{.Note}}</div>{{end}}`))
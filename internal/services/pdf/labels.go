@@ -0,0 +1,190 @@
+package pdf
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// defaultLanguage is used when an invoice does not specify a language
+const defaultLanguage = "de"
+
+// labels holds the user-facing strings printed on an invoice, keyed by a stable name
+type labels map[string]string
+
+// translations contains the built-in label sets per language
+var translations = map[string]labels{
+	"de": {
+		"title":              "RECHNUNG",
+		"creditNoteTitle":    "GUTSCHRIFT",
+		"quoteTitle":         "ANGEBOT",
+		"reminderTitle":      "MAHNUNG",
+		"billToLabel":        "RECHNUNG AN",
+		"shipToLabel":        "LIEFERANSCHRIFT",
+		"customerNoLabel":    "Kundennr.",
+		"vatIdLabel":         "USt-IdNr.",
+		"itemLabel":          "ARTIKEL UND BESCHREIBUNG",
+		"dateLabel":          "DATUM",
+		"qtyLabel":           "MENGE",
+		"rateLabel":          "PREIS",
+		"amountLabel":        "BETRAG",
+		"itemCountLabel":     "Positionen",
+		"noItemsLabel":       "Keine Positionen",
+		"totalQuantityLabel": "Gesamtmenge",
+		"notesLabel":         "HINWEISE",
+		"subtotalLabel":      "Zwischensumme",
+		"discountLabel":      "Rabatt",
+		"taxLabel":           "MwSt.",
+		"taxRateLabel":       "Steuersatz",
+		"includedTaxLabel":   "enthaltene MwSt.",
+		"settlementLabel":    "entspricht",
+		"exchangeRateLabel":  "Kurs",
+		"bindingAmountNote":  "Maßgeblich ist der Betrag in",
+		"skontoNote":         "Skonto bei Zahlung bis",
+		"skontoWithinNote":   "Skonto bei Zahlung innerhalb von",
+		"skontoDaysLabel":    "Tagen",
+		"skontoAmountLabel":  "Zahlbetrag mit Skonto",
+		"netLabel":           "Netto",
+		"grossLabel":         "Brutto",
+		"roundingLabel":      "Rundung",
+		"totalLabel":         "Gesamt",
+		"dueDateLabel":       "Fälligkeitsdatum",
+		"servicePeriodLabel": "Leistungszeitraum",
+		"serviceDateLabel":   "Leistungsdatum",
+		"amountPaidLabel":    "Anzahlung",
+		"balanceDueLabel":    "Offener Betrag",
+		"creditLabel":        "Guthaben",
+		"paidStampLabel":     "BEZAHLT am",
+		"taxExemptNote":      "Gemäß § 19 UStG wird keine Umsatzsteuer berechnet.",
+		"quoteExemptNote":    "Gemäß § 19 UStG wird keine Umsatzsteuer ausgewiesen.",
+		"creditExemptNote":   "Gemäß § 19 UStG enthält die Gutschrift keine Umsatzsteuer.",
+		"bankLabel":          "Bankverbindung:",
+		"phoneLabel":         "Tel.:",
+		"signedByLabel":      "Digital signiert von",
+		"termsLabel":         "Allgemeine Geschäftsbedingungen",
+	},
+	"en": {
+		"title":              "INVOICE",
+		"creditNoteTitle":    "CREDIT NOTE",
+		"quoteTitle":         "QUOTE",
+		"reminderTitle":      "PAYMENT REMINDER",
+		"billToLabel":        "BILL TO",
+		"shipToLabel":        "SHIP TO",
+		"customerNoLabel":    "Customer no.",
+		"vatIdLabel":         "VAT ID",
+		"itemLabel":          "ITEM AND DESCRIPTION",
+		"dateLabel":          "DATE",
+		"qtyLabel":           "QTY",
+		"rateLabel":          "RATE",
+		"amountLabel":        "AMOUNT",
+		"itemCountLabel":     "items",
+		"noItemsLabel":       "No items",
+		"totalQuantityLabel": "total quantity",
+		"notesLabel":         "NOTES",
+		"subtotalLabel":      "Subtotal",
+		"discountLabel":      "Discount",
+		"taxLabel":           "VAT",
+		"taxRateLabel":       "VAT rate",
+		"includedTaxLabel":   "incl. VAT",
+		"settlementLabel":    "equivalent to",
+		"exchangeRateLabel":  "rate",
+		"bindingAmountNote":  "The amount payable is in",
+		"skontoNote":         "early payment discount if paid by",
+		"skontoWithinNote":   "early payment discount if paid within",
+		"skontoDaysLabel":    "days",
+		"skontoAmountLabel":  "Amount with discount",
+		"netLabel":           "Net",
+		"grossLabel":         "Gross",
+		"roundingLabel":      "Rounding",
+		"totalLabel":         "Total",
+		"dueDateLabel":       "Due Date",
+		"servicePeriodLabel": "Service period",
+		"serviceDateLabel":   "Service date",
+		"amountPaidLabel":    "Deposit",
+		"balanceDueLabel":    "Balance Due",
+		"creditLabel":        "Credit",
+		"paidStampLabel":     "PAID on",
+		"taxExemptNote":      "No VAT is charged in accordance with § 19 UStG.",
+		"quoteExemptNote":    "Prices do not include VAT in accordance with § 19 UStG.",
+		"creditExemptNote":   "This credit note includes no VAT in accordance with § 19 UStG.",
+		"bankLabel":          "Bank details:",
+		"phoneLabel":         "Phone:",
+		"signedByLabel":      "Digitally signed by",
+		"termsLabel":         "Terms and Conditions",
+	},
+}
+
+// labelsFor returns the label set for the given language, falling back to German,
+// with any explicit overrides applied on top
+func labelsFor(language string, overrides map[string]string) labels {
+	lang := strings.ToLower(strings.TrimSpace(language))
+	if lang == "" {
+		lang = defaultLanguage
+	}
+	
+	set, ok := translations[lang]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Warning: Unsupported language %q, falling back to %q\n", language, defaultLanguage)
+		set = translations[defaultLanguage]
+	}
+	
+	if len(overrides) == 0 {
+		return set
+	}
+	
+	// Copy so the overrides never leak into the shared translations
+	merged := make(labels, len(set))
+	for key, value := range set {
+		merged[key] = value
+	}
+	for key, value := range overrides {
+		if _, known := translations[defaultLanguage][key]; !known {
+			fmt.Fprintf(os.Stderr, "Warning: Unknown label %q will be ignored\n", key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// documentLabels holds the labels that replace the title and the tax
+// exemption note for each document type other than an invoice
+var documentLabels = map[string]map[string]string{
+	models.DocumentCreditNote: {"title": "creditNoteTitle", "taxExemptNote": "creditExemptNote"},
+	models.DocumentQuote:      {"title": "quoteTitle", "taxExemptNote": "quoteExemptNote"},
+	models.DocumentReminder:   {"title": "reminderTitle"},
+}
+
+// forDocument returns the labels with the title and tax exemption note of
+// the given document type
+func (l labels) forDocument(documentType string) labels {
+	replacements, ok := documentLabels[documentType]
+	if !ok {
+		return l
+	}
+	
+	// Copy so the shared translations stay untouched
+	merged := make(labels, len(l))
+	for key, value := range l {
+		merged[key] = value
+	}
+	for key, replacement := range replacements {
+		merged[key] = l.get(replacement)
+	}
+	return merged
+}
+
+// Label returns a single label for an invoice, honoring its language and overrides
+func Label(invoice *models.Invoice, key string) string {
+	return labelsFor(invoice.Language, invoice.Labels).forDocument(invoice.DocumentType).get(key)
+}
+
+// get returns the label for key, or the German default if the set lacks it
+func (l labels) get(key string) string {
+	if value, ok := l[key]; ok {
+		return value
+	}
+	return translations[defaultLanguage][key]
+}
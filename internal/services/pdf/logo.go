@@ -0,0 +1,110 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	
+	"github.com/signintech/gopdf"
+	"github.com/srwiley/oksvg"
+	"github.com/srwiley/rasterx"
+)
+
+// svgRasterScale renders SVG logos at a multiple of their nominal size, so
+// they stay sharp when the PDF is printed or zoomed
+const svgRasterScale = 4
+
+// loadLogo reads a logo for embedding and returns it with its size in pixels.
+// PNG and JPEG files are embedded as they are, GIF logos are converted and SVG
+// logos rasterized to a PNG first. Any other format is an error.
+func (r *PDFRenderer) loadLogo(path string) (gopdf.ImageHolder, int, int, error) {
+	if strings.EqualFold(filepath.Ext(path), ".svg") {
+		return rasterizeSVG(path)
+	}
+	
+	width, height, format, err := r.getImageDimension(path)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	
+	// gopdf only embeds PNG and JPEG images
+	if format != "png" && format != "jpeg" {
+		return convertToPNG(path)
+	}
+	
+	holder, err := gopdf.ImageHolderByPath(path)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to read logo %s: %v", path, err)
+	}
+	return holder, width, height, nil
+}
+
+// rasterizeSVG renders an SVG file to a PNG image
+func rasterizeSVG(path string) (gopdf.ImageHolder, int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to open logo %s: %v", path, err)
+	}
+	defer file.Close()
+	
+	icon, err := oksvg.ReadIconStream(file)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to parse SVG logo %s: %v", path, err)
+	}
+	
+	width := int(icon.ViewBox.W * svgRasterScale)
+	height := int(icon.ViewBox.H * svgRasterScale)
+	if width <= 0 || height <= 0 {
+		return nil, 0, 0, fmt.Errorf("SVG logo %s has no size, set a viewBox", path)
+	}
+	
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	icon.SetTarget(0, 0, float64(width), float64(height))
+	scanner := rasterx.NewScannerGV(width, height, img, img.Bounds())
+	icon.Draw(rasterx.NewDasher(width, height, scanner), 1)
+	
+	holder, err := pngHolder(img)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to rasterize SVG logo %s: %v", path, err)
+	}
+	return holder, width, height, nil
+}
+
+// convertToPNG decodes a raster image that can't be embedded directly, such
+// as a GIF, and converts it to a PNG image. Animated GIFs use the first frame.
+func convertToPNG(path string) (gopdf.ImageHolder, int, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to open logo %s: %v", path, err)
+	}
+	defer file.Close()
+	
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to decode logo %s: %v", path, err)
+	}
+	
+	bounds := img.Bounds()
+	if bounds.Dx() == 0 || bounds.Dy() == 0 {
+		return nil, 0, 0, fmt.Errorf("image %s has no size", path)
+	}
+	
+	holder, err := pngHolder(img)
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("unable to convert logo %s: %v", path, err)
+	}
+	return holder, bounds.Dx(), bounds.Dy(), nil
+}
+
+// pngHolder encodes an image as PNG for embedding
+func pngHolder(img image.Image) (gopdf.ImageHolder, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return gopdf.ImageHolderByBytes(buf.Bytes())
+}
@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The logos in testdata are 40x20 pixels. The test doesn't import the image
+// packages itself, so it relies on the decoders the renderer registers.
+func TestLoadLogo(t *testing.T) {
+	renderer := newTestRenderer()
+	
+	tests := []struct {
+		file   string
+		format string
+	}{
+		{"logo.png", "png"},
+		{"logo.jpg", "jpeg"},
+		{"logo.gif", "gif"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			path := filepath.Join("testdata", tt.file)
+			
+			width, height, format, err := renderer.getImageDimension(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if width != 40 || height != 20 || format != tt.format {
+				t.Errorf("getImageDimension = %d, %d, %q, want 40, 20, %q", width, height, format, tt.format)
+			}
+			
+			holder, width, height, err := renderer.loadLogo(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if holder == nil || width != 40 || height != 20 {
+				t.Errorf("loadLogo = %v, %d, %d, want an image of 40x20", holder, width, height)
+			}
+			
+			invoice := testInvoice()
+			invoice.Logo = path
+			layout, err := extractLayout(render(t, renderer, invoice))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(layout, "\nimage ") {
+				t.Error("the logo is missing from the PDF")
+			}
+		})
+	}
+}
+
+func TestBrokenLogoRendersTextHeader(t *testing.T) {
+	renderer := newTestRenderer()
+	dir := t.TempDir()
+	corrupt := filepath.Join(dir, "corrupt.png")
+	if err := os.WriteFile(corrupt, []byte("not an image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	tests := []struct {
+		name string
+		logo string
+		size float64
+	}{
+		{"missing file", filepath.Join(dir, "missing.png"), 0},
+		{"corrupt file", corrupt, 0},
+		{"infinite size", filepath.Join("testdata", "logo.png"), math.Inf(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := testInvoice()
+			invoice.Logo = tt.logo
+			invoice.LogoWidth = tt.size
+			invoice.LogoMaxHeight = tt.size
+			
+			layout, err := extractLayout(render(t, renderer, invoice))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(layout, "\nimage ") {
+				t.Error("a broken logo was drawn")
+			}
+			if !strings.Contains(layout, `"Firma GmbH"`) || !strings.Contains(layout, `"RECHNUNG"`) {
+				t.Errorf("the header is missing from the layout:\n%s", layout)
+			}
+		})
+	}
+}
+
+func TestIsDrawableSize(t *testing.T) {
+	tests := []struct {
+		size float64
+		want bool
+	}{
+		{150, true},
+		{0.5, true},
+		{0, false},
+		{-10, false},
+		{math.Inf(1), false},
+		{math.NaN(), false},
+	}
+	for _, tt := range tests {
+		if got := isDrawableSize(tt.size); got != tt.want {
+			t.Errorf("isDrawableSize(%g) = %v, want %v", tt.size, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	
+	"invoice/internal/models"
+)
+
+// pdftoppm is the Poppler tool that rasterizes the PDF, gopdf can only write PDFs
+const pdftoppm = "pdftoppm"
+
+// DefaultPNGDPI is the resolution of PNG previews unless another is set
+const DefaultPNGDPI = 150
+
+// PNGRenderer implements the Renderer interface for a PNG image of the first
+// page, e.g. for thumbnails and chat previews. The invoice is rendered as PDF
+// and rasterized with pdftoppm from Poppler, which must be installed.
+type PNGRenderer struct {
+	pdf *PDFRenderer
+	dpi int
+}
+
+// NewPNGRenderer creates a PNGRenderer rasterizing the output of renderer at
+// dpi dots per inch, or DefaultPNGDPI if dpi is zero or less
+func NewPNGRenderer(renderer *PDFRenderer, dpi int) *PNGRenderer {
+	if dpi <= 0 {
+		dpi = DefaultPNGDPI
+	}
+	return &PNGRenderer{
+		pdf: renderer,
+		dpi: dpi,
+	}
+}
+
+// CheckFonts checks the fonts of the PDF and that pdftoppm is installed
+func (r *PNGRenderer) CheckFonts() error {
+	if err := checkPdftoppm(); err != nil {
+		return err
+	}
+	return r.pdf.CheckFonts()
+}
+
+// Render renders the first page of an invoice as PNG and writes it to the provided writer
+func (r *PNGRenderer) Render(invoice *models.Invoice, w io.Writer) error {
+	if err := checkPdftoppm(); err != nil {
+		return err
+	}
+	
+	var document bytes.Buffer
+	if err := r.pdf.Render(invoice, &document); err != nil {
+		return err
+	}
+	
+	// pdftoppm reads the PDF from stdin and writes <root>.png with -singlefile
+	dir, err := os.MkdirTemp("", "invoice-png-")
+	if err != nil {
+		return fmt.Errorf("unable to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "page")
+	
+	var stderr bytes.Buffer
+	cmd := exec.Command(pdftoppm, "-png", "-r", fmt.Sprint(r.dpi), "-f", "1", "-l", "1", "-singlefile", "-", root)
+	cmd.Stdin = &document
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %v: %s", pdftoppm, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	
+	image, err := os.ReadFile(root + ".png")
+	if err != nil {
+		return fmt.Errorf("unable to read rasterized page: %v", err)
+	}
+	_, err = w.Write(image)
+	return err
+}
+
+// RenderToFile renders the first page of an invoice as PNG and saves it to
+// the provided file path. Nothing is written if rasterizing fails.
+func (r *PNGRenderer) RenderToFile(invoice *models.Invoice, filePath string) error {
+	var image bytes.Buffer
+	if err := r.Render(invoice, &image); err != nil {
+		return err
+	}
+	return os.WriteFile(filePath, image.Bytes(), 0644)
+}
+
+// checkPdftoppm reports a missing pdftoppm with a hint how to install it
+func checkPdftoppm() error {
+	if _, err := exec.LookPath(pdftoppm); err != nil {
+		return fmt.Errorf("%s not found, install Poppler (e.g. poppler-utils) for PNG output", pdftoppm)
+	}
+	return nil
+}
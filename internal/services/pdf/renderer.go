@@ -1,12 +1,17 @@
 package pdf
 
 import (
+	"bytes"
 	"fmt"
 	"image"
+	_ "image/gif" // Register the decoders for logos
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
+	"math"
 	"os"
-	"strconv"
 	"strings"
+	"unicode/utf8"
 	
 	"invoice/internal/models"
 	"invoice/internal/services/currency"
@@ -16,25 +21,50 @@ import (
 
 // Constants for PDF layout
 const (
-	quantityColumnOffset = 390
-	rateColumnOffset     = 450
-	amountColumnOffset   = 510
+	// Widths of the item table's columns, laid out from the right page margin.
+	// The description takes the remaining width.
+	quantityColumnWidth = 60.0
+	rateColumnWidth     = 60.0
+	amountColumnWidth   = 45.0
+	dateColumnWidth     = 70.0
+	
+	// totalsLabelX is where the labels of the totals block start, their values
+	// are right-aligned against the right page margin
+	totalsLabelX = 350
+	
+	// totalNoteLineHeight is the line height of notes that wrap in the totals block
+	totalNoteLineHeight = 11
+	
+	// shipToX is where the delivery address starts, next to the recipient
+	shipToX = 300
+	
+	// averageGlyphWidth is a conservative average advance of a glyph as a fraction
+	// of the font size, used when the real text width cannot be measured
+	averageGlyphWidth = 0.6
+	
+	// Default logo size, used unless the invoice sets its own
+	defaultLogoWidth     = 150.0
+	defaultLogoMaxHeight = 100.0
+	
+	// senderRuleMinWidth is the shortest rule below the sender block, which
+	// is longer for a wider sender
+	senderRuleMinWidth = 220.0
+	
+	// footerMarginBottom is the space below the footer's last line; the footer
+	// sits this far above the bottom of the page, however many lines it has
+	footerMarginBottom = 20.0
 )
 
-// German label translations
+// Position of the visible signature on the last page, right above the footer.
+// ReserveSignatureSpace keeps this area free of content. The Y position
+// depends on the footer's height, see SignatureY.
 const (
-	invoiceTitle   = "RECHNUNG"
-	billToLabel    = "RECHNUNG AN"
-	itemLabel      = "ARTIKEL UND BESCHREIBUNG"
-	qtyLabel       = "MENGE"
-	rateLabel      = "PREIS"
-	amountLabel    = "BETRAG"
-	notesLabel     = "HINWEISE"
-	subtotalLabel  = "Zwischensumme"
-	discountLabel  = "Rabatt"
-	taxLabel       = "MwSt."
-	totalLabel     = "Gesamt"
-	dueDateLabel   = "Fälligkeitsdatum"
+	SignatureX      = 350.0
+	SignatureWidth  = 205.0
+	SignatureHeight = 38.0
+	
+	// signatureGap separates the signature from the rule above the footer
+	signatureGap = 7.0
 )
 
 // Font paths for Inter fonts
@@ -43,15 +73,45 @@ const (
 	InterBoldFont    = "Inter/Inter Hinted for Windows/Desktop/Inter-Bold.ttf"
 )
 
-// Renderer defines the interface for invoice rendering
+// Generic family names the regular and bold faces are registered under,
+// independent of which font files are actually loaded
+const (
+	fontRegular = "Regular"
+	fontBold    = "Bold"
+)
+
+// Renderer defines the interface for invoice rendering. PDFRenderer,
+// HTMLRenderer and PNGRenderer implement it for the output formats.
 type Renderer interface {
 	Render(invoice *models.Invoice, w io.Writer) error
 	RenderToFile(invoice *models.Invoice, filePath string) error
+	CheckFonts() error
 }
 
 // PDFRenderer implements the Renderer interface for PDF output
 type PDFRenderer struct {
 	currencyService currency.Service
+	
+	// Font sources - an explicit path wins over embedded data,
+	// and the Inter files on disk are used when neither is set
+	regularFontPath string
+	boldFontPath    string
+	regularFontData []byte
+	boldFontData    []byte
+	
+	// Keep the signature area above the footer free on the last page
+	reserveSignature bool
+	
+	// Write no document info at all, see SetDeterministic
+	deterministic bool
+	
+	// footerTop is the Y position of the rule above the footer, content must
+	// end above it. It is set for each invoice by layoutPDF.
+	footerTop float64
+	
+	// missingGlyphs collects the characters the fonts can't display while
+	// buildPDF lays out an invoice
+	missingGlyphs *[]rune
 }
 
 // NewPDFRenderer creates a new PDFRenderer instance
@@ -61,116 +121,421 @@ func NewPDFRenderer(currencyService currency.Service) *PDFRenderer {
 	}
 }
 
-// Render renders an invoice as PDF and writes it to the provided writer
-func (r *PDFRenderer) Render(invoice *models.Invoice, w io.Writer) error {
+// ReserveSignatureSpace makes sure the content on the last page ends above
+// the signature area, so a visible signature can be added afterwards
+func (r *PDFRenderer) ReserveSignatureSpace() {
+	r.reserveSignature = true
+}
+
+// SetDeterministic makes sure identical invoices render to identical bytes.
+// The document info is left out entirely, so no timestamp can get into the
+// file. gopdf writes no document ID, so nothing else varies.
+func (r *PDFRenderer) SetDeterministic(deterministic bool) {
+	r.deterministic = deterministic
+}
+
+// SetFontData uses the given TrueType data (e.g. fonts embedded in the binary)
+// instead of reading the Inter fonts from disk
+func (r *PDFRenderer) SetFontData(regular, bold []byte) {
+	r.regularFontData = regular
+	r.boldFontData = bold
+}
+
+// SetFontPaths overrides the font files loaded from disk. Empty paths are ignored.
+func (r *PDFRenderer) SetFontPaths(regular, bold string) {
+	r.regularFontPath = regular
+	r.boldFontPath = bold
+}
+
+// SignatureY returns the Y position of the visible signature on the last
+// page of an invoice, right above its footer
+func (r *PDFRenderer) SignatureY(invoice *models.Invoice) (float64, error) {
 	pdf := r.createPDF()
+	if err := r.setupFonts(pdf, invoice); err != nil {
+		return 0, err
+	}
+	
+	l := labelsFor(invoice.Language, invoice.Labels).forDocument(invoice.DocumentType)
+	return r.footerTopFor(pdf, invoice, l) - SignatureHeight - signatureGap, nil
+}
+
+// SignaturePage returns the page of the visible signature, the last page of
+// the invoice itself. It is 0, the last page of the document, unless PDFs are
+// appended after the invoice.
+func (r *PDFRenderer) SignaturePage(invoice *models.Invoice) (int, error) {
+	if len(invoice.AppendPDFs) == 0 {
+		return 0, nil
+	}
 	
-	if err := r.setupFonts(pdf); err != nil {
+	layout := *r
+	pdf, err := layout.layoutPDF(invoice, 1)
+	if err != nil {
+		return 0, err
+	}
+	return pdf.GetNumberOfPages(), nil
+}
+
+// CheckFonts loads the configured fonts into an empty document, so missing or
+// broken font files are noticed without rendering an invoice
+func (r *PDFRenderer) CheckFonts() error {
+	return r.setupFonts(r.createPDF(), &models.Invoice{})
+}
+
+// Render renders an invoice as PDF and writes it to the provided writer
+func (r *PDFRenderer) Render(invoice *models.Invoice, w io.Writer) error {
+	pdf, err := r.buildPDF(invoice)
+	if err != nil {
 		return err
 	}
 	
-	// Combine ID and IdSuffix for the full invoice number
-	fullInvoiceId := invoice.Id
-	if invoice.IdSuffix != "" {
-		fullInvoiceId = invoice.Id + invoice.IdSuffix
+	// Write the PDF bytes to the provided writer
+	return pdf.Write(w)
+}
+
+// RenderToFile renders an invoice as PDF and saves it to the provided file path
+func (r *PDFRenderer) RenderToFile(invoice *models.Invoice, filePath string) error {
+	pdf, err := r.buildPDF(invoice)
+	if err != nil {
+		return err
 	}
 	
-	// Generate the content
-	r.writeLogo(pdf, invoice.Logo, invoice.From)
-	r.writeTitle(pdf, invoice.Title, fullInvoiceId, invoice.Date)
-	r.writeBillTo(pdf, invoice.To)
-	r.writeHeaderRow(pdf)
+	// Write the PDF to the file
+	return pdf.WritePdf(filePath)
+}
+
+// documentInfo returns the document info dictionary of an invoice, its title
+// and creator. It has no creation date, which would make every run give a
+// different file.
+func documentInfo(invoice *models.Invoice, title string) gopdf.PdfInfo {
+	return gopdf.PdfInfo{
+		Title:   strings.TrimSpace(title + " " + invoice.Id + invoice.IdSuffix),
+		Creator: "invoice",
+	}
+}
+
+// buildPDF lays out the complete invoice document. The page count is only
+// known after layout, so invoices that spill onto more pages are laid out
+// a second time to print the correct total in the page numbers. Appended
+// PDFs follow the numbered invoice pages.
+func (r *PDFRenderer) buildPDF(invoice *models.Invoice) (*gopdf.GoPdf, error) {
+	// The layout keeps the invoice's footer position, so it works on a copy
+	// of the renderer, which may be rendering other invoices at the same time
+	layout := *r
+	layout.missingGlyphs = &[]rune{}
+	
+	pdf, err := layout.layoutPDF(invoice, 1)
+	if err != nil {
+		return nil, err
+	}
 	
-	subtotal := 0.0
-	if len(invoice.Items) > 0 {
-		for i := range invoice.Items {
-			q := 1
-			if len(invoice.Quantities) > i {
-				q = invoice.Quantities[i]
-			}
-			
-			rate := 0.0
-			if len(invoice.Rates) > i {
-				rate = invoice.Rates[i]
-			}
-			
-			r.writeRow(pdf, invoice.Items[i], q, rate, invoice.Currency)
-			subtotal += float64(q) * rate
+	if pages := pdf.GetNumberOfPages(); pages > 1 {
+		pdf, err = layout.layoutPDF(invoice, pages)
+		if err != nil {
+			return nil, err
 		}
 	}
 	
-	// Write notes first before totals
-	if invoice.Note != "" {
-		r.writeNotes(pdf, invoice.Note)
+	if len(invoice.AppendPDFs) > 0 {
+		if err := appendPDFs(pdf, invoice.AppendPDFs); err != nil {
+			return nil, err
+		}
 	}
 	
-	// Then write totals (will be positioned on the right side)
-	r.writeTotals(pdf, subtotal, subtotal*invoice.Tax, subtotal*invoice.Discount, invoice.TaxExempt, invoice.Currency)
+	warnMissingGlyphs(invoice, *layout.missingGlyphs)
+	
+	return pdf, nil
+}
+
+// serviceDateKeywords are phrases that show a note already states the service date
+var serviceDateKeywords = []string{"leistungszeitraum", "leistungsdatum", "lieferdatum", "service period", "service date", "delivery date"}
+
+// Warnings returns what an invoice may be missing for its document type and
+// language, for the caller to show. Rendering doesn't depend on them.
+func Warnings(invoice *models.Invoice) []string {
+	var warnings []string
+	if missingServiceDate(invoice) {
+		warnings = append(warnings, fmt.Sprintf("Invoice %s has no service date (Leistungsdatum/Leistungszeitraum), which is required by §14 UStG", invoice.Id))
+	}
+	return warnings
+}
+
+// missingServiceDate reports whether a German invoice states no service or
+// delivery date, which §14 UStG requires. Other languages and documents such
+// as quotes aren't checked.
+func missingServiceDate(invoice *models.Invoice) bool {
+	language := strings.ToLower(strings.TrimSpace(invoice.Language))
+	if language != "" && language != defaultLanguage {
+		return false
+	}
+	if invoice.DocumentType != "" && invoice.DocumentType != models.DocumentInvoice {
+		return false
+	}
+	if invoice.ServiceDateFrom != "" || invoice.ServiceDateTo != "" {
+		return false
+	}
 	
-	if invoice.Due != "" {
-		r.writeDueDate(pdf, invoice.Due)
+	note := strings.ToLower(invoice.Note)
+	for _, keyword := range serviceDateKeywords {
+		if strings.Contains(note, keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// servicePeriod formats the service date line, or returns "" if none is set
+func servicePeriod(invoice *models.Invoice, l labels) string {
+	from, to := invoice.ServiceDateFrom, invoice.ServiceDateTo
+	
+	switch {
+	case from != "" && to != "" && from != to:
+		return l.get("servicePeriodLabel") + ": " + from + " – " + to
+	case from != "":
+		return l.get("serviceDateLabel") + ": " + from
+	case to != "":
+		return l.get("serviceDateLabel") + ": " + to
+	}
+	return ""
+}
+
+// recordMissingGlyph notes a character the fonts have no glyph for, which
+// gopdf prints as a space
+func (r *PDFRenderer) recordMissingGlyph(c rune) {
+	if r.missingGlyphs == nil || strings.ContainsRune(string(*r.missingGlyphs), c) {
+		return
+	}
+	*r.missingGlyphs = append(*r.missingGlyphs, c)
+}
+
+// warnMissingGlyphs warns about characters the fonts can't display, such as
+// Cyrillic or Chinese names with the default Inter fonts, naming the texts
+// that contain them
+func warnMissingGlyphs(invoice *models.Invoice, missing []rune) {
+	if len(missing) == 0 {
+		return
 	}
 	
-	r.writeFooter(pdf, fullInvoiceId, invoice.Footer)
+	var affected []string
+	texts := append([]string{invoice.Title, invoice.From, invoice.To, invoice.ShipTo, invoice.Note}, invoice.Items...)
+	for _, text := range texts {
+		if strings.ContainsAny(text, string(missing)) {
+			affected = append(affected, fmt.Sprintf("%q", text))
+		}
+	}
+	where := "the invoice"
+	if len(affected) > 0 {
+		where = strings.Join(affected, ", ")
+	}
 	
-	// Write the PDF bytes to the provided writer
-	return pdf.Write(w)
+	fmt.Fprintf(os.Stderr, "Warning: Invoice %s: the font has no glyphs for %q, they are left blank in %s. Set fontRegularPath and fontBoldPath (--font, --font-bold) to a font that covers them.\n",
+		invoice.Id, string(missing), where)
 }
 
-// RenderToFile renders an invoice as PDF and saves it to the provided file path
-func (r *PDFRenderer) RenderToFile(invoice *models.Invoice, filePath string) error {
+// layoutPDF writes the invoice content, numbering pages out of totalPages
+func (r *PDFRenderer) layoutPDF(invoice *models.Invoice, totalPages int) (*gopdf.GoPdf, error) {
 	pdf := r.createPDF()
 	
-	if err := r.setupFonts(pdf); err != nil {
-		return err
+	if err := r.setupFonts(pdf, invoice); err != nil {
+		return nil, err
 	}
 	
+	terms, err := termsText(invoice)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Resolve the labels for the invoice language and document type, explicit overrides win
+	l := labelsFor(invoice.Language, invoice.Labels).forDocument(invoice.DocumentType)
+	
 	// Combine ID and IdSuffix for the full invoice number
 	fullInvoiceId := invoice.Id
 	if invoice.IdSuffix != "" {
 		fullInvoiceId = invoice.Id + invoice.IdSuffix
 	}
 	
+	// Content starts below the letterhead's header zone on every page
+	pdf.SetMarginTop(pdf.MarginTop() + invoice.BackgroundMarginTop)
+	letterhead := r.loadBackground(invoice)
+	
+	// Every page, including those started by ensureSpace, carries the
+	// background, the page number and the footer, which the content flows around
+	r.footerTop = r.footerTopFor(pdf, invoice, l)
+	pdf.AddHeader(func() {
+		r.writeBackground(pdf, letterhead)
+		r.writePageNumber(pdf, fullInvoiceId, pdf.GetNumberOfPages(), totalPages)
+	})
+	pdf.AddFooter(func() {
+		r.writeFooter(pdf, invoice.Footer, l)
+	})
+	pdf.AddPage()
+	
+	// An explicit title wins over the localized default
+	title := invoice.Title
+	if title == "" {
+		title = l.get("title")
+	}
+	if !r.deterministic {
+		pdf.SetInfo(documentInfo(invoice, title))
+	}
+	
+	d := densityFor(invoice.Density)
+	
 	// Generate the content
-	r.writeLogo(pdf, invoice.Logo, invoice.From)
-	r.writeTitle(pdf, invoice.Title, fullInvoiceId, invoice.Date)
-	r.writeBillTo(pdf, invoice.To)
-	r.writeHeaderRow(pdf)
+	r.writeLogo(pdf, invoice)
+	r.writeTitle(pdf, title, fullInvoiceId, invoice.Date, customerNumber(invoice, l), servicePeriod(invoice, l), d)
+	r.writeBillTo(pdf, invoice, l, d)
+	
+	// A prominent note above the item table
+	if invoice.Note != "" && invoice.NotePosition == models.NoteBeforeItems {
+		r.writeNotes(pdf, invoice.Note, l, d)
+		pdf.Br(d.gap(15))
+	}
 	
-	subtotal := 0.0
+	// The date column only appears when items have dates
+	columns := newTableColumns(pdf, len(invoice.ItemDates) > 0)
+	ruled, striped := tableStyle(invoice)
 	if len(invoice.Items) > 0 {
-		for i := range invoice.Items {
-			q := 1
-			if len(invoice.Quantities) > i {
-				q = invoice.Quantities[i]
-			}
-			
-			rate := 0.0
-			if len(invoice.Rates) > i {
-				rate = invoice.Rates[i]
-			}
-			
-			r.writeRow(pdf, invoice.Items[i], q, rate, invoice.Currency)
-			subtotal += float64(q) * rate
+		r.writeHeaderRow(pdf, columns, l, ruled, d)
+	} else {
+		r.writeNoItems(pdf, l, d)
+	}
+	
+	money := newAmountFormatter(r.currencyService, invoice)
+	
+	// The same calculation as CalculateTotal, so the PDF and the API agree
+	computed := models.ComputeInvoice(invoice)
+	sections := computed.Sections
+	
+	for i := range invoice.Items {
+		q := 1
+		if len(invoice.Quantities) > i {
+			q = invoice.Quantities[i]
+		}
+		
+		rate := 0.0
+		if len(invoice.Rates) > i {
+			rate = invoice.Rates[i] * invoice.AmountSign()
+		}
+		
+		date := ""
+		if len(invoice.ItemDates) > i {
+			date = invoice.ItemDates[i]
+		}
+		
+		if invoice.StartsSection(i) {
+			r.writeSectionHeader(pdf, invoice.ItemSection(i), d)
+		}
+		
+		quantity := formatItemQuantity(q, invoice.QuantityDecimals, invoice.ItemUnit(i))
+		
+		// Every other row is striped, starting with the second
+		r.writeRow(pdf, columns, invoice.Items[i], date, quantity, rate, float64(q)*rate, money, striped && i%2 == 1, d)
+		
+		// Sections end with their subtotal, in the order ComputeInvoice lists them
+		if invoice.EndsSection(i) {
+			r.writeSectionTotal(pdf, columns, sectionTotalLabel(sections[0].Name, l), money.format(sections[0].Subtotal), d)
+			sections = sections[1:]
 		}
 	}
 	
-	// Write notes first before totals
-	if invoice.Note != "" {
-		r.writeNotes(pdf, invoice.Note)
+	if summary := itemSummary(invoice, l); summary != "" {
+		r.writeItemSummary(pdf, summary, d)
+	}
+	
+	totals := totalLines(invoice, computed, money, newSettlementFormatter(r.currencyService, invoice), l)
+	
+	// Write notes first before totals, unless placed elsewhere
+	if invoice.Note != "" && (invoice.NotePosition == "" || invoice.NotePosition == models.NoteAfterItems) {
+		r.writeNotes(pdf, invoice.Note, l, d)
+	}
+	
+	// Keep the totals and due date together below the notes, on a new page if needed
+	totalsHeight := r.totalsHeight(pdf, totals, d)
+	if invoice.ShowsDueDate() {
+		totalsHeight += 12
 	}
+	r.ensureSpace(pdf, totalsHeight)
 	
 	// Then write totals (will be positioned on the right side)
-	r.writeTotals(pdf, subtotal, subtotal*invoice.Tax, subtotal*invoice.Discount, invoice.TaxExempt, invoice.Currency)
+	r.writeTotals(pdf, invoice.PaidDate, totals, l, d)
 	
-	if invoice.Due != "" {
-		r.writeDueDate(pdf, invoice.Due)
+	if invoice.ShowsDueDate() {
+		r.writeDueDate(pdf, invoice.Due, l)
 	}
 	
-	r.writeFooter(pdf, fullInvoiceId, invoice.Footer)
+	// Items taxed at different rates are summed up per rate
+	if rows := taxSummaryRows(computed, money, l); rows != nil {
+		r.writeTaxSummary(pdf, rows, d)
+	}
 	
-	// Write the PDF to the file
-	return pdf.WritePdf(filePath)
+	// Notes below the totals, such as payment terms, break onto a new page
+	// line by line instead of running into the footer
+	if invoice.Note != "" && invoice.NotePosition == models.NoteAfterTotals {
+		r.writeNotes(pdf, invoice.Note, l, d)
+	}
+	
+	// The terms follow on pages of their own
+	if terms != "" {
+		r.writeTerms(pdf, terms, l)
+	}
+	
+	// Continue on a new page if the content reaches into the signature area
+	if r.reserveSignature {
+		r.ensureSpace(pdf, SignatureHeight+signatureGap)
+	}
+	
+	return pdf, nil
+}
+
+// termsText returns the invoice's terms and conditions, read from TermsFile
+// unless they are given inline
+func termsText(invoice *models.Invoice) (string, error) {
+	if invoice.Terms != "" || invoice.TermsFile == "" {
+		return strings.TrimSpace(invoice.Terms), nil
+	}
+	
+	data, err := os.ReadFile(invoice.TermsFile)
+	if err != nil {
+		return "", fmt.Errorf("unable to read terms file: %v", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// writeTerms starts a new page with the terms and conditions, which continue
+// on as many pages as they need
+func (r *PDFRenderer) writeTerms(pdf *gopdf.GoPdf, terms string, l labels) {
+	lineHeight := 12.0
+	
+	pdf.AddPage()
+	pdf.SetX(pdf.MarginLeft())
+	pdf.SetY(pdf.MarginTop() + 20)
+	
+	_ = pdf.SetFont(fontBold, "", 12)
+	pdf.SetTextColor(0, 0, 0)
+	_ = pdf.Cell(nil, l.get("termsLabel"))
+	pdf.Br(24)
+	
+	width := gopdf.PageSizeA4.W - pdf.MarginLeft() - pdf.MarginRight()
+	terms = strings.ReplaceAll(terms, "\r\n", "\n")
+	
+	// wrapText drops empty lines, so paragraphs are wrapped one by one
+	for i, paragraph := range strings.Split(terms, "\n\n") {
+		if i > 0 {
+			pdf.Br(lineHeight / 2)
+		}
+		
+		_ = pdf.SetFont(fontRegular, "", 9)
+		for _, line := range r.wrapText(pdf, paragraph, width, 9) {
+			r.ensureSpace(pdf, lineHeight)
+			
+			// Set per line as a new page's header changes the font
+			_ = pdf.SetFont(fontRegular, "", 9)
+			pdf.SetTextColor(0, 0, 0)
+			pdf.SetX(pdf.MarginLeft())
+			_ = pdf.Cell(nil, line)
+			pdf.Br(lineHeight)
+		}
+	}
 }
 
 // createPDF initializes a new GoPdf instance with correct page setup
@@ -180,22 +545,83 @@ func (r *PDFRenderer) createPDF() *gopdf.GoPdf {
 		PageSize: *gopdf.PageSizeA4,
 	})
 	pdf.SetMargins(40, 40, 40, 40)
-	pdf.AddPage()
 	return pdf
 }
 
-// setupFonts loads the required fonts for the PDF
-func (r *PDFRenderer) setupFonts(pdf *gopdf.GoPdf) error {
-	// Check if font files exist before attempting to load them
-	if _, err := os.Stat(InterRegularFont); os.IsNotExist(err) {
-		return fmt.Errorf("Error: The Inter fonts are missing. Please download and restore the Inter font files.\n"+
-			"You can download them from: https://github.com/rsms/inter\n"+
-			"Directories needed:\n"+
-			"- %s\n"+
-			"- %s", InterRegularFont, InterBoldFont)
+// ensureSpace starts a new page if a block of the given height would run into the footer
+func (r *PDFRenderer) ensureSpace(pdf *gopdf.GoPdf, height float64) {
+	if pdf.GetY()+height <= r.footerTop {
+		return
+	}
+	
+	pdf.AddPage()
+	pdf.SetX(pdf.MarginLeft())
+	pdf.SetY(pdf.MarginTop())
+}
+
+// FontError reports a font that is missing or can't be loaded, as opposed to
+// a problem with the invoice itself
+type FontError struct {
+	Err error
+}
+
+// Error returns the message of the underlying error
+func (e *FontError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *FontError) Unwrap() error {
+	return e.Err
+}
+
+// setupFonts loads the required fonts for the PDF. Font paths set on the
+// invoice take precedence over the renderer-wide settings. Failures are
+// returned as a FontError.
+func (r *PDFRenderer) setupFonts(pdf *gopdf.GoPdf, invoice *models.Invoice) error {
+	regularPath := r.regularFontPath
+	if invoice.FontRegularPath != "" {
+		regularPath = invoice.FontRegularPath
+	}
+	
+	boldPath := r.boldFontPath
+	if invoice.FontBoldPath != "" {
+		boldPath = invoice.FontBoldPath
+	}
+	
+	if err := r.addFont(pdf, fontRegular, regularPath, r.regularFontData, InterRegularFont); err != nil {
+		return &FontError{Err: err}
+	}
+	if err := r.addFont(pdf, fontBold, boldPath, r.boldFontData, InterBoldFont); err != nil {
+		return &FontError{Err: err}
+	}
+	return nil
+}
+
+// addFont registers a single font family from an override path, embedded data,
+// or the default location on disk, in that order of preference
+func (r *PDFRenderer) addFont(pdf *gopdf.GoPdf, family, overridePath string, data []byte, defaultPath string) error {
+	// Characters the font can't display are collected for a warning
+	option := gopdf.TtfOption{OnGlyphNotFound: r.recordMissingGlyph}
+	
+	// Embedded data is used unless the user explicitly asked for a file
+	if overridePath == "" && len(data) > 0 {
+		if err := pdf.AddTTFFontByReaderWithOption(family, bytes.NewReader(data), option); err != nil {
+			return fmt.Errorf("failed to load embedded %s font: %v", strings.ToLower(family), err)
+		}
+		return nil
+	}
+	
+	path := overridePath
+	if path == "" {
+		path = defaultPath
 	}
 	
-	if _, err := os.Stat(InterBoldFont); os.IsNotExist(err) {
+	// Check if the font file exists before attempting to load it
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if overridePath != "" {
+			return fmt.Errorf("%s font file not found: %s", strings.ToLower(family), overridePath)
+		}
 		return fmt.Errorf("Error: The Inter fonts are missing. Please download and restore the Inter font files.\n"+
 			"You can download them from: https://github.com/rsms/inter\n"+
 			"Directories needed:\n"+
@@ -203,77 +629,131 @@ func (r *PDFRenderer) setupFonts(pdf *gopdf.GoPdf) error {
 			"- %s", InterRegularFont, InterBoldFont)
 	}
 	
-	// Load the Inter font from file
-	err := pdf.AddTTFFont("Inter", InterRegularFont)
-	if err != nil {
-		return fmt.Errorf("failed to load Inter font: %v", err)
-	}
-	
-	// Load the Inter-Bold font from file
-	err = pdf.AddTTFFont("Inter-Bold", InterBoldFont)
-	if err != nil {
-		return fmt.Errorf("failed to load Inter-Bold font: %v", err)
+	if err := pdf.AddTTFFontWithOption(family, path, option); err != nil {
+		return fmt.Errorf("failed to load %s font %s: %v", strings.ToLower(family), path, err)
 	}
 	
 	return nil
 }
 
 // writeLogo adds the company logo and name to the PDF
-func (r *PDFRenderer) writeLogo(pdf *gopdf.GoPdf, logo string, from string) {
-	if logo != "" {
-		width, height := r.getImageDimension(logo)
-		
-		// Increase the logo size
-		scaledWidth := 150.0  // Increased from 100.0
-		scaledHeight := float64(height) * scaledWidth / float64(width)
-		
-		// Set a reasonable maximum height while allowing larger logos
-		maxHeight := 100.0  // Increased from 60.0
-		
-		// If logo is too tall, rescale it to the maximum height
-		if scaledHeight > maxHeight {
-			scaledHeight = maxHeight
-			scaledWidth = float64(width) * maxHeight / float64(height)
-		}
-		
-		err := pdf.Image(logo, pdf.GetX(), pdf.GetY(), &gopdf.Rect{W: scaledWidth, H: scaledHeight})
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: Unable to add logo to PDF: %v\n", err)
-		} else {
-			pdf.Br(scaledHeight + 10) // Space after logo
-		}
+func (r *PDFRenderer) writeLogo(pdf *gopdf.GoPdf, invoice *models.Invoice) {
+	if invoice.Logo != "" {
+		r.writeLogoImage(pdf, invoice)
 	}
 	
 	pdf.SetTextColor(55, 55, 55)
 	
-	formattedFrom := strings.ReplaceAll(from, `\n`, "\n")
-	fromLines := strings.Split(formattedFrom, "\n")
+	fromLines := invoice.SenderLines()
+	senderWidth := 0.0
 	
 	for i := 0; i < len(fromLines); i++ {
 		if i == 0 {
-			_ = pdf.SetFont("Inter", "", 12)
-			_ = pdf.Cell(nil, fromLines[i])
+			_ = pdf.SetFont(fontRegular, "", 12)
+		} else {
+			_ = pdf.SetFont(fontRegular, "", 10)
+		}
+		if width, err := pdf.MeasureTextWidth(fromLines[i]); err == nil && width > senderWidth {
+			senderWidth = width
+		}
+		_ = pdf.Cell(nil, fromLines[i])
+		if i == 0 {
 			pdf.Br(14)
 		} else {
-			_ = pdf.SetFont("Inter", "", 10)
-			_ = pdf.Cell(nil, fromLines[i])
 			pdf.Br(12)
 		}
 	}
 	
 	pdf.Br(15)
-	pdf.SetStrokeColor(225, 225, 225)
-	pdf.Line(pdf.GetX(), pdf.GetY(), 260, pdf.GetY())
+	if invoice.ShowSenderRule {
+		pdf.SetStrokeColor(225, 225, 225)
+		pdf.Line(pdf.GetX(), pdf.GetY(), senderRuleEnd(pdf, invoice, senderWidth), pdf.GetY())
+	}
 	pdf.Br(20)
 }
 
-// writeTitle adds the invoice title and ID to the PDF
-func (r *PDFRenderer) writeTitle(pdf *gopdf.GoPdf, title, id, date string) {
-	_ = pdf.SetFont("Inter-Bold", "", 22)  // Slightly smaller font
+// senderRuleEnd returns the X position the rule below the sender ends at. It
+// spans the content width below a centered or right-aligned logo, and
+// otherwise the sender block, at least senderRuleMinWidth.
+func senderRuleEnd(pdf *gopdf.GoPdf, invoice *models.Invoice, senderWidth float64) float64 {
+	right := gopdf.PageSizeA4.W - pdf.MarginRight()
+	if invoice.Logo != "" && (invoice.LogoAlign == models.LogoAlignCenter || invoice.LogoAlign == models.LogoAlignRight) {
+		return right
+	}
+	
+	return math.Min(pdf.GetX()+math.Max(senderWidth, senderRuleMinWidth), right)
+}
+
+// writeLogoImage adds the logo, scaled and aligned as configured on the invoice.
+// A logo that can't be loaded is left out with a warning.
+func (r *PDFRenderer) writeLogoImage(pdf *gopdf.GoPdf, invoice *models.Invoice) {
+	holder, width, height, err := r.loadLogo(invoice.Logo)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add logo to PDF: %v\n", err)
+		return
+	}
+	
+	// The scaling below divides by both, so an empty image is never drawn
+	if width <= 0 || height <= 0 {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add logo to PDF: %s has no size\n", invoice.Logo)
+		return
+	}
+	
+	scaledWidth := defaultLogoWidth
+	if invoice.LogoWidth > 0 {
+		scaledWidth = invoice.LogoWidth
+	}
+	scaledHeight := float64(height) * scaledWidth / float64(width)
+	
+	maxHeight := defaultLogoMaxHeight
+	if invoice.LogoMaxHeight > 0 {
+		maxHeight = invoice.LogoMaxHeight
+	}
+	
+	// If logo is too tall, rescale it to the maximum height
+	if scaledHeight > maxHeight {
+		scaledHeight = maxHeight
+		scaledWidth = float64(width) * maxHeight / float64(height)
+	}
+	
+	// Sizes such as .inf in a YAML config would otherwise reach the PDF
+	if !isDrawableSize(scaledWidth) || !isDrawableSize(scaledHeight) {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add logo to PDF: invalid logo size %gx%g\n", scaledWidth, scaledHeight)
+		return
+	}
+	
+	x := pdf.GetX()
+	switch invoice.LogoAlign {
+	case models.LogoAlignCenter:
+		x = (gopdf.PageSizeA4.W - scaledWidth) / 2
+	case models.LogoAlignRight:
+		x = gopdf.PageSizeA4.W - pdf.MarginRight() - scaledWidth
+	case "", models.LogoAlignLeft:
+	default:
+		fmt.Fprintf(os.Stderr, "Warning: Unknown logo alignment %q, using %q\n", invoice.LogoAlign, models.LogoAlignLeft)
+	}
+	
+	err = pdf.ImageByHolder(holder, x, pdf.GetY(), &gopdf.Rect{W: scaledWidth, H: scaledHeight})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Unable to add logo to PDF: %v\n", err)
+	} else {
+		pdf.Br(scaledHeight + 10) // Space after logo
+	}
+}
+
+// isDrawableSize reports whether a size is a positive, finite number
+func isDrawableSize(size float64) bool {
+	return size > 0 && !math.IsInf(size, 0) && !math.IsNaN(size)
+}
+
+// writeTitle adds the invoice title and ID to the PDF, followed by the date
+// and customer number
+func (r *PDFRenderer) writeTitle(pdf *gopdf.GoPdf, title, id, date, customerNumber, servicePeriod string, d density) {
+	_ = pdf.SetFont(fontBold, "", 22)  // Slightly smaller font
 	pdf.SetTextColor(0, 0, 0)
 	_ = pdf.Cell(nil, title)
-	pdf.Br(24) // Reduced space
-	_ = pdf.SetFont("Inter", "", 11) // Slightly smaller font
+	pdf.Br(d.gap(24)) // Reduced space
+	_ = pdf.SetFont(fontRegular, "", 11) // Slightly smaller font
 	pdf.SetTextColor(100, 100, 100)
 	_ = pdf.Cell(nil, "#")
 	_ = pdf.Cell(nil, id)
@@ -281,378 +761,530 @@ func (r *PDFRenderer) writeTitle(pdf *gopdf.GoPdf, title, id, date string) {
 	_ = pdf.Cell(nil, "  ·  ")
 	pdf.SetTextColor(100, 100, 100)
 	_ = pdf.Cell(nil, date)
-	pdf.Br(32) // Reduced space
+	if customerNumber != "" {
+		pdf.SetTextColor(150, 150, 150)
+		_ = pdf.Cell(nil, "  ·  ")
+		pdf.SetTextColor(100, 100, 100)
+		_ = pdf.Cell(nil, customerNumber)
+	}
+	
+	// Service date or period directly below the invoice date
+	if servicePeriod != "" {
+		pdf.Br(14)
+		_ = pdf.SetFont(fontRegular, "", 9)
+		_ = pdf.Cell(nil, servicePeriod)
+		pdf.Br(d.gap(18))
+	} else {
+		pdf.Br(d.gap(32)) // Reduced space
+	}
 }
 
 // writeDueDate adds the payment due date to the PDF
-func (r *PDFRenderer) writeDueDate(pdf *gopdf.GoPdf, due string) {
-	_ = pdf.SetFont("Inter", "", 9)
+func (r *PDFRenderer) writeDueDate(pdf *gopdf.GoPdf, due string, l labels) {
+	_ = pdf.SetFont(fontRegular, "", 9)
 	pdf.SetTextColor(75, 75, 75)
-	pdf.SetX(350) // Fixed position for label
-	_ = pdf.Cell(nil, dueDateLabel)
+	pdf.SetX(totalsLabelX)
+	_ = pdf.Cell(nil, l.get("dueDateLabel"))
 	pdf.SetTextColor(0, 0, 0)
 	_ = pdf.SetFontSize(11)
-	pdf.SetX(470) // Fixed position for value
+	// Flush with the totals above
+	pdf.SetX(gopdf.PageSizeA4.W - pdf.MarginRight() - r.textWidth(pdf, due, 11))
 	_ = pdf.Cell(nil, due)
 	pdf.Br(12)
 }
 
-// writeBillTo adds the recipient information to the PDF
-func (r *PDFRenderer) writeBillTo(pdf *gopdf.GoPdf, to string) {
+// writeBillTo adds the recipient information to the PDF, with the VAT id and
+// customer number of a structured recipient in small print below. A separate
+// delivery address is printed as a second block to the right of it.
+func (r *PDFRenderer) writeBillTo(pdf *gopdf.GoPdf, invoice *models.Invoice, l labels, d density) {
+	top := pdf.GetY()
+	bottom := r.writeAddressBlock(pdf, pdf.MarginLeft(), l.get("billToLabel"), invoice.RecipientLines())
+	
+	if details := recipientDetails(invoice, l); len(details) > 0 {
+		pdf.SetY(bottom + 2)
+		_ = pdf.SetFont(fontRegular, "", 8)
+		pdf.SetTextColor(100, 100, 100)
+		for _, line := range details {
+			pdf.SetX(pdf.MarginLeft())
+			_ = pdf.Cell(nil, line)
+			pdf.Br(10)
+		}
+		bottom = pdf.GetY()
+	}
+	
+	if invoice.ShipTo != "" {
+		pdf.SetY(top)
+		if y := r.writeAddressBlock(pdf, shipToX, l.get("shipToLabel"), addressLines(invoice.ShipTo)); y > bottom {
+			bottom = y
+		}
+	}
+	
+	pdf.SetY(bottom)
+	pdf.Br(d.gap(30)) // Reduced space
+}
+
+// writeAddressBlock writes a labelled address starting at x and the current
+// line, and returns the y position below it
+func (r *PDFRenderer) writeAddressBlock(pdf *gopdf.GoPdf, x float64, label string, lines []string) float64 {
 	pdf.SetTextColor(75, 75, 75)
-	_ = pdf.SetFont("Inter", "", 9)
-	_ = pdf.Cell(nil, billToLabel)
+	_ = pdf.SetFont(fontRegular, "", 9)
+	pdf.SetX(x)
+	_ = pdf.Cell(nil, label)
 	pdf.Br(12) // Reduced space
-	pdf.SetTextColor(75, 75, 75)
-	
-	formattedTo := strings.ReplaceAll(to, `\n`, "\n")
-	toLines := strings.Split(formattedTo, "\n")
 	
-	for i := 0; i < len(toLines); i++ {
+	for i := 0; i < len(lines); i++ {
+		pdf.SetX(x)
 		if i == 0 {
-			_ = pdf.SetFont("Inter", "", 15)
-			_ = pdf.Cell(nil, toLines[i])
+			_ = pdf.SetFont(fontRegular, "", 15)
+			_ = pdf.Cell(nil, lines[i])
 			pdf.Br(16) // Reduced space
 		} else {
-			_ = pdf.SetFont("Inter", "", 10)
-			_ = pdf.Cell(nil, toLines[i])
+			_ = pdf.SetFont(fontRegular, "", 10)
+			_ = pdf.Cell(nil, lines[i])
 			pdf.Br(12) // Reduced space
 		}
 	}
-	pdf.Br(30) // Reduced space
+	return pdf.GetY()
+}
+
+// tableStyle returns whether the item table has a rule below its header row
+// and whether every other row is striped
+func tableStyle(invoice *models.Invoice) (ruled, striped bool) {
+	striped = invoice.TableStyle == models.TableStyleStriped
+	return striped || invoice.TableStyle == models.TableStyleRuled, striped
 }
 
-// writeHeaderRow adds the column headers for invoice items to the PDF
-func (r *PDFRenderer) writeHeaderRow(pdf *gopdf.GoPdf) {
-	_ = pdf.SetFont("Inter", "", 9)
+// writeHeaderRow adds the column headers for invoice items to the PDF, with
+// a rule below them if ruled is set
+func (r *PDFRenderer) writeHeaderRow(pdf *gopdf.GoPdf, columns tableColumns, l labels, ruled bool, d density) {
+	headerTop := pdf.GetY()
+	_ = pdf.SetFont(fontRegular, "", d.size(9))
 	pdf.SetTextColor(55, 55, 55)
-	_ = pdf.Cell(nil, itemLabel)
-	pdf.SetX(quantityColumnOffset)
-	_ = pdf.Cell(nil, qtyLabel)
-	pdf.SetX(rateColumnOffset)
-	_ = pdf.Cell(nil, rateLabel)
-	pdf.SetX(amountColumnOffset)
-	_ = pdf.Cell(nil, amountLabel)
-	pdf.Br(24)
+	_ = pdf.Cell(nil, l.get("itemLabel"))
+	if columns.withDates {
+		pdf.SetX(columns.date)
+		_ = pdf.Cell(nil, l.get("dateLabel"))
+	}
+	pdf.SetX(columns.quantity)
+	_ = pdf.Cell(nil, l.get("qtyLabel"))
+	pdf.SetX(columns.rate)
+	_ = pdf.Cell(nil, l.get("rateLabel"))
+	pdf.SetX(columns.amount)
+	_ = pdf.Cell(nil, l.get("amountLabel"))
+	
+	// The rule sits halfway between the headers and the first row
+	if ruled {
+		y := headerTop + (d.size(9)+d.gap(24))/2
+		pdf.SetStrokeColor(225, 225, 225)
+		pdf.Line(pdf.MarginLeft(), y, columns.end(), y)
+	}
+	
+	pdf.Br(d.gap(24))
 }
 
-// writeMultilineText draws text with word wrapping
-func (r *PDFRenderer) writeMultilineText(pdf *gopdf.GoPdf, text string, x, y, width float64, lineHeight float64) float64 {
-	pdf.SetX(x)
-	pdf.SetY(y)
+// writeNoItems stands in for the item table of an invoice without items
+func (r *PDFRenderer) writeNoItems(pdf *gopdf.GoPdf, l labels, d density) {
+	_ = pdf.SetFont(fontRegular, "", d.size(10))
+	pdf.SetTextColor(100, 100, 100)
+	_ = pdf.Cell(nil, l.get("noItemsLabel"))
+	pdf.Br(d.gap(24))
+}
+
+// tableColumns holds the X positions of the item table's columns
+type tableColumns struct {
+	withDates                    bool
+	date, quantity, rate, amount float64
 	
-	words := strings.Fields(text)
-	currentLine := ""
+	// descriptionWidth is the width the item description wraps to
+	descriptionWidth float64
+}
+
+// end returns the X position of the table's right edge
+func (c tableColumns) end() float64 {
+	return c.amount + amountColumnWidth
+}
+
+// newTableColumns lays out the item table from the right page margin, with
+// a date column between the description and the quantity if withDates is set
+func newTableColumns(pdf *gopdf.GoPdf, withDates bool) tableColumns {
+	columns := tableColumns{withDates: withDates}
+	// Columns start on whole points, like the fixed positions they replace
+	columns.amount = math.Floor(gopdf.PageSizeA4.W-pdf.MarginRight()) - amountColumnWidth
+	columns.rate = columns.amount - rateColumnWidth
+	columns.quantity = columns.rate - quantityColumnWidth
+	
+	descriptionEnd := columns.quantity
+	if withDates {
+		columns.date = columns.quantity - dateColumnWidth
+		descriptionEnd = columns.date
+	}
 	
-	for _, word := range words {
-		testLine := currentLine
-		if testLine != "" {
-			testLine += " "
-		}
-		testLine += word
+	// Keep a gap between the description and the next column
+	columns.descriptionWidth = descriptionEnd - 60
+	return columns
+}
+
+// wrapText splits text into lines that fit the given width in the current font,
+// which must be set at fontSize
+func (r *PDFRenderer) wrapText(pdf *gopdf.GoPdf, text string, width float64, fontSize float64) []string {
+	var lines []string
+	warned := false
+	
+	// Explicit line breaks always start a new line
+	for _, paragraph := range strings.Split(text, "\n") {
+		words := strings.Fields(paragraph)
+		currentLine := ""
 		
-		// Measure the width of the test line
-		textWidth, err := pdf.MeasureTextWidth(testLine)
-		if err != nil {
-			textWidth = float64(len(testLine) * 5) // rough estimate
+		for _, word := range words {
+			testLine := currentLine
+			if testLine != "" {
+				testLine += " "
+			}
+			testLine += word
+			
+			// Measure the width of the test line
+			textWidth, err := pdf.MeasureTextWidth(testLine)
+			if err != nil {
+				if !warned {
+					fmt.Fprintf(os.Stderr, "Warning: Unable to measure text width, estimating line breaks: %v\n", err)
+					warned = true
+				}
+				// Count runes, not bytes, so umlauts and currency symbols aren't underestimated
+				textWidth = float64(utf8.RuneCountInString(testLine)) * fontSize * averageGlyphWidth
+			}
+			
+			// If adding the word exceeds available width, keep the current line and start a new one
+			if textWidth > width && currentLine != "" {
+				lines = append(lines, currentLine)
+				currentLine = word
+			} else {
+				currentLine = testLine
+			}
 		}
 		
-		// If adding the word exceeds available width, write the current line and start a new one
-		if textWidth > width && currentLine != "" {
-			pdf.SetX(x)
-			_ = pdf.Cell(nil, currentLine)
-			pdf.Br(lineHeight)
-			currentLine = word
-		} else {
-			// Add the word to the current line
-			if currentLine != "" {
-				currentLine += " "
-			}
-			currentLine += word
+		if currentLine != "" {
+			lines = append(lines, currentLine)
 		}
 	}
 	
-	// Write the last line if any
-	if currentLine != "" {
-		pdf.SetX(x)
-		_ = pdf.Cell(nil, currentLine)
-		pdf.Br(lineHeight)
-	}
-	
-	// Return the new Y position after writing all the text
-	return pdf.GetY()
+	return lines
 }
 
 // writeNotes adds notes to the PDF
-func (r *PDFRenderer) writeNotes(pdf *gopdf.GoPdf, notes string) {
-	// Get the current Y position after writing all the invoice items
-	currentY := pdf.GetY()
-	
-	// Add spacing after the items (reduced)
-	currentY += 15
-	
-	// Set position for notes section
-	pdf.SetY(currentY)
-	
-	// Write the "NOTES" header
-	_ = pdf.SetFont("Inter", "", 9)
-	pdf.SetTextColor(55, 55, 55)
-	_ = pdf.Cell(nil, notesLabel)
-	pdf.Br(12) // Reduced space
-	
-	// Configure for the notes content
-	_ = pdf.SetFont("Inter", "", 9)
-	pdf.SetTextColor(0, 0, 0)
-	
+func (r *PDFRenderer) writeNotes(pdf *gopdf.GoPdf, notes string, l labels, d density) {
 	// Available width for text (leaving space for the totals column)
 	availableWidth := 320.0
+	lineHeight := d.size(12) // Reduced line height
+	fontSize := d.size(9)
 	
-	// Format notes text
+	// Format notes text and wrap it up front so the block height is known
+	_ = pdf.SetFont(fontRegular, "", fontSize)
 	formattedNotes := strings.ReplaceAll(notes, `\n`, "\n")
+	lines := r.wrapText(pdf, formattedNotes, availableWidth, fontSize)
 	
-	// Write the notes with word wrapping
-	r.writeMultilineText(pdf, formattedNotes, pdf.GetX(), pdf.GetY(), availableWidth, 12) // Reduced line height
-}
-
-// writeFooter adds the footer information to the PDF
-func (r *PDFRenderer) writeFooter(pdf *gopdf.GoPdf, id string, footer models.Footer) {
-	// Set position for footer - moved higher up the page
-	pdf.SetY(770)
-	
-	// Add a line above the footer
-	pdf.SetStrokeColor(225, 225, 225)
-	pdf.Line(40, pdf.GetY(), 550, pdf.GetY())
-	pdf.Br(15)
-	
-	// Set font for footer text
-	_ = pdf.SetFont("Inter", "", 8)
-	pdf.SetTextColor(75, 75, 75)
-	
-	// Define column widths and positions
-	leftColX := 40.0
-	leftColWidth := 150.0
-	
-	middleColX := 215.0
-	middleColWidth := 160.0
-	
-	rightColX := 400.0
+	// Spacing after the items, the header and the wrapped lines
+	height := d.gap(15) + lineHeight + float64(len(lines))*lineHeight
+	r.ensureSpace(pdf, height)
 	
-	lineHeight := 10.0 // Space between lines
-	
-	// Column 1 - Left
-	currentY := pdf.GetY()
-	startY := currentY
+	// Add spacing after the items (reduced)
+	pdf.SetY(pdf.GetY() + d.gap(15))
 	
-	// Company name
-	pdf.SetX(leftColX)
-	_ = pdf.Cell(nil, footer.CompanyName)
+	// Write the "NOTES" header
+	pdf.SetTextColor(55, 55, 55)
+	_ = pdf.Cell(nil, l.get("notesLabel"))
 	pdf.Br(lineHeight)
 	
-	// Registration info - only if it should be shown
-	if footer.ShowRegistration && footer.RegistrationInfo != "" {
-		pdf.SetX(leftColX)
-		formattedRegInfo := strings.ReplaceAll(footer.RegistrationInfo, `\n`, "\n")
-		if strings.Contains(formattedRegInfo, "\n") {
-			// If it contains newlines, use multiline text
-			currentY = pdf.GetY()
-			newY := r.writeMultilineText(pdf, formattedRegInfo, leftColX, currentY, leftColWidth, lineHeight)
-			// Set Y position after multiline text
-			pdf.SetY(newY)
-		} else {
-			// Single line
-			_ = pdf.Cell(nil, formattedRegInfo)
-			pdf.Br(lineHeight)
-		}
-	}
-	
-	// VAT ID - only if it should be shown
-	if footer.ShowVatId && footer.VatId != "" {
-		pdf.SetX(leftColX)
-		_ = pdf.Cell(nil, footer.VatId)
+	// Notes longer than a page continue on the next one
+	x := pdf.GetX()
+	for _, line := range lines {
+		r.ensureSpace(pdf, lineHeight)
+		
+		// Set per line as a new page's header changes the font
+		_ = pdf.SetFont(fontRegular, "", fontSize)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetX(x)
+		_ = pdf.Cell(nil, line)
+		pdf.Br(lineHeight)
 	}
+}
+
+// writePageNumber adds the invoice number and page position at the top of the page
+func (r *PDFRenderer) writePageNumber(pdf *gopdf.GoPdf, id string, page, totalPages int) {
+	_ = pdf.SetFont(fontRegular, "", 8)
+	pdf.SetTextColor(75, 75, 75)
+	pdf.SetY(pdf.MarginTop() - 15)
+	pdf.SetX(500)
+	_ = pdf.Cell(nil, fmt.Sprintf("%s · %d/%d", id, page, totalPages))
+}
+
+// writeRow adds an invoice item row to the PDF, on a light background if
+// striped is set. The quantity is already formatted with its unit.
+func (r *PDFRenderer) writeRow(pdf *gopdf.GoPdf, columns tableColumns, item, date, quantity string, rate, total float64, money amountFormatter, striped bool, d density) {
+	fontSize := d.size(10) // Slightly smaller font
+	_ = pdf.SetFont(fontRegular, "", fontSize)
+	pdf.SetTextColor(0, 0, 0)
 	
-	// Column 2 - Middle
-	pdf.SetY(startY)
-	currentY = startY
+	rowHeight := d.gap(20)   // Reduced row spacing
+	lineHeight := d.size(12) // Reduced line height
 	
-	// Address
-	pdf.SetX(middleColX)
-	_ = pdf.Cell(nil, footer.Address)
-	pdf.Br(lineHeight)
+	// Wrap the description first so the row height is known before drawing
+	lines := r.wrapText(pdf, item, columns.descriptionWidth, fontSize)
 	
-	// Zip and City
-	pdf.SetX(middleColX)
-	zipCity := footer.Zip
-	if zipCity != "" && footer.City != "" {
-		zipCity += " " + footer.City
-	} else if footer.City != "" {
-		zipCity = footer.City
+	// Wrapped rows keep the same gap to the next row as single-line rows
+	height := rowHeight
+	if descriptionHeight := float64(len(lines))*lineHeight + rowHeight - lineHeight; descriptionHeight > height {
+		height = descriptionHeight
 	}
-	_ = pdf.Cell(nil, zipCity)
-	pdf.Br(lineHeight)
+	r.ensureSpace(pdf, height)
 	
-	// Phone
-	pdf.SetX(middleColX)
-	if footer.Phone != "" {
-		_ = pdf.Cell(nil, "Tel.: " + footer.Phone)
-	}
-	pdf.Br(lineHeight)
+	// Set after ensureSpace as a new page's header changes the font
+	_ = pdf.SetFont(fontRegular, "", fontSize)
+	pdf.SetTextColor(0, 0, 0)
 	
-	// Email and Website
-	pdf.SetX(middleColX)
-	contactInfo := ""
-	if footer.Email != "" {
-		contactInfo = footer.Email
-		if footer.Website != "" {
-			contactInfo += " | " + footer.Website
-		}
-	} else if footer.Website != "" {
-		contactInfo = footer.Website
-	}
+	x := pdf.GetX()
+	rowTop := pdf.GetY()
 	
-	// Check if contact info is long and needs wrapping
-	if len(contactInfo) > 30 {
-		currentY = pdf.GetY()
-		r.writeMultilineText(pdf, contactInfo, middleColX, currentY, middleColWidth, lineHeight)
-	} else {
-		_ = pdf.Cell(nil, contactInfo)
+	// The stripe covers the whole row including wrapped lines, centred on
+	// the text, and is drawn first so the text lies on top of it
+	if striped {
+		padding := (rowHeight - lineHeight) / 2
+		pdf.SetFillColor(245, 245, 245)
+		pdf.RectFromUpperLeftWithStyle(pdf.MarginLeft(), rowTop-padding, columns.end()-pdf.MarginLeft(), height, "F")
 	}
 	
-	// Column 3 - Right
-	pdf.SetY(startY)
-	
-	// Bank header
-	pdf.SetX(rightColX)
-	_ = pdf.Cell(nil, "Bankverbindung:")
-	pdf.Br(lineHeight)
-	
-	// Bank name
-	pdf.SetX(rightColX)
-	_ = pdf.Cell(nil, footer.BankName)
-	pdf.Br(lineHeight)
-	
-	// IBAN
-	pdf.SetX(rightColX)
-	if footer.BankIban != "" {
-		_ = pdf.Cell(nil, "IBAN: " + footer.BankIban)
+	// Numbers always sit on the first line of the row
+	if date != "" {
+		pdf.SetX(columns.date)
+		_ = pdf.Cell(nil, date)
 	}
-	pdf.Br(lineHeight)
-	
-	// BIC
-	pdf.SetX(rightColX)
-	if footer.BankBic != "" {
-		_ = pdf.Cell(nil, "BIC: " + footer.BankBic)
+	pdf.SetX(columns.quantity)
+	_ = pdf.Cell(nil, quantity)
+	pdf.SetX(columns.rate)
+	_ = pdf.Cell(nil, money.format(rate))
+	pdf.SetX(columns.amount)
+	_ = pdf.Cell(nil, money.format(total))
+	
+	for i, line := range lines {
+		pdf.SetX(x)
+		pdf.SetY(rowTop + float64(i)*lineHeight)
+		_ = pdf.Cell(nil, line)
 	}
 	
-	// Add invoice number at the top of the page
-	pdf.SetY(25)
-	pdf.SetX(500)
-	_ = pdf.Cell(nil, id + " · " + "1/1")
+	pdf.SetX(x)
+	pdf.SetY(rowTop + height)
 }
 
-// writeRow adds an invoice item row to the PDF
-func (r *PDFRenderer) writeRow(pdf *gopdf.GoPdf, item string, quantity int, rate float64, currency string) {
-	_ = pdf.SetFont("Inter", "", 10) // Slightly smaller font
-	pdf.SetTextColor(0, 0, 0)
+// writeSectionHeader adds the name of a section of items above its first row
+func (r *PDFRenderer) writeSectionHeader(pdf *gopdf.GoPdf, name string, d density) {
+	// Keep the name together with the first row of the section
+	r.ensureSpace(pdf, d.gap(20)+d.gap(20))
 	
-	total := float64(quantity) * rate
-	amount := strconv.FormatFloat(total, 'f', 2, 64)
+	// Set after ensureSpace as a new page's header changes the font
+	_ = pdf.SetFont(fontBold, "", d.size(10))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetX(pdf.MarginLeft())
+	_ = pdf.Cell(nil, name)
+	pdf.Br(d.gap(20))
+}
+
+// writeSectionTotal adds the subtotal of a section of items below its last row
+func (r *PDFRenderer) writeSectionTotal(pdf *gopdf.GoPdf, columns tableColumns, label, amount string, d density) {
+	r.ensureSpace(pdf, d.gap(24))
 	
-	// For article/description column, use text wrapping if it's too long
-	if len(item) > 40 {
-		availableWidth := float64(quantityColumnOffset - 60)
-		r.writeMultilineText(pdf, item, pdf.GetX(), pdf.GetY(), availableWidth, 12) // Reduced line height
-		// Reset Y position for quantity, rate, and amount
-		pdf.SetY(pdf.GetY() - 12)
-	} else {
-		_ = pdf.Cell(nil, item)
-	}
+	// Set after ensureSpace as a new page's header changes the font
+	_ = pdf.SetFont(fontRegular, "", d.size(9))
+	pdf.SetTextColor(75, 75, 75)
+	pdf.SetX(pdf.MarginLeft())
+	_ = pdf.Cell(nil, label)
 	
-	// Get currency symbol from the service
-	currencySymbol := r.currencyService.GetSymbol(currency)
+	_ = pdf.SetFont(fontBold, "", d.size(10))
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetX(columns.amount)
+	_ = pdf.Cell(nil, amount)
+	pdf.Br(d.gap(24))
+}
+
+// writeItemSummary adds the item count and total quantity below the last item
+func (r *PDFRenderer) writeItemSummary(pdf *gopdf.GoPdf, summary string, d density) {
+	r.ensureSpace(pdf, d.gap(20))
 	
-	pdf.SetX(quantityColumnOffset)
-	_ = pdf.Cell(nil, strconv.Itoa(quantity))
-	pdf.SetX(rateColumnOffset)
-	_ = pdf.Cell(nil, currencySymbol+strconv.FormatFloat(rate, 'f', 2, 64))
-	pdf.SetX(amountColumnOffset)
-	_ = pdf.Cell(nil, currencySymbol+amount)
-	pdf.Br(20) // Reduced row spacing
+	// Set after ensureSpace as a new page's header changes the font
+	_ = pdf.SetFont(fontRegular, "", d.size(9))
+	pdf.SetTextColor(75, 75, 75)
+	pdf.SetX(pdf.MarginLeft())
+	_ = pdf.Cell(nil, summary)
+	pdf.Br(d.gap(20))
+}
+
+// totalsHeight returns the vertical space writeTotals needs for the given lines
+func (r *PDFRenderer) totalsHeight(pdf *gopdf.GoPdf, lines []totalLine, d density) float64 {
+	// Spacing above the block and one row per line, notes may wrap
+	height := d.gap(20) + float64(len(lines))*d.gap(24)
+	for _, line := range lines {
+		if line.Note {
+			height += float64(len(r.wrapTotalNote(pdf, line.Label, d))-1) * d.size(totalNoteLineHeight)
+		}
+	}
+	return height
 }
 
-// writeTotals adds the invoice totals to the PDF
-func (r *PDFRenderer) writeTotals(pdf *gopdf.GoPdf, subtotal float64, tax float64, discount float64, taxExempt bool, currency string) {
+// writeTotals adds the invoice totals to the PDF, next to the paid stamp of
+// a paid invoice
+func (r *PDFRenderer) writeTotals(pdf *gopdf.GoPdf, paidDate string, lines []totalLine, l labels, d density) {
 	// Get the current Y position - use dynamic positioning instead of fixed position
-	currentY := pdf.GetY() + 20
+	currentY := pdf.GetY() + d.gap(20)
+	
+	if paidDate != "" {
+		r.writePaidStamp(pdf, paidDate, currentY, l)
+	}
 	
 	// Set X position for the totals section (using absolute positioning)
-	pdf.SetX(350) // Fixed position for labels
+	pdf.SetX(totalsLabelX)
 	pdf.SetY(currentY)
 	
-	// Get currency symbol from the service
-	currencySymbol := r.currencyService.GetSymbol(currency)
-	
-	r.writeTotal(pdf, subtotalLabel, subtotal, currencySymbol)
-	
-	// Only show tax if not exempt
-	if !taxExempt && tax > 0 {
-		r.writeTotal(pdf, taxLabel, tax, currencySymbol)
-	} else if taxExempt {
-		// Add a note about tax exemption (Kleinunternehmer-Regelung)
-		pdf.SetX(350)
-		_ = pdf.SetFont("Inter", "", 9)
-		pdf.SetTextColor(75, 75, 75)
-		_ = pdf.Cell(nil, "Gemäß § 19 UStG wird keine Umsatzsteuer berechnet.")
-		pdf.Br(24)
+	for _, line := range lines {
+		if line.Note {
+			r.writeTotalNote(pdf, line.Label, d)
+		} else {
+			r.writeTotalText(pdf, line.Label, line.Value, line.Bold, d)
+		}
 	}
-	
-	if discount > 0 {
-		r.writeTotal(pdf, discountLabel, discount, currencySymbol)
+}
+
+// writeTaxSummary adds the tax summary table below the totals, see
+// taxSummaryRows. The header row is gray and the sum in bold.
+func (r *PDFRenderer) writeTaxSummary(pdf *gopdf.GoPdf, rows [][]string, d density) {
+	fontSize := d.size(8)
+	lineHeight := d.size(11)
+	
+	r.ensureSpace(pdf, d.gap(12)+float64(len(rows))*lineHeight)
+	pdf.SetY(pdf.GetY() + d.gap(12))
+	
+	// The rate is left-aligned below the totals' labels, the amounts are
+	// right-aligned in columns ending at the right page margin
+	right := gopdf.PageSizeA4.W - pdf.MarginRight()
+	ends := []float64{right - 130, right - 65, right}
+	
+	for i, row := range rows {
+		switch i {
+		case 0:
+			_ = pdf.SetFont(fontRegular, "", fontSize)
+			pdf.SetTextColor(75, 75, 75)
+		case len(rows) - 1:
+			_ = pdf.SetFont(fontBold, "", fontSize)
+			pdf.SetTextColor(0, 0, 0)
+		default:
+			_ = pdf.SetFont(fontRegular, "", fontSize)
+			pdf.SetTextColor(0, 0, 0)
+		}
+		
+		pdf.SetX(totalsLabelX)
+		_ = pdf.Cell(nil, row[0])
+		for j, cell := range row[1:] {
+			pdf.SetX(ends[j] - r.textWidth(pdf, cell, fontSize))
+			_ = pdf.Cell(nil, cell)
+		}
+		pdf.Br(lineHeight)
 	}
-	
-	// Calculate total - only add tax if not exempt
-	total := subtotal - discount
-	if !taxExempt {
-		total += tax
+}
+
+// writePaidStamp draws a green box saying when the invoice was paid, left of
+// the totals block starting at y
+func (r *PDFRenderer) writePaidStamp(pdf *gopdf.GoPdf, paidDate string, y float64, l labels) {
+	text := l.get("paidStampLabel") + " " + paidDate
+	fontSize := 11.0
+	padding := 8.0
+	
+	_ = pdf.SetFont(fontBold, "", fontSize)
+	width := r.textWidth(pdf, text, fontSize) + 2*padding
+	height := fontSize + 2*padding
+	
+	pdf.SetStrokeColor(30, 130, 60)
+	pdf.SetLineWidth(1.5)
+	pdf.RectFromUpperLeftWithStyle(pdf.MarginLeft(), y, width, height, "D")
+	pdf.SetLineWidth(1)
+	
+	pdf.SetTextColor(30, 130, 60)
+	pdf.SetX(pdf.MarginLeft() + padding)
+	pdf.SetY(y + padding)
+	_ = pdf.Cell(nil, text)
+}
+
+// writeTotalNote adds a note in place of a total line, such as the tax
+// exemption note (Kleinunternehmer-Regelung)
+func (r *PDFRenderer) writeTotalNote(pdf *gopdf.GoPdf, note string, d density) {
+	lines := r.wrapTotalNote(pdf, note, d)
+	pdf.SetTextColor(75, 75, 75)
+	for i, line := range lines {
+		if i > 0 {
+			pdf.Br(d.size(totalNoteLineHeight))
+		}
+		pdf.SetX(totalsLabelX)
+		_ = pdf.Cell(nil, line)
 	}
-	
-	r.writeTotal(pdf, totalLabel, total, currencySymbol)
+	pdf.Br(d.gap(24))
+}
+
+// wrapTotalNote sets the font of a note in the totals block and wraps the
+// note to the width of the block
+func (r *PDFRenderer) wrapTotalNote(pdf *gopdf.GoPdf, note string, d density) []string {
+	_ = pdf.SetFont(fontRegular, "", d.size(9))
+	lines := r.wrapText(pdf, note, gopdf.PageSizeA4.W-pdf.MarginRight()-totalsLabelX, d.size(9))
+	if len(lines) == 0 {
+		return []string{""}
+	}
+	return lines
 }
 
-// writeTotal adds a single total line to the PDF
-func (r *PDFRenderer) writeTotal(pdf *gopdf.GoPdf, label string, total float64, currencySymbol string) {
-	_ = pdf.SetFont("Inter", "", 9)
+// writeTotalText adds a total line with an already formatted value. The value
+// is right-aligned against the right margin, so every line ends flush no
+// matter how wide the currency symbol is.
+func (r *PDFRenderer) writeTotalText(pdf *gopdf.GoPdf, label string, value string, bold bool, d density) {
+	_ = pdf.SetFont(fontRegular, "", d.size(9))
 	pdf.SetTextColor(75, 75, 75)
-	pdf.SetX(350) // Fixed position for labels
+	pdf.SetX(totalsLabelX)
 	_ = pdf.Cell(nil, label)
 	pdf.SetTextColor(0, 0, 0)
-	_ = pdf.SetFontSize(12)
-	pdf.SetX(470) // Fixed position for values
-	if label == totalLabel {
-		_ = pdf.SetFont("Inter-Bold", "", 11.5)
+	fontSize := d.size(12)
+	_ = pdf.SetFontSize(fontSize)
+	if bold {
+		fontSize = d.size(11.5)
+		_ = pdf.SetFont(fontBold, "", fontSize)
 	}
-	_ = pdf.Cell(nil, currencySymbol+strconv.FormatFloat(total, 'f', 2, 64))
-	pdf.Br(24)
+	pdf.SetX(gopdf.PageSizeA4.W - pdf.MarginRight() - r.textWidth(pdf, value, fontSize))
+	_ = pdf.Cell(nil, value)
+	pdf.Br(d.gap(24))
 }
 
-// getImageDimension returns the width and height of an image
-func (r *PDFRenderer) getImageDimension(imagePath string) (int, int) {
-	// If image path is empty, return zero dimensions
-	if imagePath == "" {
-		return 0, 0
+// textWidth returns the width of text in the current font, which must be set
+// at fontSize, estimating it if the font can't measure it
+func (r *PDFRenderer) textWidth(pdf *gopdf.GoPdf, text string, fontSize float64) float64 {
+	width, err := pdf.MeasureTextWidth(text)
+	if err != nil {
+		return float64(utf8.RuneCountInString(text)) * fontSize * averageGlyphWidth
 	}
-	
+	return width
+}
+
+// getImageDimension returns the width, height and format ("png", "jpeg" or
+// "gif") of an image. Images without a size are an error, so callers can
+// safely scale by them.
+func (r *PDFRenderer) getImageDimension(imagePath string) (int, int, string, error) {
 	file, err := os.Open(imagePath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening image %s: %v\n", imagePath, err)
-		return 0, 0
+		return 0, 0, "", fmt.Errorf("unable to open image %s: %v", imagePath, err)
 	}
 	defer file.Close()
 	
-	image, _, err := image.DecodeConfig(file)
+	config, format, err := image.DecodeConfig(file)
+	if err == image.ErrFormat {
+		return 0, 0, "", fmt.Errorf("unsupported image format in %s, use PNG, JPEG, GIF or SVG", imagePath)
+	}
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error decoding image %s: %v\n", imagePath, err)
-		return 0, 0
+		return 0, 0, "", fmt.Errorf("unable to decode image %s: %v", imagePath, err)
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return 0, 0, "", fmt.Errorf("image %s has no size", imagePath)
 	}
-	return image.Width, image.Height
+	return config.Width, config.Height, format, nil
 }
\ No newline at end of file
@@ -0,0 +1,355 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+	"unicode/utf8"
+	
+	"invoice/internal/models"
+	"invoice/internal/services/currency"
+	
+	"github.com/signintech/gopdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// newTestRenderer returns a renderer with the Go fonts, so tests don't
+// depend on the Inter files
+func newTestRenderer() *PDFRenderer {
+	renderer := NewPDFRenderer(currency.NewCurrencyService())
+	renderer.SetFontData(goregular.TTF, gobold.TTF)
+	return renderer
+}
+
+// testInvoice returns a small invoice with fixed dates
+func testInvoice() models.Invoice {
+	invoice := models.DefaultInvoice()
+	invoice.Id = "R-2024-001"
+	invoice.Date = "01.03.2024"
+	invoice.Due = "15.03.2024"
+	invoice.ServiceDateFrom = "01.02.2024"
+	invoice.From = "Firma GmbH\nHauptstraße 1\n10115 Berlin"
+	invoice.To = "Kunde AG\nNebenweg 2\n80331 München"
+	invoice.Items = []string{"Beratung", "Entwicklung"}
+	invoice.Quantities = []int{2, 10}
+	invoice.Rates = []float64{120, 95.5}
+	return invoice
+}
+
+// render renders an invoice to bytes
+func render(t *testing.T, renderer *PDFRenderer, invoice models.Invoice) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := renderer.Render(&invoice, &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// layoutText is a text drawn on a page, as listed by extractLayout. The
+// position is the baseline in PDF coordinates, from the bottom of the page.
+type layoutText struct {
+	page       int
+	x, y, size float64
+	font, text string
+}
+
+// renderTexts renders an invoice and returns the texts drawn on its pages
+func renderTexts(t *testing.T, renderer *PDFRenderer, invoice models.Invoice) []layoutText {
+	t.Helper()
+	layout, err := extractLayout(render(t, renderer, invoice))
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	var texts []layoutText
+	page := 0
+	for _, line := range strings.Split(layout, "\n") {
+		if strings.HasPrefix(line, "page ") {
+			page++
+			continue
+		}
+		if !strings.HasPrefix(line, "text ") {
+			continue
+		}
+		text := layoutText{page: page}
+		if _, err := fmt.Sscanf(line, "text %f %f %s %f %q", &text.x, &text.y, &text.font, &text.size, &text.text); err != nil {
+			t.Fatalf("unable to read %q: %v", line, err)
+		}
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+// findTexts returns the texts that match
+func findTexts(texts []layoutText, match func(layoutText) bool) []layoutText {
+	var found []layoutText
+	for _, text := range texts {
+		if match(text) {
+			found = append(found, text)
+		}
+	}
+	return found
+}
+
+// footerRuleY returns the height of the rule above an invoice's footer in
+// PDF coordinates, content must stay above it
+func footerRuleY(t *testing.T, renderer *PDFRenderer, invoice models.Invoice) float64 {
+	t.Helper()
+	signatureY, err := renderer.SignatureY(&invoice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gopdf.PageSizeA4.H - (signatureY + SignatureHeight + signatureGap)
+}
+
+func TestDeterministicRender(t *testing.T) {
+	tests := []struct {
+		name          string
+		deterministic bool
+		info          bool
+	}{
+		{"default", false, true},
+		{"deterministic", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := newTestRenderer()
+			renderer.SetDeterministic(tt.deterministic)
+			
+			first := render(t, renderer, testInvoice())
+			second := render(t, renderer, testInvoice())
+			if !bytes.Equal(first, second) {
+				t.Fatal("rendering the same invoice twice gave different bytes")
+			}
+			
+			if bytes.Contains(first, []byte("/CreationDate")) {
+				t.Error("the document info has a creation date")
+			}
+			if info := bytes.Contains(first, []byte("/Info")); info != tt.info {
+				t.Errorf("document info written = %v, want %v", info, tt.info)
+			}
+		})
+	}
+}
+
+func TestNotesClearTotals(t *testing.T) {
+	renderer := newTestRenderer()
+	invoice := testInvoice()
+	invoice.Items, invoice.Quantities, invoice.Rates = nil, nil, nil
+	for i := 1; i <= 20; i++ {
+		invoice.Items = append(invoice.Items, fmt.Sprintf("Position %d", i))
+		invoice.Quantities = append(invoice.Quantities, 1)
+		invoice.Rates = append(invoice.Rates, 10)
+	}
+	var note []string
+	for i := 1; i <= 10; i++ {
+		note = append(note, fmt.Sprintf("Hinweis Zeile %d", i))
+	}
+	invoice.Note = strings.Join(note, "\n")
+	
+	texts := renderTexts(t, renderer, invoice)
+	notes := findTexts(texts, func(text layoutText) bool { return strings.HasPrefix(text.text, "Hinweis Zeile") })
+	totals := findTexts(texts, func(text layoutText) bool { return text.text == "Zwischensumme" || text.text == "Gesamt" })
+	if len(notes) != 10 {
+		t.Fatalf("got %d note lines, want 10", len(notes))
+	}
+	if len(totals) == 0 {
+		t.Fatal("no totals")
+	}
+	
+	// Nothing runs into the footer
+	ruleY := footerRuleY(t, renderer, invoice)
+	for _, text := range append(notes, totals...) {
+		if text.y <= ruleY {
+			t.Errorf("%q on page %d at y %.2f overlaps the footer below %.2f", text.text, text.page, text.y, ruleY)
+		}
+	}
+	
+	// The totals start below the last note line or on a later page
+	last, first := notes[len(notes)-1], totals[0]
+	if first.page == last.page && first.y >= last.y {
+		t.Errorf("totals at y %.2f overlap the notes ending at y %.2f on page %d", first.y, last.y, last.page)
+	}
+}
+
+func TestWrapTextFitsColumn(t *testing.T) {
+	renderer := newTestRenderer()
+	text := "Überprüfung der Größenänderungen für Müller & Söhne, Bürgermeister-Smidt-Straße, Präsentation über ₹ 15.000 für Lösungsvorschläge"
+	const fontSize = 10
+	
+	pdf := renderer.createPDF()
+	invoice := testInvoice()
+	if err := renderer.setupFonts(pdf, &invoice); err != nil {
+		t.Fatal(err)
+	}
+	_ = pdf.SetFont(fontRegular, "", fontSize)
+	width := newTableColumns(pdf, false).descriptionWidth
+	
+	lines := renderer.wrapText(pdf, text, width, fontSize)
+	if len(lines) < 2 {
+		t.Fatalf("got %d lines, want the text wrapped", len(lines))
+	}
+	if got := strings.Join(lines, " "); got != text {
+		t.Errorf("wrapped lines join to %q, want %q", got, text)
+	}
+	for _, line := range lines {
+		measured, err := pdf.MeasureTextWidth(line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if measured > width {
+			t.Errorf("%q is %.2f wide, more than the column's %.2f", line, measured, width)
+		}
+		
+		// The estimate used when measuring fails counts runes, so umlauts
+		// and currency symbols don't make it fall short of the real width
+		if estimate := float64(utf8.RuneCountInString(line)) * fontSize * averageGlyphWidth; estimate < measured {
+			t.Errorf("estimated %.2f for %q, less than the measured %.2f", estimate, line, measured)
+		}
+	}
+}
+
+func TestWrappedRowKeepsNumbersOnFirstLine(t *testing.T) {
+	renderer := newTestRenderer()
+	invoice := testInvoice()
+	description := "Konzeption, Entwicklung und Test der Schnittstelle zum Warenwirtschaftssystem einschließlich Dokumentation, Schulung und Abnahme"
+	invoice.Items = []string{description, "Kurz"}
+	invoice.Quantities = []int{3, 1}
+	invoice.Rates = []float64{120, 10}
+	
+	texts := renderTexts(t, renderer, invoice)
+	lines := findTexts(texts, func(text layoutText) bool {
+		return text.x == 40 && text.size == 10 && strings.Contains(description, text.text)
+	})
+	if len(lines) < 2 {
+		t.Fatalf("got %d description lines, want it wrapped", len(lines))
+	}
+	first, last := lines[0], lines[len(lines)-1]
+	
+	for _, value := range []string{"3", "€120.00", "€360.00"} {
+		found := findTexts(texts, func(text layoutText) bool { return text.text == value })
+		if len(found) != 1 {
+			t.Fatalf("found %q %d times, want once", value, len(found))
+		}
+		if found[0].y != first.y {
+			t.Errorf("%q at y %.2f, want it on the first description line at y %.2f", value, found[0].y, first.y)
+		}
+	}
+	
+	// The next row starts below the last wrapped line
+	next := findTexts(texts, func(text layoutText) bool { return text.text == "Kurz" })
+	if len(next) != 1 || next[0].y >= last.y {
+		t.Errorf("next row %v, want it below the description ending at y %.2f", next, last.y)
+	}
+}
+
+func TestTotalsFlushRight(t *testing.T) {
+	renderer := newTestRenderer()
+	
+	// Measure in the fonts the invoice was rendered with
+	pdf := renderer.createPDF()
+	base := testInvoice()
+	if err := renderer.setupFonts(pdf, &base); err != nil {
+		t.Fatal(err)
+	}
+	right := gopdf.PageSizeA4.W - pdf.MarginRight()
+	
+	for _, code := range []string{"EUR", "CHF", "MXN"} {
+		t.Run(code, func(t *testing.T) {
+			invoice := testInvoice()
+			invoice.Currency = code
+			invoice.Rates = []float64{12000, 95.5}
+			
+			// Only the values of the totals block use these sizes right of the labels
+			values := findTexts(renderTexts(t, renderer, invoice), func(text layoutText) bool {
+				return text.x > totalsLabelX && (text.size == 12 || text.size == 11.5)
+			})
+			if len(values) != 3 {
+				t.Fatalf("got %d total values, want subtotal, tax and total", len(values))
+			}
+			for _, value := range values {
+				_ = pdf.SetFont(value.font, "", value.size)
+				width, err := pdf.MeasureTextWidth(value.text)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if end := value.x + width; math.Abs(end-right) > 0.01 {
+					t.Errorf("%q ends at x %.2f, want %.2f", value.text, end, right)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderWithoutItems(t *testing.T) {
+	renderer := newTestRenderer()
+	invoice := testInvoice()
+	invoice.DocumentType = models.DocumentCreditNote
+	invoice.Items, invoice.Quantities, invoice.Rates = nil, nil, nil
+	invoice.Note = "Die Gutschrift wird mit der nächsten Rechnung verrechnet."
+	
+	texts := renderTexts(t, renderer, invoice)
+	has := func(value string) bool {
+		return len(findTexts(texts, func(text layoutText) bool { return text.text == value })) > 0
+	}
+	
+	for _, value := range []string{"Keine Positionen", invoice.Note} {
+		if !has(value) {
+			t.Errorf("%q is missing", value)
+		}
+	}
+	
+	// Neither the table header nor a total of zero is printed
+	for _, value := range []string{"MENGE", "BETRAG", "Zwischensumme", "Gesamt", "€0.00"} {
+		if has(value) {
+			t.Errorf("%q is printed for an invoice without items", value)
+		}
+	}
+}
+
+func TestRenderItemUnits(t *testing.T) {
+	renderer := newTestRenderer()
+	invoice := testInvoice()
+	invoice.Items = []string{"Anzahlung", "Reisekosten", "Support"}
+	invoice.Quantities = []int{30, 2, 8}
+	invoice.Rates = []float64{50, 120, 95}
+	invoice.ItemUnits = []string{models.PercentUnit, "", "h"}
+	
+	texts := renderTexts(t, renderer, invoice)
+	for _, want := range []string{"30%", "2", "8 h"} {
+		if len(findTexts(texts, func(text layoutText) bool { return text.text == want })) == 0 {
+			t.Errorf("quantity %q is missing", want)
+		}
+	}
+}
+
+func TestWarnings(t *testing.T) {
+	tests := []struct {
+		name string
+		edit func(*models.Invoice)
+		want bool
+	}{
+		{"service date set", func(invoice *models.Invoice) {}, false},
+		{"german invoice without service date", func(invoice *models.Invoice) { invoice.ServiceDateFrom = "" }, true},
+		{"default language", func(invoice *models.Invoice) { invoice.ServiceDateFrom, invoice.Language = "", "" }, true},
+		{"service date in the note", func(invoice *models.Invoice) {
+			invoice.ServiceDateFrom, invoice.Note = "", "Leistungszeitraum: März 2024"
+		}, false},
+		{"english invoice", func(invoice *models.Invoice) { invoice.ServiceDateFrom, invoice.Language = "", "en" }, false},
+		{"quote", func(invoice *models.Invoice) { invoice.ServiceDateFrom, invoice.DocumentType = "", models.DocumentQuote }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := testInvoice()
+			invoice.Language = "de"
+			tt.edit(&invoice)
+			if got := len(Warnings(&invoice)) > 0; got != tt.want {
+				t.Errorf("Warnings() = %q, want a warning %v", Warnings(&invoice), tt.want)
+			}
+		})
+	}
+}
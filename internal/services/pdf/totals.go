@@ -0,0 +1,207 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// totalLine is a line of the totals block. A note is printed in place of
+// the label and has no value, like the tax exemption note.
+type totalLine struct {
+	Label string
+	Value string
+	Bold  bool
+	Note  bool
+}
+
+// totalLines lists the lines of the totals block, shared by the PDF and HTML
+// output. The discount and tax lines are listed in the order they were applied.
+// Tax included in gross prices is listed below the total it is part of, as
+// is the total converted to the settlement currency, formatted by settlement.
+// An invoice without items, e.g. a reminder with only a note, has no totals.
+func totalLines(invoice *models.Invoice, totals models.Totals, money, settlement amountFormatter, l labels) []totalLine {
+	if len(invoice.Items) == 0 {
+		return nil
+	}
+	
+	lines := []totalLine{{Label: l.get("subtotalLabel"), Value: money.format(totals.Subtotal)}}
+	
+	taxes := taxLines(invoice, totals, money, l)
+	included := invoice.PricesIncludeTax && !invoice.TaxExempt
+	if included {
+		taxes = nil
+	}
+	
+	if invoice.DiscountBeforeTax {
+		lines = append(lines, discountLines(invoice, totals, money, l)...)
+		lines = append(lines, taxes...)
+	} else {
+		lines = append(lines, taxes...)
+		lines = append(lines, discountLines(invoice, totals, money, l)...)
+	}
+	
+	// The rounding adjustment makes the lines above add up to the total
+	if totals.Rounding != 0 {
+		lines = append(lines, totalLine{Label: l.get("roundingLabel"), Value: money.format(totals.Rounding)})
+	}
+	
+	lines = append(lines, totalLine{Label: l.get("totalLabel"), Value: money.format(totals.Total), Bold: true})
+	if included {
+		lines = append(lines, includedTaxLines(totals, money, l)...)
+	}
+	if invoice.HasSettlement() {
+		lines = append(lines, settlementLines(invoice, totals, settlement, l)...)
+	}
+	
+	// Show the deposit and what is left to pay. The stamp of a paid invoice
+	// already states the payment, so only the zero balance follows.
+	if invoice.PaidDate != "" {
+		lines = append(lines, totalLine{Label: l.get("balanceDueLabel"), Value: money.format(totals.BalanceDue), Bold: true})
+	} else if totals.AmountPaid != 0 {
+		lines = append(lines, totalLine{Label: l.get("amountPaidLabel"), Value: money.format(-totals.AmountPaid)})
+		
+		if totals.BalanceDue < 0 {
+			// Overpayment - show the credit as a positive amount in the customer's favor
+			lines = append(lines, totalLine{Label: l.get("creditLabel"), Value: money.format(-totals.BalanceDue), Bold: true})
+		} else {
+			lines = append(lines, totalLine{Label: l.get("balanceDueLabel"), Value: money.format(totals.BalanceDue), Bold: true})
+		}
+	}
+	
+	if totals.Skonto > 0 {
+		lines = append(lines, skontoLines(invoice, totals, money, l)...)
+	}
+	
+	return lines
+}
+
+// skontoLines returns the early payment discount offered, e.g. "2% Skonto
+// bei Zahlung bis 25.10.2024", and the amount to pay when it is taken. The
+// number of days is shown if the invoice date can't be read.
+func skontoLines(invoice *models.Invoice, totals models.Totals, money amountFormatter, l labels) []totalLine {
+	note := formatPercent(invoice.SkontoPercent/100) + " "
+	if deadline := invoice.SkontoDate(); deadline != "" {
+		note += l.get("skontoNote") + " " + deadline
+	} else {
+		note += l.get("skontoWithinNote") + " " + strconv.Itoa(invoice.SkontoDays) + " " + l.get("skontoDaysLabel")
+	}
+	return []totalLine{
+		{Label: note, Note: true},
+		{Label: l.get("skontoAmountLabel"), Value: money.format(totals.BalanceWithSkonto)},
+	}
+}
+
+// sectionTotalLabel labels the subtotal of a section of items, e.g.
+// "Zwischensumme Entwicklung"
+func sectionTotalLabel(name string, l labels) string {
+	return l.get("subtotalLabel") + " " + name
+}
+
+// itemSummary returns the summary printed below the last item, e.g.
+// "3 Positionen, Gesamtmenge 12", or "" unless ShowItemSummary is set.
+// Items without a quantity count once, like in the totals.
+func itemSummary(invoice *models.Invoice, l labels) string {
+	if !invoice.ShowItemSummary || len(invoice.Items) == 0 {
+		return ""
+	}
+	
+	quantity := 0
+	for i := range invoice.Items {
+		if len(invoice.Quantities) > i {
+			quantity += invoice.Quantities[i]
+		} else {
+			quantity++
+		}
+	}
+	
+	return fmt.Sprintf("%d %s, %s %s", len(invoice.Items), l.get("itemCountLabel"),
+		l.get("totalQuantityLabel"), formatQuantity(quantity, invoice.QuantityDecimals))
+}
+
+// taxSummaryRows returns the tax summary of an invoice whose items are taxed
+// at different rates, as German invoices need it: a header, one row per rate
+// and their sum, each with the rate, net amount, tax and gross amount. The
+// sum adds up to the total before any rounding. Invoices with a single rate
+// have no summary.
+func taxSummaryRows(totals models.Totals, money amountFormatter, l labels) [][]string {
+	if len(totals.TaxBreakdown) < 2 {
+		return nil
+	}
+	
+	rows := [][]string{{l.get("taxRateLabel"), l.get("netLabel"), l.get("taxLabel"), l.get("grossLabel")}}
+	var net, tax float64
+	for _, line := range totals.TaxBreakdown {
+		rows = append(rows, []string{formatPercent(line.Rate), money.format(line.Net), money.format(line.Tax), money.format(line.Net + line.Tax)})
+		net += line.Net
+		tax += line.Tax
+	}
+	return append(rows, []string{l.get("totalLabel"), money.format(net), money.format(tax), money.format(net + tax)})
+}
+
+// taxLines returns the tax line, or the tax exemption note for exempt
+// invoices. The invoice's own note wins over the one of its language.
+func taxLines(invoice *models.Invoice, totals models.Totals, money amountFormatter, l labels) []totalLine {
+	if invoice.TaxExempt {
+		note := strings.TrimSpace(strings.ReplaceAll(invoice.TaxExemptNote, `\n`, "\n"))
+		if note == "" {
+			note = l.get("taxExemptNote")
+		}
+		return []totalLine{{Label: note, Note: true}}
+	}
+	
+	// Tax is negative only on a credit invoice
+	if totals.Tax == 0 {
+		return nil
+	}
+	
+	label := l.get("taxLabel")
+	if len(totals.TaxBreakdown) == 1 {
+		label += " (" + formatPercent(totals.TaxBreakdown[0].Rate) + ")"
+	}
+	return []totalLine{{Label: label, Value: money.format(totals.Tax)}}
+}
+
+// settlementLines returns the total converted to the settlement currency,
+// e.g. "entspricht (Kurs 1.08)", and a note that the amounts in the invoice
+// currency are binding
+func settlementLines(invoice *models.Invoice, totals models.Totals, settlement amountFormatter, l labels) []totalLine {
+	rate := strconv.FormatFloat(invoice.ExchangeRate, 'f', -1, 64)
+	label := l.get("settlementLabel") + " (" + l.get("exchangeRateLabel") + " " + rate + ")"
+	return []totalLine{
+		{Label: label, Value: settlement.format(totals.SettlementTotal)},
+		{Label: l.get("bindingAmountNote") + " " + invoice.Currency, Note: true},
+	}
+}
+
+// includedTaxLines returns the tax included in gross prices, one line per
+// rate such as "enthaltene MwSt. (19%)"
+func includedTaxLines(totals models.Totals, money amountFormatter, l labels) []totalLine {
+	var lines []totalLine
+	for _, line := range totals.TaxBreakdown {
+		if line.Tax == 0 {
+			continue
+		}
+		label := l.get("includedTaxLabel") + " (" + formatPercent(line.Rate) + ")"
+		lines = append(lines, totalLine{Label: label, Value: money.format(line.Tax)})
+	}
+	return lines
+}
+
+// discountLines returns the discount line if there is a discount, as "-€50.00"
+// for a fixed discount or "-10% (€50.00)" for a percentage
+func discountLines(invoice *models.Invoice, totals models.Totals, money amountFormatter, l labels) []totalLine {
+	if totals.Discount == 0 {
+		return nil
+	}
+	
+	value := money.format(-totals.Discount)
+	if invoice.DiscountType != models.DiscountFixed {
+		percent := strconv.FormatFloat(math.Round(invoice.Discount*10000)/100, 'f', -1, 64)
+		value = "-" + percent + "% (" + money.format(totals.Discount) + ")"
+	}
+	return []totalLine{{Label: l.get("discountLabel"), Value: value}}
+}
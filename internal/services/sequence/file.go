@@ -0,0 +1,116 @@
+package sequence
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// lockTimeout is how long Next waits for another process to release the lock
+	lockTimeout = 10 * time.Second
+	
+	// staleLockAge is the age after which a lock file is assumed to be left
+	// over from a crashed process and removed
+	staleLockAge = time.Minute
+)
+
+// FileStore keeps the last number of each year in a JSON file, e.g.
+// {"2024": 42}. A lock file next to it keeps processes on the same machine
+// or shared volume from handing out a number twice.
+type FileStore struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewFileStore creates a new FileStore for the given file, which is created
+// on first use
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Next increments and returns the number for the given year
+func (s *FileStore) Next(year int) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	unlock, err := s.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	
+	counters := make(map[string]int64)
+	data, err := os.ReadFile(s.path)
+	if err == nil {
+		if err := json.Unmarshal(data, &counters); err != nil {
+			return "", fmt.Errorf("invalid sequence file %s: %v", s.path, err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("unable to read sequence file: %v", err)
+	}
+	
+	key := strconv.Itoa(year)
+	counters[key]++
+	
+	data, err = json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(s.path, data); err != nil {
+		return "", fmt.Errorf("unable to write sequence file: %v", err)
+	}
+	
+	return formatNumber(year, counters[key]), nil
+}
+
+// lock creates the lock file, waiting while another process holds it, and
+// returns the function that releases it
+func (s *FileStore) lock() (func(), error) {
+	lockPath := s.path + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+	
+	for {
+		file, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			file.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("unable to lock sequence file: %v", err)
+		}
+		
+		if info, err := os.Stat(lockPath); err == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("sequence file %s is locked, remove %s if no other process is running", s.path, lockPath)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeFileAtomic replaces a file by renaming a temporary file over it, so a
+// crash never leaves a half-written file behind
+func writeFileAtomic(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
@@ -0,0 +1,90 @@
+package sequence
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RedisStore keeps the numbers in Redis, whose INCR is atomic, so any number
+// of servers can share one sequence. It speaks the Redis protocol directly
+// and opens a connection per number, which is plenty for invoices.
+type RedisStore struct {
+	addr     string
+	password string
+	timeout  time.Duration
+}
+
+// NewRedisStore creates a new RedisStore for a server at addr, e.g. localhost:6379
+func NewRedisStore(addr, password string) (*RedisStore, error) {
+	if addr == "" {
+		return nil, fmt.Errorf("redis sequence backend requires sequenceRedisAddr")
+	}
+	
+	return &RedisStore{
+		addr:     addr,
+		password: password,
+		timeout:  10 * time.Second,
+	}, nil
+}
+
+// Next increments and returns the number for the given year
+func (s *RedisStore) Next(year int) (string, error) {
+	conn, err := net.DialTimeout("tcp", s.addr, s.timeout)
+	if err != nil {
+		return "", fmt.Errorf("unable to connect to redis: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(s.timeout))
+	
+	reader := bufio.NewReader(conn)
+	if s.password != "" {
+		if _, err := command(conn, reader, "AUTH", s.password); err != nil {
+			return "", fmt.Errorf("redis authentication failed: %v", err)
+		}
+	}
+	
+	reply, err := command(conn, reader, "INCR", "invoice:sequence:"+strconv.Itoa(year))
+	if err != nil {
+		return "", fmt.Errorf("redis INCR failed: %v", err)
+	}
+	
+	n, err := strconv.ParseInt(reply, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("unexpected redis reply %q", reply)
+	}
+	return formatNumber(year, n), nil
+}
+
+// command sends a command and returns its simple string or integer reply
+func command(conn net.Conn, reader *bufio.Reader, args ...string) (string, error) {
+	var request strings.Builder
+	fmt.Fprintf(&request, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&request, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(request.String())); err != nil {
+		return "", err
+	}
+	
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", fmt.Errorf("empty reply")
+	}
+	
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", fmt.Errorf("%s", line[1:])
+	default:
+		return "", fmt.Errorf("unexpected reply %q", line)
+	}
+}
@@ -0,0 +1,37 @@
+package sequence
+
+import (
+	"fmt"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// NextId is the invoice number that asks for the next number of the sequence
+// instead of a fixed one, e.g. --id next
+const NextId = "next"
+
+// Store hands out consecutive invoice numbers. Next must never return the
+// same number twice, even when several processes share the store.
+type Store interface {
+	Next(year int) (string, error)
+}
+
+// NewStore creates the store selected by the SequenceBackend setting. An
+// empty backend uses the file store, which suits a single server; the CLI and
+// the web server share it as long as they read the same web config.
+func NewStore(config models.WebConfig) (Store, error) {
+	switch strings.ToLower(strings.TrimSpace(config.SequenceBackend)) {
+	case "", "file":
+		return NewFileStore(config.SequenceFile), nil
+	case "redis":
+		return NewRedisStore(config.SequenceRedisAddr, config.SequenceRedisPassword)
+	default:
+		return nil, fmt.Errorf("unknown sequence backend: %s (supported: file, redis)", config.SequenceBackend)
+	}
+}
+
+// formatNumber formats the n-th invoice number of a year, e.g. 2024-0042
+func formatNumber(year int, n int64) string {
+	return fmt.Sprintf("%d-%04d", year, n)
+}
@@ -0,0 +1,194 @@
+package sign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Object identifiers used in the CMS signature (RFC 5652, RFC 5035)
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type signedData struct {
+	Version          int
+	DigestAlgorithms asn1.RawValue
+	EncapContentInfo encapsulatedContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      asn1.RawValue
+}
+
+// encapsulatedContentInfo has no content, the signature is detached
+type encapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+}
+
+type signerInfo struct {
+	Version            int
+	SID                issuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type issuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// signingCertificateV2 binds the signature to the signing certificate, as
+// required for PAdES. The hash algorithm defaults to SHA-256 and is omitted.
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+// signDigest creates a detached CMS SignedData structure for a document
+// digest, with the signed attributes required by PAdES baseline signatures
+func (s *PKCS12Signer) signDigest(digest []byte) ([]byte, error) {
+	certHash := sha256.Sum256(s.certificate.Raw)
+	attributes, err := signedAttributes(digest, certHash[:])
+	if err != nil {
+		return nil, err
+	}
+	
+	// The signature covers the attributes encoded as a SET, not the [0] tag
+	signedAttrs, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attributes})
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(signedAttrs)
+	
+	signatureAlgorithm, err := signatureAlgorithmFor(s.key.Public())
+	if err != nil {
+		return nil, err
+	}
+	signature, err := s.key.Sign(rand.Reader, attrsDigest[:], crypto.SHA256)
+	if err != nil {
+		return nil, fmt.Errorf("signing failed: %v", err)
+	}
+	
+	info, err := asn1.Marshal(signerInfo{
+		Version: 1,
+		SID: issuerAndSerialNumber{
+			Issuer:       asn1.RawValue{FullBytes: s.certificate.RawIssuer},
+			SerialNumber: s.certificate.SerialNumber,
+		},
+		DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+		SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attributes},
+		SignatureAlgorithm: signatureAlgorithm,
+		Signature:          signature,
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	digestAlgorithm, err := asn1.Marshal(pkix.AlgorithmIdentifier{Algorithm: oidSHA256})
+	if err != nil {
+		return nil, err
+	}
+	
+	var certificates [][]byte
+	certificates = append(certificates, s.certificate.Raw)
+	for _, certificate := range s.chain {
+		certificates = append(certificates, certificate.Raw)
+	}
+	
+	content, err := asn1.Marshal(signedData{
+		Version:          1,
+		DigestAlgorithms: derSet(digestAlgorithm),
+		EncapContentInfo: encapsulatedContentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: bytes.Join(certificates, nil)},
+		SignerInfos:      derSet(info),
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: content},
+	})
+}
+
+// signedAttributes returns the DER encoded content type, message digest and
+// signing certificate attributes, sorted as required for a DER SET OF
+func signedAttributes(digest, certHash []byte) ([]byte, error) {
+	contentType, err := asn1.Marshal(oidData)
+	if err != nil {
+		return nil, err
+	}
+	messageDigest, err := asn1.Marshal(digest)
+	if err != nil {
+		return nil, err
+	}
+	signingCertificate, err := asn1.Marshal(signingCertificateV2{Certs: []essCertIDv2{{CertHash: certHash}}})
+	if err != nil {
+		return nil, err
+	}
+	
+	var encoded [][]byte
+	for _, attr := range []attribute{
+		{Type: oidContentType, Values: derSet(contentType)},
+		{Type: oidMessageDigest, Values: derSet(messageDigest)},
+		{Type: oidSigningCertificateV2, Values: derSet(signingCertificate)},
+	} {
+		b, err := asn1.Marshal(attr)
+		if err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, b)
+	}
+	
+	sort.Slice(encoded, func(i, j int) bool {
+		return bytes.Compare(encoded[i], encoded[j]) < 0
+	})
+	return bytes.Join(encoded, nil), nil
+}
+
+// signatureAlgorithmFor returns the CMS signature algorithm for a key
+func signatureAlgorithmFor(key crypto.PublicKey) (pkix.AlgorithmIdentifier, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue}, nil
+	case *ecdsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}, nil
+	default:
+		return pkix.AlgorithmIdentifier{}, fmt.Errorf("only RSA and ECDSA keys are supported")
+	}
+}
+
+// derSet wraps a single DER encoded element in a SET
+func derSet(element []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: element}
+}
@@ -0,0 +1,298 @@
+package sign
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// signatureSize is the space reserved for the CMS signature in bytes. It fits
+// an RSA-4096 signature with a chain of several certificates.
+const signatureSize = 16384
+
+var (
+	trailerPattern   = regexp.MustCompile(`(?s)trailer\s*<<(.*?)>>\s*startxref\s+(\d+)\s+%%EOF\s*$`)
+	sizePattern      = regexp.MustCompile(`/Size\s+(\d+)`)
+	rootPattern      = regexp.MustCompile(`/Root\s+(\d+)\s+0\s+R`)
+	infoPattern      = regexp.MustCompile(`^/Info\s+\d+\s+0\s+R`)
+	pagesPattern     = regexp.MustCompile(`/Pages\s+(\d+)\s+0\s+R`)
+	kidsPattern      = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+	referencePattern = regexp.MustCompile(`(\d+)\s+0\s+R`)
+	mediaBoxPattern  = regexp.MustCompile(`/MediaBox\s*\[\s*[-\d.]+\s+[-\d.]+\s+[-\d.]+\s+([-\d.]+)\s*\]`)
+)
+
+// signDocument appends a signature field to the field's page and signs the
+// result. The original bytes are left untouched, the signature is added as an
+// incremental update covering everything but the signature value itself.
+func signDocument(document []byte, field Field, name string, now time.Time, signDigest func([]byte) ([]byte, error)) ([]byte, error) {
+	// Only the last trailer is relevant if the document was updated before
+	trailerStart := bytes.LastIndex(document, []byte("trailer"))
+	if trailerStart < 0 {
+		return nil, fmt.Errorf("unsupported PDF: no xref trailer found")
+	}
+	trailer := trailerPattern.FindSubmatch(document[trailerStart:])
+	if trailer == nil {
+		return nil, fmt.Errorf("unsupported PDF: no xref trailer found")
+	}
+	trailerDict := string(trailer[1])
+	if strings.Contains(trailerDict, "/Encrypt") {
+		return nil, fmt.Errorf("unsupported PDF: encrypted documents cannot be signed")
+	}
+	
+	size, err := intMatch(sizePattern, trailerDict, "/Size")
+	if err != nil {
+		return nil, err
+	}
+	root, err := intMatch(rootPattern, trailerDict, "/Root")
+	if err != nil {
+		return nil, err
+	}
+	prevXref := string(trailer[2])
+	
+	catalog, err := findObject(document, root)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(catalog, "/AcroForm") {
+		return nil, fmt.Errorf("unsupported PDF: the document already has form fields")
+	}
+	
+	pagesID, err := intMatch(pagesPattern, catalog, "/Pages")
+	if err != nil {
+		return nil, err
+	}
+	pages, err := findObject(document, pagesID)
+	if err != nil {
+		return nil, err
+	}
+	kids := kidsPattern.FindStringSubmatch(pages)
+	if kids == nil {
+		return nil, fmt.Errorf("unsupported PDF: no pages found")
+	}
+	pageRefs := referencePattern.FindAllStringSubmatch(kids[1], -1)
+	if len(pageRefs) == 0 {
+		return nil, fmt.Errorf("unsupported PDF: no pages found")
+	}
+	index := len(pageRefs) - 1
+	if field.Page > 0 {
+		if field.Page > len(pageRefs) {
+			return nil, fmt.Errorf("cannot place the signature on page %d of %d", field.Page, len(pageRefs))
+		}
+		index = field.Page - 1
+	}
+	pageID, _ := strconv.Atoi(pageRefs[index][1])
+	page, err := findObject(document, pageID)
+	if err != nil {
+		return nil, err
+	}
+	
+	// PDF coordinates start at the bottom left of the page
+	pageHeight := 841.89 // A4
+	if match := mediaBoxPattern.FindStringSubmatch(page + pages); match != nil {
+		pageHeight, _ = strconv.ParseFloat(match[1], 64)
+	}
+	left := field.X
+	top := pageHeight - field.Y
+	right := field.X + field.Width
+	bottom := top - field.Height
+	
+	sigID, fieldID, appearanceID, fontID := size, size+1, size+2, size+3
+	
+	var buf bytes.Buffer
+	buf.Write(document)
+	if !bytes.HasSuffix(document, []byte("\n")) {
+		buf.WriteString("\n")
+	}
+	
+	offsets := make(map[int]int)
+	writeObject := func(id int, body string) {
+		offsets[id] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", id, body)
+	}
+	
+	// Signature dictionary with placeholders for the byte range and the signature
+	byteRangePlaceholder := fmt.Sprintf("[0 %-10d %-10d %-10d]", 0, 0, 0)
+	contentsPlaceholder := "<" + strings.Repeat("0", 2*signatureSize) + ">"
+	writeObject(sigID, fmt.Sprintf("<<\n/Type /Sig\n/Filter /Adobe.PPKLite\n/SubFilter /ETSI.CAdES.detached\n/Name %s\n/M %s\n/ByteRange %s\n/Contents %s\n>>",
+		pdfTextString(name), pdfTextString(pdfDate(now)), byteRangePlaceholder, contentsPlaceholder))
+	
+	writeObject(fieldID, fmt.Sprintf("<<\n/Type /Annot\n/Subtype /Widget\n/FT /Sig\n/T (Signature1)\n/V %d 0 R\n/F 4\n/Rect [%.2f %.2f %.2f %.2f]\n/P %d 0 R\n/AP << /N %d 0 R >>\n>>",
+		sigID, left, bottom, right, top, pageID, appearanceID))
+	
+	appearance := signatureAppearance(field, name, now)
+	writeObject(appearanceID, fmt.Sprintf("<<\n/Type /XObject\n/Subtype /Form\n/BBox [0 0 %.2f %.2f]\n/Resources << /Font << /F1 %d 0 R >> >>\n/Length %d\n>>\nstream\n%s\nendstream",
+		field.Width, field.Height, fontID, len(appearance), appearance))
+	
+	writeObject(fontID, "<<\n/Type /Font\n/Subtype /Type1\n/BaseFont /Helvetica\n/Encoding /WinAnsiEncoding\n>>")
+	
+	// Updated catalog and page referencing the new field
+	writeObject(root, insertIntoDict(catalog, fmt.Sprintf("  /AcroForm << /Fields [%d 0 R] /SigFlags 3 >>\n", fieldID)))
+	if strings.Contains(page, "/Annots [") {
+		writeObject(pageID, strings.Replace(page, "/Annots [", fmt.Sprintf("/Annots [%d 0 R ", fieldID), 1))
+	} else {
+		writeObject(pageID, insertIntoDict(page, fmt.Sprintf("  /Annots [%d 0 R]\n", fieldID)))
+	}
+	
+	// Cross-reference section for the new and updated objects only
+	ids := make([]int, 0, len(offsets))
+	for id := range offsets {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	
+	xrefOffset := buf.Len()
+	buf.WriteString("xref\n")
+	for _, id := range ids {
+		fmt.Fprintf(&buf, "%d 1\n%010d 00000 n \n", id, offsets[id])
+	}
+	buf.WriteString("trailer\n<<\n")
+	fmt.Fprintf(&buf, "/Size %d\n/Root %d 0 R\n/Prev %s\n", fontID+1, root, prevXref)
+	if info := infoEntry(trailerDict); info != "" {
+		fmt.Fprintf(&buf, "%s\n", info)
+	}
+	fmt.Fprintf(&buf, ">>\nstartxref\n%d\n%%%%EOF\n", xrefOffset)
+	
+	signed := buf.Bytes()
+	
+	// The byte range covers everything except the hex encoded signature
+	contentsStart := offsets[sigID] + bytes.Index(signed[offsets[sigID]:], []byte(contentsPlaceholder))
+	contentsEnd := contentsStart + len(contentsPlaceholder)
+	byteRange := fmt.Sprintf("[0 %-10d %-10d %-10d]", contentsStart, contentsEnd, len(signed)-contentsEnd)
+	byteRangeStart := offsets[sigID] + bytes.Index(signed[offsets[sigID]:], []byte(byteRangePlaceholder))
+	copy(signed[byteRangeStart:], byteRange)
+	
+	hash := sha256.New()
+	hash.Write(signed[:contentsStart])
+	hash.Write(signed[contentsEnd:])
+	
+	signature, err := signDigest(hash.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) > signatureSize {
+		return nil, fmt.Errorf("signature of %d bytes does not fit the reserved %d bytes, the certificate chain is too large", len(signature), signatureSize)
+	}
+	copy(signed[contentsStart+1:], hex.EncodeToString(signature))
+	
+	return signed, nil
+}
+
+// findObject returns the dictionary of an indirect object
+func findObject(document []byte, id int) (string, error) {
+	pattern := regexp.MustCompile(fmt.Sprintf(`(?s)(?:^|\s)%d 0 obj\s*(<<.*?>>)\s*endobj`, id))
+	matches := pattern.FindAllSubmatch(document, -1)
+	if matches == nil {
+		return "", fmt.Errorf("unsupported PDF: object %d not found", id)
+	}
+	// The last definition wins in case of earlier incremental updates
+	return string(matches[len(matches)-1][1]), nil
+}
+
+// intMatch returns the first integer group of a pattern
+func intMatch(pattern *regexp.Regexp, text, name string) (int, error) {
+	match := pattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, fmt.Errorf("unsupported PDF: %s not found", name)
+	}
+	return strconv.Atoi(match[1])
+}
+
+// infoEntry returns the /Info entry of a trailer, either a reference or an
+// inline dictionary, so the document information survives the update
+func infoEntry(trailer string) string {
+	start := strings.Index(trailer, "/Info")
+	if start < 0 {
+		return ""
+	}
+	rest := trailer[start:]
+	if reference := infoPattern.FindString(rest); reference != "" {
+		return reference
+	}
+	
+	open := strings.Index(rest, "<<")
+	if open < 0 {
+		return ""
+	}
+	depth := 0
+	for i := open; i < len(rest)-1; i++ {
+		switch rest[i : i+2] {
+		case "<<":
+			depth++
+			i++
+		case ">>":
+			depth--
+			i++
+			if depth == 0 {
+				return rest[:i+1]
+			}
+		}
+	}
+	return ""
+}
+
+// insertIntoDict adds entries before the closing >> of a dictionary
+func insertIntoDict(dict, entries string) string {
+	end := strings.LastIndex(dict, ">>")
+	return dict[:end] + entries + dict[end:]
+}
+
+// signatureAppearance draws the visible signature: a thin frame with the
+// label, the signer name and the signing date
+func signatureAppearance(field Field, name string, now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "q 0.6 G 0.5 w 0.25 0.25 %.2f %.2f re S Q\n", field.Width-0.5, field.Height-0.5)
+	fmt.Fprintf(&b, "BT /F1 7 Tf 0.3 g 4 %.2f Td %s Tj ET\n", field.Height-10, pdfLatinString(field.Label))
+	fmt.Fprintf(&b, "BT /F1 9 Tf 0 g 4 %.2f Td %s Tj ET\n", field.Height-21, pdfLatinString(name))
+	fmt.Fprintf(&b, "BT /F1 7 Tf 0.3 g 4 %.2f Td %s Tj ET", field.Height-31, pdfLatinString(now.Format("02.01.2006 15:04:05 -07:00")))
+	return b.String()
+}
+
+// pdfDate formats a time as a PDF date string
+func pdfDate(t time.Time) string {
+	offset := t.Format("-07'00'")
+	if offset == "+00'00'" {
+		offset = "Z"
+	}
+	return "D:" + t.Format("20060102150405") + offset
+}
+
+// pdfTextString encodes a text string, as UTF-16 if it isn't plain ASCII
+func pdfTextString(text string) string {
+	for _, r := range text {
+		if r > 0x7e {
+			var b strings.Builder
+			b.WriteString("<FEFF")
+			for _, unit := range utf16.Encode([]rune(text)) {
+				fmt.Fprintf(&b, "%04X", unit)
+			}
+			b.WriteString(">")
+			return b.String()
+		}
+	}
+	return "(" + escapeLiteral(text) + ")"
+}
+
+// pdfLatinString encodes text for the WinAnsi encoded Helvetica font.
+// Characters outside Latin-1 are replaced with a question mark.
+func pdfLatinString(text string) string {
+	var b []byte
+	for _, r := range text {
+		if r > 0xff {
+			r = '?'
+		}
+		b = append(b, byte(r))
+	}
+	return "(" + escapeLiteral(string(b)) + ")"
+}
+
+// escapeLiteral escapes the special characters of a PDF literal string
+func escapeLiteral(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `(`, `\(`, `)`, `\)`, "\r", `\r`, "\n", `\n`).Replace(text)
+}
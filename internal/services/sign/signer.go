@@ -0,0 +1,132 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+	
+	"golang.org/x/crypto/pkcs12"
+)
+
+// Field describes the visible signature field. The position is given in points
+// from the top left of the page, like the rest of the invoice layout.
+type Field struct {
+	X, Y, Width, Height float64
+	
+	// Page is the 1-based page the field is placed on, 0 for the last page
+	Page int
+	
+	// Label is printed above the signer name, e.g. "Digital signiert von"
+	Label string
+}
+
+// Signer defines the interface for digitally signing a rendered invoice
+type Signer interface {
+	Sign(document []byte, field Field) ([]byte, error)
+}
+
+// PKCS12Signer signs PDFs with the key and certificate chain from a PKCS#12 file
+type PKCS12Signer struct {
+	key         crypto.Signer
+	certificate *x509.Certificate
+	chain       []*x509.Certificate
+}
+
+// NewPKCS12Signer loads the private key and certificates from a .p12 file.
+// The file must contain exactly one RSA or ECDSA key and its certificate;
+// any further certificates are embedded as the chain.
+func NewPKCS12Signer(path, password string) (Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read certificate: %v", err)
+	}
+	
+	blocks, err := pkcs12.ToPEM(data, password)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode certificate %s (wrong password or unsupported encryption): %v", path, err)
+	}
+	
+	var key crypto.Signer
+	var certificates []*x509.Certificate
+	for _, block := range blocks {
+		switch block.Type {
+		case "PRIVATE KEY":
+			if key != nil {
+				return nil, fmt.Errorf("certificate %s contains more than one private key", path)
+			}
+			if key, err = parsePrivateKey(block.Bytes); err != nil {
+				return nil, fmt.Errorf("unable to parse private key in %s: %v", path, err)
+			}
+		case "CERTIFICATE":
+			certificate, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return nil, fmt.Errorf("unable to parse certificate in %s: %v", path, err)
+			}
+			certificates = append(certificates, certificate)
+		}
+	}
+	if key == nil {
+		return nil, fmt.Errorf("certificate %s contains no private key", path)
+	}
+	
+	// The signing certificate is the one matching the key, the rest form the chain
+	signer := &PKCS12Signer{key: key}
+	for _, certificate := range certificates {
+		if signer.certificate == nil && publicKeysEqual(certificate.PublicKey, key.Public()) {
+			signer.certificate = certificate
+		} else {
+			signer.chain = append(signer.chain, certificate)
+		}
+	}
+	if signer.certificate == nil {
+		return nil, fmt.Errorf("certificate %s contains no certificate for its private key", path)
+	}
+	
+	now := time.Now()
+	if now.Before(signer.certificate.NotBefore) || now.After(signer.certificate.NotAfter) {
+		return nil, fmt.Errorf("certificate %s is only valid from %s to %s", path,
+			signer.certificate.NotBefore.Format("02.01.2006"), signer.certificate.NotAfter.Format("02.01.2006"))
+	}
+	
+	return signer, nil
+}
+
+// Name returns the signer name shown in the signature, the certificate's common name
+func (s *PKCS12Signer) Name() string {
+	if s.certificate.Subject.CommonName != "" {
+		return s.certificate.Subject.CommonName
+	}
+	return s.certificate.Subject.String()
+}
+
+// Sign adds a signature covering the whole document as an incremental update
+func (s *PKCS12Signer) Sign(document []byte, field Field) ([]byte, error) {
+	return signDocument(document, field, s.Name(), time.Now(), s.signDigest)
+}
+
+// parsePrivateKey parses a key as converted by pkcs12.ToPEM
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("only RSA and ECDSA keys are supported")
+}
+
+// publicKeysEqual reports whether two public keys are the same
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	switch key := a.(type) {
+	case *rsa.PublicKey:
+		return key.Equal(b)
+	case *ecdsa.PublicKey:
+		return key.Equal(b)
+	default:
+		return false
+	}
+}
@@ -0,0 +1,114 @@
+package upload
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// DefaultShareURLTemplate is the link to an uploaded file in the share, e.g.
+// https://cloud.example.com/index.php/s/CAr4Gfs9NFd9RqG?path=&files=Rechnung%202024.pdf
+// with a share of /index.php/s/CAr4Gfs9NFd9RqG
+const DefaultShareURLTemplate = "{url}{share}?path=&files={file}"
+
+// NextcloudUploader uploads files to a Nextcloud share using an external script
+type NextcloudUploader struct {
+	scriptPath       string
+	nextcloudURL     string
+	shareID          string
+	shareURLTemplate string
+}
+
+// NewNextcloudUploader creates a new NextcloudUploader instance. The template
+// of the returned links defaults to DefaultShareURLTemplate, and must give an
+// absolute URL. Without a Nextcloud URL or template, as in the shipped web
+// config, uploads aren't set up and the links are only checked on upload.
+func NewNextcloudUploader(scriptPath, nextcloudURL, shareID, shareURLTemplate string) (*NextcloudUploader, error) {
+	configured := nextcloudURL != "" || shareURLTemplate != ""
+	if shareURLTemplate == "" {
+		shareURLTemplate = DefaultShareURLTemplate
+	}
+	
+	uploader := &NextcloudUploader{
+		scriptPath:       scriptPath,
+		nextcloudURL:     nextcloudURL,
+		shareID:          shareID,
+		shareURLTemplate: shareURLTemplate,
+	}
+	
+	// Check the template once with a sample name rather than on the first upload
+	if configured {
+		if _, err := uploader.shareLink("invoice.pdf"); err != nil {
+			return nil, err
+		}
+	}
+	return uploader, nil
+}
+
+// Upload uploads a file to Nextcloud using the configured script
+func (u *NextcloudUploader) Upload(localPath string) (models.UploadResult, error) {
+	result := models.UploadResult{
+		Success: false,
+	}
+	
+	// Check if the upload script exists
+	if _, err := os.Stat(u.scriptPath); os.IsNotExist(err) {
+		return result, fmt.Errorf("upload script not found: %s", u.scriptPath)
+	}
+	
+	// Check if the file exists
+	if _, err := os.Stat(localPath); os.IsNotExist(err) {
+		return result, fmt.Errorf("file not found: %s", localPath)
+	}
+	
+	link, err := u.shareLink(filepath.Base(localPath))
+	if err != nil {
+		return result, err
+	}
+	
+	// Construct the share URL
+	shareURL := u.nextcloudURL + u.shareID
+	
+	// Run the upload script
+	cmd := exec.Command(u.scriptPath, localPath, shareURL)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	
+	err = cmd.Run()
+	if err != nil {
+		return result, fmt.Errorf("upload failed: %v\nStderr: %s", err, stderr.String())
+	}
+	
+	result.Success = true
+	result.URL = link
+	result.Message = "File uploaded successfully"
+	
+	return result, nil
+}
+
+// shareLink fills in the share URL template for a file: {url} is the
+// Nextcloud URL, {share} the share as configured and {file} the escaped file
+// name. Spaces are escaped as %20, which Nextcloud reads in any part of the URL.
+func (u *NextcloudUploader) shareLink(filename string) (string, error) {
+	link := strings.NewReplacer(
+		"{url}", strings.TrimRight(u.nextcloudURL, "/"),
+		"{share}", u.shareID,
+		"{file}", strings.ReplaceAll(url.QueryEscape(filename), "+", "%20"),
+	).Replace(u.shareURLTemplate)
+	
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("invalid share URL %q: %v", link, err)
+	}
+	if parsed.Scheme == "" || parsed.Host == "" {
+		return "", fmt.Errorf("invalid share URL %q: set nextcloudUrl and shareUrlTemplate to an absolute URL", link)
+	}
+	return link, nil
+}
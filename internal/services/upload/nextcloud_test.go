@@ -0,0 +1,126 @@
+package upload
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNextcloudShareLink(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		share    string
+		template string
+		filename string
+		want     string
+	}{
+		{
+			name:     "default template",
+			url:      "https://cloud.example.com/",
+			share:    "/index.php/s/CAr4Gfs9NFd9RqG",
+			filename: "Rechnung 2024.pdf",
+			want:     "https://cloud.example.com/index.php/s/CAr4Gfs9NFd9RqG?path=&files=Rechnung%202024.pdf",
+		},
+		{
+			name:     "file name with reserved characters",
+			url:      "https://cloud.example.com",
+			share:    "/s/abc",
+			filename: "R&D #1+2.pdf",
+			want:     "https://cloud.example.com/s/abc?path=&files=R%26D%20%231%2B2.pdf",
+		},
+		{
+			name:     "custom template",
+			url:      "https://cloud.example.com",
+			share:    "abc",
+			template: "{url}/index.php/s/{share}/download?files={file}",
+			filename: "Müller.pdf",
+			want:     "https://cloud.example.com/index.php/s/abc/download?files=M%C3%BCller.pdf",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uploader, err := NewNextcloudUploader("upload.sh", tt.url, tt.share, tt.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			link, err := uploader.shareLink(tt.filename)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if link != tt.want {
+				t.Errorf("shareLink(%q) = %q, want %q", tt.filename, link, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextcloudRejectsRelativeLinks(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		template string
+	}{
+		{"template without url", "https://cloud.example.com", "/s/{share}?files={file}"},
+		{"template with an empty url", "", "{url}{share}?files={file}"},
+	}
+	for _, tt := range tests {
+		if _, err := NewNextcloudUploader("upload.sh", tt.url, "/s/abc", tt.template); err == nil {
+			t.Errorf("%s: NewNextcloudUploader() accepted a relative share link", tt.name)
+		}
+	}
+}
+
+func TestNextcloudUnconfigured(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "upload.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "R-1.pdf")
+	if err := os.WriteFile(file, []byte("%PDF-1.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	// Without a URL the web server still starts, only uploading fails
+	uploader, err := NewNextcloudUploader(script, "", "", "")
+	if err != nil {
+		t.Fatalf("NewNextcloudUploader() without a URL: %v", err)
+	}
+	if result, err := uploader.Upload(file); err == nil || result.Success {
+		t.Errorf("Upload() = %+v, %v, want an error for the relative share link", result, err)
+	}
+}
+
+func TestNextcloudUpload(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "upload.sh")
+	args := filepath.Join(dir, "args")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$1 $2\" > "+args+"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "R 1.pdf")
+	if err := os.WriteFile(file, []byte("%PDF-1.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	uploader, err := NewNextcloudUploader(script, "https://cloud.example.com", "/index.php/s/abc", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := uploader.Upload(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://cloud.example.com/index.php/s/abc?path=&files=R%201.pdf"; !result.Success || result.URL != want {
+		t.Errorf("result = %+v, want the link %s", result, want)
+	}
+	
+	got, err := os.ReadFile(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := file + " https://cloud.example.com/index.php/s/abc\n"; string(got) != want {
+		t.Errorf("script arguments = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,173 @@
+package upload
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+	
+	"invoice/internal/models"
+)
+
+// S3Uploader uploads files to an S3 (or S3-compatible) bucket using
+// path-style requests signed with AWS Signature Version 4
+type S3Uploader struct {
+	endpoint  string
+	region    string
+	bucket    string
+	prefix    string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// NewS3Uploader creates a new S3Uploader. The endpoint defaults to AWS for the
+// given region; set it for S3-compatible services such as MinIO.
+func NewS3Uploader(endpoint, region, bucket, prefix, accessKey, secretKey string) (*S3Uploader, error) {
+	if bucket == "" || region == "" {
+		return nil, fmt.Errorf("s3 upload backend requires s3Bucket and s3Region")
+	}
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("s3 upload backend requires s3AccessKey and s3SecretKey")
+	}
+	
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	
+	return &S3Uploader{
+		endpoint:  strings.TrimRight(endpoint, "/"),
+		region:    region,
+		bucket:    bucket,
+		prefix:    strings.Trim(prefix, "/"),
+		accessKey: accessKey,
+		secretKey: secretKey,
+		client:    &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Upload puts the file into the bucket under the configured key prefix
+func (u *S3Uploader) Upload(localPath string) (models.UploadResult, error) {
+	result := models.UploadResult{
+		Success: false,
+	}
+	
+	data, err := os.ReadFile(localPath)
+	if err != nil {
+		return result, fmt.Errorf("file not found: %s", localPath)
+	}
+	
+	key := path.Join(u.prefix, filepath.Base(localPath))
+	objectURL := u.endpoint + "/" + uriEncode(u.bucket) + "/" + escapeKey(key)
+	
+	req, err := http.NewRequest(http.MethodPut, objectURL, bytes.NewReader(data))
+	if err != nil {
+		return result, fmt.Errorf("invalid s3 endpoint: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/pdf")
+	u.sign(req, data, time.Now().UTC())
+	
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("upload failed: %v", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return result, fmt.Errorf("upload failed: %s\n%s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	
+	result.Success = true
+	result.URL = objectURL
+	result.Message = "File uploaded successfully"
+	
+	return result, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to the request
+func (u *S3Uploader) sign(req *http.Request, payload []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(payload)
+	
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+	
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	
+	scope := date + "/" + u.region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+	
+	// Derive the signing key for this date, region and service
+	signingKey := hmacSHA256([]byte("AWS4"+u.secretKey), date)
+	signingKey = hmacSHA256(signingKey, u.region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+	
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.accessKey, scope, signedHeaders, signature))
+}
+
+// escapeKey URI-encodes each segment of an object key, keeping the slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = uriEncode(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// uriEncode encodes a path segment the way AWS signs it: everything but the
+// unreserved characters A-Z, a-z, 0-9, '-', '_', '.' and '~' is percent-encoded
+// with uppercase hex. url.PathEscape leaves characters such as '+' and '='
+// as they are, which S3 would encode and so reject the signature.
+func uriEncode(segment string) string {
+	var b strings.Builder
+	for i := 0; i < len(segment); i++ {
+		c := segment[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// sha256Hex returns the hex encoded SHA-256 hash of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data using key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
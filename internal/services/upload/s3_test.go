@@ -0,0 +1,54 @@
+package upload
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestURIEncode(t *testing.T) {
+	tests := []struct {
+		segment string
+		want    string
+	}{
+		{"Rechnung-1_a.b~c.pdf", "Rechnung-1_a.b~c.pdf"},
+		{"R 1.pdf", "R%201.pdf"},
+		{"a+b=c.pdf", "a%2Bb%3Dc.pdf"},
+		{"a&b;c,d:e@f$g.pdf", "a%26b%3Bc%2Cd%3Ae%40f%24g.pdf"},
+		{"Müller.pdf", "M%C3%BCller.pdf"},
+		{"a/b", "a%2Fb"},
+	}
+	for _, tt := range tests {
+		if got := uriEncode(tt.segment); got != tt.want {
+			t.Errorf("uriEncode(%q) = %q, want %q", tt.segment, got, tt.want)
+		}
+	}
+}
+
+func TestS3UploadSendsEncodedKey(t *testing.T) {
+	var requestURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+	}))
+	defer server.Close()
+	
+	localPath := filepath.Join(t.TempDir(), "R+1=a b.pdf")
+	if err := os.WriteFile(localPath, []byte("%PDF-1.4\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	
+	uploader, err := NewS3Uploader(server.URL, "eu-central-1", "invoices", "2024/03", "key", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := uploader.Upload(localPath); err != nil {
+		t.Fatal(err)
+	}
+	
+	// The path sent is the one that was signed, so it must be encoded the AWS way
+	if want := "/invoices/2024/03/R%2B1%3Da%20b.pdf"; requestURI != want {
+		t.Errorf("request URI = %q, want %q", requestURI, want)
+	}
+}
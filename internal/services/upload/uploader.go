@@ -0,0 +1,28 @@
+package upload
+
+import (
+	"fmt"
+	"strings"
+	
+	"invoice/internal/models"
+)
+
+// Uploader defines the interface for sharing a generated invoice
+type Uploader interface {
+	Upload(localPath string) (models.UploadResult, error)
+}
+
+// NewUploader creates the uploader selected by the UploadBackend setting.
+// An empty backend keeps the Nextcloud script for existing configurations.
+func NewUploader(config models.WebConfig) (Uploader, error) {
+	switch strings.ToLower(strings.TrimSpace(config.UploadBackend)) {
+	case "", "nextcloud":
+		return NewNextcloudUploader(config.UploadScript, config.NextcloudURL, config.NextcloudShare, config.ShareURLTemplate)
+	case "s3":
+		return NewS3Uploader(config.S3Endpoint, config.S3Region, config.S3Bucket, config.S3Prefix, config.S3AccessKey, config.S3SecretKey)
+	case "webdav":
+		return NewWebDAVUploader(config.WebDAVURL, config.WebDAVUser, config.WebDAVPassword)
+	default:
+		return nil, fmt.Errorf("unknown upload backend: %s (supported: nextcloud, s3, webdav)", config.UploadBackend)
+	}
+}
@@ -0,0 +1,77 @@
+package upload
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	
+	"invoice/internal/models"
+)
+
+// WebDAVUploader uploads files to a WebDAV collection with an HTTP PUT
+type WebDAVUploader struct {
+	baseURL  string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAVUploader creates a new WebDAVUploader for the collection at baseURL
+func NewWebDAVUploader(baseURL, user, password string) (*WebDAVUploader, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("webdav upload backend requires webdavUrl")
+	}
+	
+	return &WebDAVUploader{
+		baseURL:  strings.TrimRight(baseURL, "/"),
+		user:     user,
+		password: password,
+		client:   &http.Client{Timeout: 60 * time.Second},
+	}, nil
+}
+
+// Upload puts the file into the configured WebDAV collection
+func (u *WebDAVUploader) Upload(localPath string) (models.UploadResult, error) {
+	result := models.UploadResult{
+		Success: false,
+	}
+	
+	file, err := os.Open(localPath)
+	if err != nil {
+		return result, fmt.Errorf("file not found: %s", localPath)
+	}
+	defer file.Close()
+	
+	targetURL := u.baseURL + "/" + url.PathEscape(filepath.Base(localPath))
+	
+	req, err := http.NewRequest(http.MethodPut, targetURL, file)
+	if err != nil {
+		return result, fmt.Errorf("invalid webdav url: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/pdf")
+	if u.user != "" {
+		req.SetBasicAuth(u.user, u.password)
+	}
+	
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return result, fmt.Errorf("upload failed: %v", err)
+	}
+	defer resp.Body.Close()
+	
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return result, fmt.Errorf("upload failed: %s\n%s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	
+	result.Success = true
+	result.URL = targetURL
+	result.Message = "File uploaded successfully"
+	
+	return result, nil
+}
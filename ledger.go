@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"invoice/internal/services/currency"
+	"invoice/internal/services/ledger"
+
+	"github.com/spf13/cobra"
+)
+
+// ledgerEnv names the ledger generated invoices are recorded in. The ledger
+// is off unless it or --ledger is set.
+const ledgerEnv = "INVOICE_LEDGER"
+
+// defaultLedgerPath is the ledger list and total read without --ledger or INVOICE_LEDGER
+const defaultLedgerPath = "ledger.jsonl"
+
+// List command - prints the generated invoices recorded in the ledger
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the invoices recorded in the ledger",
+	Long:  `List the generated invoices recorded in the ledger, optionally only those of a date range or customer.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := ledgerRecords(cmd)
+		if err != nil {
+			return err
+		}
+
+		currencyService := currency.NewCurrencyService()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tID\tCUSTOMER\tTOTAL\tFILE")
+		for _, record := range records {
+			total := currency.FormatAmount(record.Total, currencyService.GetDecimals(record.Currency)) + " " + record.Currency
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", record.Date, record.Id, record.Customer, total, record.File)
+		}
+		return w.Flush()
+	},
+}
+
+// Total command - sums the invoices recorded in the ledger
+var totalCmd = &cobra.Command{
+	Use:   "total",
+	Short: "Sum the invoices recorded in the ledger",
+	Long:  `Sum the totals of the generated invoices recorded in the ledger per currency, optionally only those of a date range or customer.`,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		records, err := ledgerRecords(cmd)
+		if err != nil {
+			return err
+		}
+
+		totals := ledger.Totals(records)
+		currencies := make([]string, 0, len(totals))
+		for code := range totals {
+			currencies = append(currencies, code)
+		}
+		sort.Strings(currencies)
+
+		currencyService := currency.NewCurrencyService()
+		for _, code := range currencies {
+			fmt.Printf("%s %s\n", currency.FormatAmount(totals[code], currencyService.GetDecimals(code)), code)
+		}
+		if len(records) == 1 {
+			fmt.Println("1 invoice")
+		} else {
+			fmt.Printf("%d invoices\n", len(records))
+		}
+		return nil
+	},
+}
+
+func init() {
+	for _, cmd := range []*cobra.Command{listCmd, totalCmd} {
+		cmd.Flags().String("ledger", "", "Ledger file (defaults to $"+ledgerEnv+" or "+defaultLedgerPath+")")
+		cmd.Flags().String("from", "", "Only invoices dated on or after this date")
+		cmd.Flags().String("to", "", "Only invoices dated on or before this date")
+		cmd.Flags().String("customer", "", "Only invoices whose customer contains this text")
+	}
+}
+
+// ledgerRecords reads the ledger named by the command's flags and returns
+// the records matching its filters
+func ledgerRecords(cmd *cobra.Command) ([]ledger.Record, error) {
+	path := cmd.Flag("ledger").Value.String()
+	if path == "" {
+		path = os.Getenv(ledgerEnv)
+	}
+	if path == "" {
+		path = defaultLedgerPath
+	}
+
+	records, err := ledger.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	filter := ledger.Filter{
+		From:     cmd.Flag("from").Value.String(),
+		To:       cmd.Flag("to").Value.String(),
+		Customer: cmd.Flag("customer").Value.String(),
+	}
+	return filter.Apply(records)
+}
+
+// recordInvoice adds a generated invoice to the ledger, if one is set. A
+// failure is only a warning, as the invoice itself was written.
+func recordInvoice(ledgerPath string, invoice *Invoice, file string) {
+	if ledgerPath == "" {
+		ledgerPath = os.Getenv(ledgerEnv)
+	}
+	if ledgerPath == "" {
+		return
+	}
+
+	if err := ledger.Append(ledgerPath, ledger.NewRecord(invoice, file)); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invoice %s was not recorded in the ledger: %v\n", invoice.Id, err)
+	}
+}
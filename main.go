@@ -1,111 +1,51 @@
 package main
 
 import (
-        _ "embed"
-        "flag"
+        "bytes"
+        "encoding/json"
         "fmt"
+        "io"
         "log"
         "os"
+        "path/filepath"
         "strings"
         "sort"
         "time"
 
-        "github.com/signintech/gopdf"
+        "invoice/internal/config"
+        "invoice/internal/models"
+        "invoice/internal/services/currency"
+        "invoice/internal/services/email"
+        "invoice/internal/services/pdf"
+        "invoice/internal/services/sign"
+
         "github.com/spf13/cobra"
         "github.com/spf13/viper"
 )
 
-// Font paths for Inter fonts
-const (
-    InterRegularFont = "Inter/Inter Variable/Inter.ttf"
-    InterBoldFont    = "Inter/Inter Hinted for Windows/Desktop/Inter-Bold.ttf"
+// Invoice and Footer are shared with the internal services so the CLI,
+// the web server and the PDF renderer all work on the same model
+type (
+        Invoice = models.Invoice
+        Footer  = models.Footer
 )
 
-type Footer struct {
-        CompanyName      string `json:"companyName" yaml:"companyName"`
-        RegistrationInfo string `json:"registrationInfo" yaml:"registrationInfo"`
-        ShowRegistration bool   `json:"showRegistration" yaml:"showRegistration"`
-        VatId            string `json:"vatId" yaml:"vatId"`
-        ShowVatId        bool   `json:"showVatId" yaml:"showVatId"`
-        Address          string `json:"address" yaml:"address"`
-        City             string `json:"city" yaml:"city"`
-        Zip              string `json:"zip" yaml:"zip"`
-        Phone            string `json:"phone" yaml:"phone"`
-        Email            string `json:"email" yaml:"email"`
-        Website          string `json:"website" yaml:"website"`
-        BankName         string `json:"bankName" yaml:"bankName"`
-        BankIban         string `json:"bankIban" yaml:"bankIban"`
-        BankBic          string `json:"bankBic" yaml:"bankBic"`
-}
-
-type Invoice struct {
-        Id            string `json:"id" yaml:"id"`
-        IdSuffix      string `json:"idSuffix" yaml:"idSuffix"` // New field for invoice number suffix
-        Title         string `json:"title" yaml:"title"`
-
-        Logo string `json:"logo" yaml:"logo"`
-        From string `json:"from" yaml:"from"`
-        To   string `json:"to" yaml:"to"`
-        Date string `json:"date" yaml:"date"`
-        Due  string `json:"due" yaml:"due"`
-
-        Items      []string  `json:"items" yaml:"items"`
-        Quantities []int     `json:"quantities" yaml:"quantities"`
-        Rates      []float64 `json:"rates" yaml:"rates"`
-
-        Tax           float64 `json:"tax" yaml:"tax"`
-        TaxExempt     bool    `json:"taxExempt" yaml:"taxExempt"` // Tax exemption (Kleinunternehmer-Regelung)
-        Discount      float64 `json:"discount" yaml:"discount"`
-        Currency      string  `json:"currency" yaml:"currency"` 
-
-        Note string `json:"note" yaml:"note"`
-
-        // Footer information
-        Footer Footer `json:"footer" yaml:"footer"`
-}
-
-func DefaultFooter() Footer {
-        return Footer{
-                CompanyName:      "Firma GmbH",
-                RegistrationInfo: "Registergericht München, HRB 123456",
-                ShowRegistration: true,  // Default to showing registration info
-                VatId:            "USt-IdNr. DE123456789",
-                ShowVatId:        true,  // Default to showing VAT ID
-                Address:          "Musterstraße 123",
-                City:             "München",
-                Zip:              "80331",
-                Phone:            "+49 89 1234567",
-                Email:            "info@firma.de",
-                Website:          "www.firma.de",
-                BankName:         "Sparkasse München",
-                BankIban:         "DE12 3456 7890 1234 5678 90",
-                BankBic:          "ABCDEFGHXXX",
-        }
-}
-
 func DefaultInvoice() Invoice {
-        return Invoice{
-                Id:         time.Now().Format("20060102"),
-                IdSuffix:   "",  // Default empty suffix
-                Title:      "RECHNUNG", // Use German title
-                Rates:      []float64{25},
-                Quantities: []int{2},
-                Items:      []string{"Dienstleistung"}, // Changed to German default
-                From:       "Firma GmbH",  // Changed to German default
-                To:         "Kunde GmbH",  // Changed to German default
-                Date:       time.Now().Format("02.01.2006"), // German date format (day.month.year)
-                Due:        time.Now().AddDate(0, 0, 14).Format("02.01.2006"), // German date format
-                Tax:        0.19, // Default German VAT rate (19%)
-                TaxExempt:  false, // Default to tax inclusion
-                Discount:   0,
-                Currency:   "EUR", // Default to Euro
-                Footer:     DefaultFooter(), // Default footer information
-        }
+        return models.DefaultInvoice()
 }
 
 var (
-        importPath     string
+        importPaths    []string
+        ledgerPath     string
         output         string
+        namePattern    string
+        format         string
+        dpi            int
+        verbose        bool
+        signPath       string
+        signPassword   string
+        dryRun         bool
+        deterministic  bool
         file           = Invoice{}
         defaultInvoice = DefaultInvoice()
 )
@@ -113,36 +53,83 @@ var (
 func init() {
         viper.AutomaticEnv()
 
-        generateCmd.Flags().StringVar(&importPath, "import", "", "Imported file (.json/.yaml)")
-        generateCmd.Flags().StringVar(&file.Id, "id", time.Now().Format("20060102"), "ID")
+        rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "Print debug output to stderr")
+
+        generateCmd.Flags().StringArrayVar(&importPaths, "import", nil, "Imported file (.json/.yaml), or - for stdin, repeat to merge several files in order")
+        generateCmd.Flags().Bool("strict", false, "Reject unknown keys and wrongly typed values in the imported file, a zero or negative total, and percentage items not adding up to 100")
+        generateCmd.Flags().StringVar(&file.Id, "id", time.Now().Format("20060102"), "ID, or next for the next number of the sequence")
         generateCmd.Flags().StringVar(&file.IdSuffix, "id-suffix", "", "Invoice Number Suffix (e.g. -R1, -A, etc.)")
-        generateCmd.Flags().StringVar(&file.Title, "title", "RECHNUNG", "Title")
+        generateCmd.Flags().StringVar(&file.Title, "title", defaultInvoice.Title, "Title (defaults to the localized invoice title)")
+        generateCmd.Flags().StringVar(&file.DocumentType, "type", "", "Document type: invoice, credit-note, quote or reminder")
+        generateCmd.Flags().StringVar(&file.Language, "language", defaultInvoice.Language, "Label language (de, en)")
 
         generateCmd.Flags().Float64SliceVarP(&file.Rates, "rate", "r", defaultInvoice.Rates, "Rates")
         generateCmd.Flags().IntSliceVarP(&file.Quantities, "quantity", "q", defaultInvoice.Quantities, "Quantities")
+        generateCmd.Flags().BoolVar(&file.AllowCredits, "allow-credits", false, "Allow negative rates and quantities, e.g. to credit an overcharge")
         generateCmd.Flags().StringSliceVarP(&file.Items, "item", "i", defaultInvoice.Items, "Items")
+        generateCmd.Flags().StringArray("line", nil, "Item with its quantity and rate as \"description;quantity;rate\", repeat for several items")
 
         generateCmd.Flags().StringVarP(&file.Logo, "logo", "l", defaultInvoice.Logo, "Company logo")
+        generateCmd.Flags().StringVar(&file.BackgroundImage, "background", "", "Letterhead image drawn behind the invoice content")
         generateCmd.Flags().StringVarP(&file.From, "from", "f", defaultInvoice.From, "Issuing company")
+        generateCmd.Flags().BoolVar(&file.ShowSenderRule, "sender-rule", defaultInvoice.ShowSenderRule, "Draw a rule below the sender (--sender-rule=false hides it)")
         generateCmd.Flags().StringVarP(&file.To, "to", "t", defaultInvoice.To, "Recipient company")
+        generateCmd.Flags().StringVar(&file.CustomerNumber, "customer-number", "", "Customer number, printed next to the invoice number")
+        generateCmd.Flags().StringVar(&file.ShipTo, "ship-to", "", "Delivery address, if it differs from the recipient")
         generateCmd.Flags().StringVar(&file.Date, "date", defaultInvoice.Date, "Date")
         generateCmd.Flags().StringVar(&file.Due, "due", defaultInvoice.Due, "Payment due date")
+        generateCmd.Flags().StringVar(&file.ServiceDateFrom, "service-from", "", "Service date, or start of the service period (Leistungsdatum)")
+        generateCmd.Flags().StringVar(&file.ServiceDateTo, "service-to", "", "End of the service period (Leistungszeitraum)")
+        generateCmd.Flags().StringVar(&file.PaidDate, "paid-date", "", "Date the invoice was paid, adds a paid stamp")
 
         generateCmd.Flags().Float64Var(&file.Tax, "tax", defaultInvoice.Tax, "Tax")
         generateCmd.Flags().BoolVar(&file.TaxExempt, "tax-exempt", defaultInvoice.TaxExempt, "Tax exemption (Kleinunternehmer-Regelung)")
+        generateCmd.Flags().StringVar(&file.TaxExemptNote, "tax-exempt-note", "", "Note printed on tax exempt invoices instead of the § 19 UStG note")
         generateCmd.Flags().Float64VarP(&file.Discount, "discount", "d", defaultInvoice.Discount, "Discount")
+        generateCmd.Flags().StringVar(&file.DiscountType, "discount-type", defaultInvoice.DiscountType, "Discount type: percent (discount is a rate) or fixed (discount is an amount)")
+        generateCmd.Flags().BoolVar(&file.DiscountBeforeTax, "discount-before-tax", defaultInvoice.DiscountBeforeTax, "Charge tax on the discounted amount (false taxes the full subtotal)")
+        generateCmd.Flags().BoolVar(&file.PricesIncludeTax, "prices-include-tax", false, "Rates are gross prices, the tax included in them is shown instead of added")
         generateCmd.Flags().StringVarP(&file.Currency, "currency", "c", defaultInvoice.Currency, "Currency")
+        generateCmd.Flags().StringVar(&file.SettlementCurrency, "settlement-currency", "", "Currency the customer pays in, the total is also shown converted to it")
+        generateCmd.Flags().Float64Var(&file.ExchangeRate, "exchange-rate", 0, "Units of the settlement currency per unit of the invoice currency")
+        generateCmd.Flags().StringVar(&file.DecimalSeparator, "decimal-sep", "", "Decimal separator of amounts (defaults to .)")
+        generateCmd.Flags().StringVar(&file.ThousandsSeparator, "thousands-sep", "", "Separator between groups of thousands in amounts (defaults to none)")
+        generateCmd.Flags().Float64Var(&file.SkontoPercent, "skonto-percent", 0, "Early payment discount in percent, e.g. 2 (needs --skonto-days)")
+        generateCmd.Flags().IntVar(&file.SkontoDays, "skonto-days", 0, "Days after the invoice date the early payment discount may be taken")
+        generateCmd.Flags().StringVar(&file.RoundingMode, "rounding-mode", "", "Round the total: none, swiss5 (to 0.05) or nearest (to a whole amount)")
 
         generateCmd.Flags().StringVarP(&file.Note, "note", "n", "", "Note")
-        generateCmd.Flags().StringVarP(&output, "output", "o", "invoice.pdf", "Output file (.pdf)")
-
-        flag.Parse()
+        generateCmd.Flags().StringVar(&file.NotePosition, "note-position", "", "Where the note is printed: before-items, after-items or after-totals")
+        generateCmd.Flags().StringVar(&file.Density, "density", "", "Spacing of the item table and totals: normal or compact")
+        generateCmd.Flags().StringVar(&file.TableStyle, "table-style", "", "Lines of the item table: plain, ruled (rule below the header) or striped")
+
+        generateCmd.Flags().StringVar(&file.Footer.CompanyName, "footer-company", "", "Company name in the footer")
+        generateCmd.Flags().StringVar(&file.Footer.VatId, "footer-vat-id", "", "VAT ID in the footer")
+        generateCmd.Flags().StringVar(&file.Footer.BankName, "bank-name", "", "Bank name in the footer")
+        generateCmd.Flags().StringVar(&file.Footer.BankIban, "bank-iban", "", "IBAN in the footer")
+        generateCmd.Flags().StringVar(&file.Footer.BankBic, "bank-bic", "", "BIC in the footer")
+
+        generateCmd.Flags().StringArrayVar(&file.AppendPDFs, "append-pdf", nil, "PDF appended after the invoice, repeat to append several")
+        generateCmd.Flags().StringVar(&file.FontRegularPath, "font", "", "Regular font file (.ttf), defaults to the bundled Inter font")
+        generateCmd.Flags().StringVar(&file.FontBoldPath, "font-bold", "", "Bold font file (.ttf), defaults to the bundled Inter Bold font")
+        generateCmd.Flags().StringVarP(&output, "output", "o", "invoice.pdf", "Output file (.pdf), or - for stdout")
+        generateCmd.Flags().StringVar(&format, "format", "pdf", "Output format: pdf, html or png (first page, needs pdftoppm)")
+        generateCmd.Flags().IntVar(&dpi, "dpi", pdf.DefaultPNGDPI, "Resolution of --format png")
+        generateCmd.Flags().StringVar(&ledgerPath, "ledger", "", "Record the generated invoices in this ledger file (defaults to $"+ledgerEnv+", off if neither is set)")
+        generateCmd.Flags().StringVar(&namePattern, "filename", "", "Output filename pattern, e.g. {from}-{id}-{date}.pdf (defaults to <id>.pdf)")
+        generateCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the resolved invoice and its totals as JSON instead of generating it")
+        generateCmd.Flags().BoolVar(&deterministic, "deterministic", false, "Leave the document info out of the PDF, so no timestamp can make identical invoices differ")
+        generateCmd.Flags().StringVar(&signPath, "sign", "", "Sign the PDF with this PKCS#12 certificate (.p12)")
+        generateCmd.Flags().StringVar(&signPassword, "sign-password", "", "Password of the --sign certificate (defaults to $SIGN_PASSWORD)")
 }
 
 var rootCmd = &cobra.Command{
         Use:   "invoice",
         Short: "Invoice generates invoices from the command line.",
         Long:  `Invoice generates invoices from the command line.`,
+        PersistentPreRun: func(cmd *cobra.Command, args []string) {
+                setVerbose(verbose)
+        },
 }
 
 var generateCmd = &cobra.Command{
@@ -150,107 +137,283 @@ var generateCmd = &cobra.Command{
         Short: "Generate an invoice",
         Long:  `Generate an invoice`,
         RunE: func(cmd *cobra.Command, args []string) error {
-                if importPath != "" {
-                        err := importData(importPath, &file, cmd.Flags())
+                // --line sets items, quantities and rates together, so it can't
+                // be mixed with the separate flags
+                if lines, _ := cmd.Flags().GetStringArray("line"); len(lines) > 0 {
+                        for _, name := range []string{"item", "quantity", "rate"} {
+                                if cmd.Flags().Changed(name) {
+                                        return fmt.Errorf("--line cannot be combined with --%s", name)
+                                }
+                        }
+                        if _, _, _, err := parseLines(lines); err != nil {
+                                return err
+                        }
+                }
+
+                // A config file may hold a list of invoices, each rendered to its own PDF
+                invoices := []Invoice{file}
+                if len(importPaths) > 0 {
+                        var err error
+                        invoices, err = importInvoices(importPaths, cmd.Flags())
                         if err != nil {
                                 return fmt.Errorf("import failed: %v", err)
                         }
+                } else {
+                        // Environment variables such as INVOICE_CURRENCY override the defaults,
+                        // the flags given on the command line override both
+                        if err := config.NewConfigLoader().ApplyEnvironmentVariables(&invoices[0]); err != nil {
+                                return err
+                        }
+                        applyFlagOverrides(&invoices[0], cmd.Flags())
+
+                        if err := invoices[0].NormalizeDates(); err != nil {
+                                return fmt.Errorf("invalid date: %v", err)
+                        }
+                }
+                if len(invoices) > 1 && output != "invoice.pdf" {
+                        return fmt.Errorf("--output cannot be used when %s contains several invoices", importPaths[len(importPaths)-1])
+                }
+                if format != "pdf" && format != "html" && format != "png" {
+                        return fmt.Errorf("unknown format %q (supported: pdf, html, png)", format)
+                }
+                if format != "pdf" && signPath != "" {
+                        return fmt.Errorf("--sign only works with PDF output")
+                }
+                if namePattern != "" {
+                        if output != "invoice.pdf" {
+                                return fmt.Errorf("--output and --filename cannot be used together")
+                        }
+                        if err := config.CheckFilenamePattern(namePattern); err != nil {
+                                return err
+                        }
                 }
 
-                // Combine ID and IdSuffix for the full invoice number
-                fullInvoiceId := file.Id
-                if file.IdSuffix != "" {
-                        fullInvoiceId = file.Id + file.IdSuffix
+                // Refuse to render an invoice whose items, quantities and rates don't line up
+                for i := range invoices {
+                        if err := invoices[i].Validate(); err != nil {
+                                return fmt.Errorf("invalid invoice %s: %v", invoices[i].Id, err)
+                        }
                 }
 
-                pdf := gopdf.GoPdf{}
-                pdf.Start(gopdf.Config{
-                        PageSize: *gopdf.PageSizeA4,
-                })
-                pdf.SetMargins(40, 40, 40, 40)
-                pdf.AddPage()
-                // Check if font files exist before attempting to load them
-                if _, err := os.Stat(InterRegularFont); os.IsNotExist(err) {
-                        return fmt.Errorf("Error: The Inter fonts are missing. Please download and restore the Inter font files.\n"+
-                                "You can download them from: https://github.com/rsms/inter\n"+
-                                "Directories needed:\n"+
-                                "- %s\n"+
-                                "- %s", InterRegularFont, InterBoldFont)
+                // Catch invoices that bill nothing, e.g. because the rates were forgotten
+                strict, _ := cmd.Flags().GetBool("strict")
+                for i := range invoices {
+                        if err := invoices[i].CheckTotal(); err != nil {
+                                if strict {
+                                        return fmt.Errorf("invalid invoice %s: %v", invoices[i].Id, err)
+                                }
+                                fmt.Fprintf(os.Stderr, "Warning: Invoice %s: %v\n", invoices[i].Id, err)
+                        }
                 }
-                
-                if _, err := os.Stat(InterBoldFont); os.IsNotExist(err) {
-                        return fmt.Errorf("Error: The Inter fonts are missing. Please download and restore the Inter font files.\n"+
-                                "You can download them from: https://github.com/rsms/inter\n"+
-                                "Directories needed:\n"+
-                                "- %s\n"+
-                                "- %s", InterRegularFont, InterBoldFont)
+
+                // A dry run shows the result of the import, environment and
+                // flags without drawing sequence numbers or writing files
+                if dryRun {
+                        return printDryRun(invoices, os.Stdout)
                 }
-                
-                // Load the Inter font from file
-                err := pdf.AddTTFFont("Inter", InterRegularFont)
-                if err != nil {
-                        return fmt.Errorf("failed to load Inter font: %v", err)
+
+                // Draw numbers for --id next only once the invoices are known to be valid
+                if err := assignSequenceIds(invoices); err != nil {
+                        return err
                 }
-                
-                // Load the Inter-Bold font from file
-                err = pdf.AddTTFFont("Inter-Bold", InterBoldFont)
-                if err != nil {
-                        return fmt.Errorf("failed to load Inter-Bold font: %v", err)
+
+                pdfRenderer := pdf.NewPDFRenderer(currency.NewCurrencyService())
+                pdfRenderer.SetFontData(interRegularTTF, interBoldTTF)
+                pdfRenderer.SetDeterministic(deterministic)
+
+                var renderer pdf.Renderer = pdfRenderer
+                switch format {
+                case "html":
+                        renderer = pdf.NewHTMLRenderer(currency.NewCurrencyService())
+                case "png":
+                        renderer = pdf.NewPNGRenderer(pdfRenderer, dpi)
+                }
+
+                // Load the certificate before rendering so a bad one fails early
+                var signer sign.Signer
+                if signPath != "" {
+                        password := signPassword
+                        if password == "" {
+                                password = os.Getenv("SIGN_PASSWORD")
+                        }
+
+                        var err error
+                        signer, err = sign.NewPKCS12Signer(signPath, password)
+                        if err != nil {
+                                return fmt.Errorf("unable to load signing certificate: %v", err)
+                        }
+                        pdfRenderer.ReserveSignatureSpace()
                 }
 
-                writeLogo(&pdf, file.Logo, file.From)
-                writeTitle(&pdf, file.Title, fullInvoiceId, file.Date) // Use full invoice ID with suffix
-                writeBillTo(&pdf, file.To)
-                writeHeaderRow(&pdf)
-                subtotal := 0.0
-                // Check if we have any items
-                if len(file.Items) > 0 {
-                    for i := range file.Items {
-                        q := 1
-                        if len(file.Quantities) > i {
-                                q = file.Quantities[i]
+                // "-" writes the PDF to stdout for piping, without any status output
+                if output == "-" {
+                        printWarnings(&invoices[0])
+                        if signer != nil {
+                                return writeSignedPDF(pdfRenderer, signer, &invoices[0], os.Stdout)
                         }
+                        return renderer.Render(&invoices[0], os.Stdout)
+                }
 
-                        r := 0.0
-                        if len(file.Rates) > i {
-                                r = file.Rates[i]
+                for i := range invoices {
+                        invoice := &invoices[i]
+
+                        // Name the file by the --filename pattern or the invoice ID, unless an explicit output is provided
+                        outputFile, err := config.FormatFilename(namePattern, invoice)
+                        if err != nil {
+                                return err
+                        }
+                        if output != "invoice.pdf" {
+                            // User specified a custom output filename
+                            outputFile = output
+                        }
+                        extension := "." + format
+                        outputFile = strings.TrimSuffix(strings.TrimSuffix(outputFile, ".pdf"), extension) + extension
+
+                        printWarnings(invoice)
+                        if signer != nil {
+                                err = writeSignedPDFFile(pdfRenderer, signer, invoice, outputFile)
+                        } else {
+                                err = renderer.RenderToFile(invoice, outputFile)
+                        }
+                        if err != nil {
+                                return err
                         }
 
-                        writeRow(&pdf, file.Items[i], q, r)
-                        subtotal += float64(q) * r
-                    }
+                        recordInvoice(ledgerPath, invoice, outputFile)
+                        fmt.Printf("Generated %s\n", outputFile)
                 }
 
-                // Write notes first before totals
-                if file.Note != "" {
-                        writeNotes(&pdf, file.Note)
+                return nil
+        },
+}
+
+// dryRunResult is what --dry-run prints for each invoice
+type dryRunResult struct {
+        Invoice Invoice       `json:"invoice"`
+        Totals  models.Totals `json:"totals"`
+}
+
+// printDryRun writes each fully resolved invoice with its computed totals
+// as indented JSON, one document per invoice
+func printDryRun(invoices []Invoice, w io.Writer) error {
+        for i := range invoices {
+                data, err := json.MarshalIndent(dryRunResult{Invoice: invoices[i], Totals: models.ComputeInvoice(&invoices[i])}, "", "  ")
+                if err != nil {
+                        return err
+                }
+                if _, err := fmt.Fprintf(w, "%s\n", data); err != nil {
+                        return err
                 }
+        }
+        return nil
+}
+
+// printWarnings prints what an invoice may be missing, such as the service
+// date of a German invoice, to stderr
+func printWarnings(invoice *Invoice) {
+        for _, warning := range pdf.Warnings(invoice) {
+                fmt.Fprintf(os.Stderr, "Warning: %s\n", warning)
+        }
+}
+
+// writeSignedPDF renders the invoice and writes it with a visible signature
+// above the footer of its last page, before any appended PDFs
+func writeSignedPDF(renderer *pdf.PDFRenderer, signer sign.Signer, invoice *Invoice, w io.Writer) error {
+        var buf bytes.Buffer
+        if err := renderer.Render(invoice, &buf); err != nil {
+                return err
+        }
+
+        // The signature sits above the footer, whose height depends on the invoice
+        y, err := renderer.SignatureY(invoice)
+        if err != nil {
+                return err
+        }
+        page, err := renderer.SignaturePage(invoice)
+        if err != nil {
+                return err
+        }
 
-                // Then write totals (will be positioned on the right side)
-                writeTotals(&pdf, subtotal, subtotal*file.Tax, subtotal*file.Discount)
+        signed, err := signer.Sign(buf.Bytes(), sign.Field{
+                X:      pdf.SignatureX,
+                Y:      y,
+                Width:  pdf.SignatureWidth,
+                Height: pdf.SignatureHeight,
+                Page:   page,
+                Label:  pdf.Label(invoice, "signedByLabel"),
+        })
+        if err != nil {
+                return fmt.Errorf("signing failed: %v", err)
+        }
 
-                if file.Due != "" {
-                        writeDueDate(&pdf, file.Due)
+        _, err = w.Write(signed)
+        return err
+}
+
+// writeSignedPDFFile writes a signed invoice to a file
+func writeSignedPDFFile(renderer *pdf.PDFRenderer, signer sign.Signer, invoice *Invoice, filePath string) error {
+        out, err := os.Create(filePath)
+        if err != nil {
+                return fmt.Errorf("unable to create %s: %v", filePath, err)
+        }
+        defer out.Close()
+
+        if err := writeSignedPDF(renderer, signer, invoice, out); err != nil {
+                return err
+        }
+        return out.Close()
+}
+
+// Send command - emails a generated invoice
+var sendCmd = &cobra.Command{
+        Use:   "send",
+        Short: "Email a generated invoice",
+        Long:  `Email a generated invoice PDF to the client via SMTP. The recipient is taken from the invoice's "to" field unless --email-to is given.`,
+        RunE: func(cmd *cobra.Command, args []string) error {
+                importFile := cmd.Flag("import").Value.String()
+                pdfPath := cmd.Flag("pdf").Value.String()
+                if importFile == "" && pdfPath == "" {
+                        return fmt.Errorf("either --import or --pdf is required")
+                }
+
+                // The invoice provides the id, total and recipient for the message
+                invoice := DefaultInvoice()
+                if importFile != "" {
+                        if err := importData([]string{importFile}, &invoice, cmd.Flags()); err != nil {
+                                return fmt.Errorf("import failed: %v", err)
+                        }
+                } else {
+                        invoice = Invoice{Id: strings.TrimSuffix(filepath.Base(pdfPath), filepath.Ext(pdfPath))}
+                }
+
+                // Default to the file generate writes for this invoice
+                if pdfPath == "" {
+                        pdfPath = invoice.Id + invoice.IdSuffix + ".pdf"
+                }
+
+                webConfig, err := loadWebConfig(cmd.Flag("config").Value.String())
+                if err != nil {
+                        return fmt.Errorf("failed to load web config: %v", err)
+                }
+                if err := config.NewConfigLoader().ApplyEnvironmentVariables(&webConfig.Email); err != nil {
+                        return fmt.Errorf("invalid email configuration: %v", err)
                 }
-                writeFooter(&pdf, fullInvoiceId) // Use full invoice ID with suffix in footer
-                
-                // Always use invoice ID for the filename, unless an explicit output is provided
-                outputFile := fullInvoiceId + ".pdf"
-                if output != "invoice.pdf" {
-                    // User specified a custom output filename
-                    outputFile = strings.TrimSuffix(output, ".pdf") + ".pdf"
+
+                message, err := email.InvoiceMessage(&invoice, pdfPath, cmd.Flag("email-to").Value.String())
+                if err != nil {
+                        return err
                 }
-                
-                err = pdf.WritePdf(outputFile)
+
+                sender, err := email.NewSMTPSender(webConfig.Email)
                 if err != nil {
                         return err
                 }
 
-                fmt.Printf("Generated %s\n", outputFile)
-                
-                // Set the output variable to the actual file path used
-                output = outputFile
+                if err := sender.Send(message); err != nil {
+                        return fmt.Errorf("sending failed: %v", err)
+                }
 
+                fmt.Printf("Sent %s to %s\n", pdfPath, message.To)
                 return nil
         },
 }
@@ -279,9 +442,6 @@ var webCmd = &cobra.Command{
 			}
 		}
 		
-		fmt.Printf("Starting invoice web server on port %d...\n", webConfig.Port)
-		fmt.Printf("To access the web interface, open http://localhost:%d in your browser\n", webConfig.Port)
-		
 		return runWebServer(webConfig)
 	},
 }
@@ -333,6 +493,13 @@ var exportConfigCmd = &cobra.Command{
 func init() {
 	// Add web server flags
 	webCmd.Flags().String("config", "config/web_config.json", "Path to web server configuration file")
+
+	// Add send flags
+	sendCmd.Flags().String("import", "", "Invoice config (.json/.yaml) providing id, total and recipient")
+	sendCmd.Flags().Bool("strict", false, "Reject unknown keys and wrongly typed values in the imported file")
+	sendCmd.Flags().String("pdf", "", "Invoice PDF to send (defaults to <id>.pdf)")
+	sendCmd.Flags().String("email-to", "", "Recipient address (defaults to the address in the invoice's to field)")
+	sendCmd.Flags().String("config", "config/web_config.json", "Configuration file with the email settings")
 }
 
 func main() {
@@ -344,6 +511,12 @@ func main() {
 	rootCmd.AddCommand(generateCmd)
 	rootCmd.AddCommand(currencyCmd)
 	rootCmd.AddCommand(webCmd)
+	rootCmd.AddCommand(sendCmd)
+	rootCmd.AddCommand(batchCmd)
+	rootCmd.AddCommand(convertCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(totalCmd)
+	rootCmd.AddCommand(inspectCmd)
 	
 	err := rootCmd.Execute()
 	if err != nil {
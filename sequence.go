@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"invoice/internal/config"
+	"invoice/internal/services/sequence"
+)
+
+// sequenceConfigPath is the web config the CLI reads the sequence store
+// settings from, so it hands out numbers from the same store as the server
+const sequenceConfigPath = "config/web_config.json"
+
+// assignSequenceIds replaces the id "next" of every invoice with the next
+// number from the sequence store
+func assignSequenceIds(invoices []Invoice) error {
+	var store sequence.Store
+	for i := range invoices {
+		if invoices[i].Id != sequence.NextId {
+			continue
+		}
+
+		if store == nil {
+			var err error
+			store, err = cliSequenceStore()
+			if err != nil {
+				return err
+			}
+		}
+
+		id, err := store.Next(time.Now().Year())
+		if err != nil {
+			return fmt.Errorf("unable to get the next invoice number: %v", err)
+		}
+		debugLog.Printf("assigned invoice number %s", id)
+		invoices[i].Id = id
+	}
+	return nil
+}
+
+// cliSequenceStore opens the sequence store configured in the web config,
+// or the default file store if there is no web config
+func cliSequenceStore() (sequence.Store, error) {
+	webConfig := DefaultWebConfig()
+	if _, err := os.Stat(sequenceConfigPath); err == nil {
+		webConfig, err = loadWebConfig(sequenceConfigPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := config.NewConfigLoader().ApplyEnvironmentVariables(&webConfig); err != nil {
+		return nil, fmt.Errorf("invalid web configuration: %v", err)
+	}
+	return sequence.NewStore(webConfig)
+}
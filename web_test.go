@@ -0,0 +1,17 @@
+package main
+
+import (
+	"testing"
+
+	"invoice/internal/services/upload"
+)
+
+func TestShippedWebConfigUploader(t *testing.T) {
+	webConfig, err := loadWebConfig("config/web_config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := upload.NewUploader(webConfig); err != nil {
+		t.Errorf("NewUploader() with the shipped web config: %v", err)
+	}
+}